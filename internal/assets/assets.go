@@ -0,0 +1,183 @@
+package assets
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"gtft-crawler/internal/fetcher"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/storage"
+)
+
+// Downloader fetches binary resources referenced by article records and
+// stores them under the output directory.
+type Downloader struct {
+	fetcher *fetcher.Fetcher
+	storage *storage.Storage
+	verbose bool
+
+	// Figure downloads share their own concurrency limit and size cap,
+	// independent of the page worker pool.
+	figureSlots   chan struct{}
+	maxFigureSize int64
+
+	// wait, when set, holds each request back for the crawl's rate limit
+	wait func() error
+}
+
+func NewDownloader(f *fetcher.Fetcher, s *storage.Storage, verbose bool) *Downloader {
+	return &Downloader{
+		fetcher:     f,
+		storage:     s,
+		verbose:     verbose,
+		figureSlots: make(chan struct{}, 4),
+	}
+}
+
+// SetFigureLimits sets how many figure images may download at once across
+// all workers, and the largest figure (in bytes) that is kept; 0 means no cap.
+func (d *Downloader) SetFigureLimits(workers int, maxSize int64) {
+	if workers > 0 {
+		d.figureSlots = make(chan struct{}, workers)
+	}
+	d.maxFigureSize = maxSize
+}
+
+// SetRateLimit makes every download wait on wait first, normally the worker
+// pool's WaitRate, so asset requests count against the crawl's rate limit.
+func (d *Downloader) SetRateLimit(wait func() error) {
+	d.wait = wait
+}
+
+// GraphicalAbstract downloads the record's lead image to images/{id}.jpg
+// (or the matching extension for non-JPEG images) and records its path.
+func (d *Downloader) GraphicalAbstract(metadata *parser.PaperMetadata) error {
+	if metadata.GraphicalAbstractURL == "" {
+		return nil
+	}
+
+	body, contentType, err := d.fetch(metadata.GraphicalAbstractURL, 0)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("graphical abstract is not an image: %s", contentType)
+	}
+
+	name := d.storage.AssetPath(metadata.URL, "images", metadata.ID+imageExtension(contentType))
+	if err := d.storage.WriteFile(name, body); err != nil {
+		return fmt.Errorf("failed to save graphical abstract: %w", err)
+	}
+
+	metadata.GraphicalAbstractPath = name
+	if d.verbose {
+		fmt.Printf("[Assets] Saved graphical abstract: %s\n", name)
+	}
+
+	return nil
+}
+
+// Figures downloads the record's figure images into images/{id}/, named by
+// figure position, and records each saved path. Oversized images are
+// skipped. Individual failures don't stop the remaining figures.
+func (d *Downloader) Figures(metadata *parser.PaperMetadata) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed []string
+
+	for i := range metadata.Figures {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+
+			d.figureSlots <- struct{}{}
+			defer func() { <-d.figureSlots }()
+
+			figure := &metadata.Figures[i]
+			if err := d.figure(metadata, i+1, figure); err != nil {
+				mu.Lock()
+				failed = append(failed, fmt.Sprintf("figure %d: %v", i+1, err))
+				mu.Unlock()
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if len(failed) > 0 {
+		return fmt.Errorf("%d of %d figures not saved: %s", len(failed), len(metadata.Figures), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (d *Downloader) figure(metadata *parser.PaperMetadata, n int, figure *parser.Figure) error {
+	body, contentType, err := d.fetch(figure.ImageURL, d.maxFigureSize)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(contentType, "image/") {
+		return fmt.Errorf("not an image: %s", contentType)
+	}
+
+	name := d.storage.AssetPath(metadata.URL, "images", metadata.ID, fmt.Sprintf("fig-%d%s", n, imageExtension(contentType)))
+	if err := d.storage.WriteFile(name, body); err != nil {
+		return fmt.Errorf("failed to save figure: %w", err)
+	}
+
+	figure.Path = name
+	if d.verbose {
+		fmt.Printf("[Assets] Saved figure: %s\n", name)
+	}
+
+	return nil
+}
+
+// fetch downloads url and returns its body and sniffed content type. A
+// positive maxSize skips bodies larger than that, without downloading more
+// of them than the cap.
+func (d *Downloader) fetch(url string, maxSize int64) ([]byte, string, error) {
+	if err := d.waitRate(); err != nil {
+		return nil, "", err
+	}
+
+	var fetchResult *fetcher.FetchResult
+	var err error
+	if maxSize > 0 {
+		fetchResult, err = d.fetcher.FetchLimited(url, maxSize)
+	} else {
+		fetchResult, err = d.fetcher.Fetch(url)
+	}
+	if err != nil {
+		return nil, "", fmt.Errorf("fetch failed: %w", err)
+	}
+	if fetchResult.Error != nil {
+		return nil, "", fmt.Errorf("HTTP error: %w", fetchResult.Error)
+	}
+
+	return fetchResult.Body, http.DetectContentType(fetchResult.Body), nil
+}
+
+// waitRate waits for the rate limit set with SetRateLimit, if any.
+func (d *Downloader) waitRate() error {
+	if d.wait == nil {
+		return nil
+	}
+	if err := d.wait(); err != nil {
+		return fmt.Errorf("rate limit wait: %w", err)
+	}
+	return nil
+}
+
+func imageExtension(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
@@ -0,0 +1,142 @@
+package assets
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	"gtft-crawler/internal/parser"
+)
+
+// PDF downloads the record's full text to pdf/{id}.pdf and records its
+// path and SHA-256. A previously downloaded file that still verifies is
+// reused; a corrupt or truncated one is downloaded again.
+func (d *Downloader) PDF(metadata *parser.PaperMetadata) error {
+	if metadata.PDFURL == "" {
+		return nil
+	}
+
+	name := d.storage.AssetPath(metadata.URL, "pdf", metadata.ID+".pdf")
+
+	existing, err := d.storage.ReadFile(name)
+	switch {
+	case err == nil && verifyPDF(existing, -1) == nil:
+		d.recordPDF(metadata, name, existing)
+		return nil
+	case err == nil:
+		if d.verbose {
+			fmt.Printf("[Assets] Re-downloading corrupt PDF: %s\n", name)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return fmt.Errorf("failed to read existing PDF: %w", err)
+	}
+
+	if err := d.waitRate(); err != nil {
+		return err
+	}
+	fetchResult, err := d.fetcher.Fetch(metadata.PDFURL)
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	if fetchResult.Error != nil {
+		return fmt.Errorf("HTTP error: %w", fetchResult.Error)
+	}
+
+	if err := verifyPDF(fetchResult.Body, fetchResult.ContentLength); err != nil {
+		return fmt.Errorf("invalid PDF from %s: %w", metadata.PDFURL, err)
+	}
+
+	if err := d.storage.WriteFile(name, fetchResult.Body); err != nil {
+		return fmt.Errorf("failed to save PDF: %w", err)
+	}
+
+	d.recordPDF(metadata, name, fetchResult.Body)
+	if d.verbose {
+		fmt.Printf("[Assets] Saved PDF: %s\n", name)
+	}
+
+	return nil
+}
+
+func (d *Downloader) recordPDF(metadata *parser.PaperMetadata, name string, body []byte) {
+	sum := sha256.Sum256(body)
+	metadata.PDFPath = name
+	metadata.PDFSHA256 = hex.EncodeToString(sum[:])
+	setPDFSize(metadata, int64(len(body)))
+}
+
+// PDFSize fills the record's PDF size from a HEAD request, without
+// downloading the file. Servers that don't report a length leave it empty.
+func (d *Downloader) PDFSize(metadata *parser.PaperMetadata) error {
+	if metadata.PDFURL == "" || metadata.PDFBytes > 0 {
+		return nil
+	}
+
+	if err := d.waitRate(); err != nil {
+		return err
+	}
+	result, err := d.fetcher.Head(metadata.PDFURL)
+	if err != nil {
+		return fmt.Errorf("HEAD failed: %w", err)
+	}
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.ContentLength <= 0 {
+		return errors.New("server did not report a Content-Length")
+	}
+
+	setPDFSize(metadata, result.ContentLength)
+	return nil
+}
+
+func setPDFSize(metadata *parser.PaperMetadata, size int64) {
+	metadata.PDFBytes = size
+	metadata.PDFSize = formatSize(size)
+}
+
+// formatSize renders a byte count the way the journal site does, e.g. "1.2MB".
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%dB", size)
+	}
+
+	value := float64(size)
+	suffixes := []string{"KB", "MB", "GB", "TB"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.1f%s", value, suffixes[i])
+}
+
+// verifyPDF checks that body is a complete PDF: non-empty, starting with the
+// %PDF- magic bytes, ending with an %%EOF marker, and matching the declared
+// Content-Length (pass -1 when unknown).
+func verifyPDF(body []byte, contentLength int64) error {
+	if len(body) == 0 {
+		return errors.New("empty file")
+	}
+	if !bytes.HasPrefix(body, []byte("%PDF-")) {
+		return errors.New("missing %PDF- header")
+	}
+	if contentLength >= 0 && int64(len(body)) != contentLength {
+		return fmt.Errorf("got %d bytes, Content-Length was %d", len(body), contentLength)
+	}
+
+	// The trailer may be followed by whitespace or a few stray bytes
+	tail := body
+	if len(tail) > 1024 {
+		tail = tail[len(tail)-1024:]
+	}
+	if !bytes.Contains(tail, []byte("%%EOF")) {
+		return errors.New("missing %%EOF trailer, file looks truncated")
+	}
+
+	return nil
+}
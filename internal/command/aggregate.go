@@ -0,0 +1,184 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "aggregate",
+		Summary: "Summarize throughput and failure trends across the runs recorded in an output directory",
+		Run:     runAggregate,
+	})
+}
+
+// runSummary is one run as reported by aggregate.
+type runSummary struct {
+	RunID     string    `json:"run_id"`
+	StartTime time.Time `json:"start_time"`
+	Duration  string    `json:"duration"`
+	Processed int       `json:"processed"`
+	Saved     int       `json:"saved"`
+	Failed    int       `json:"failed"`
+	Skipped   int       `json:"skipped"`
+	// FailureRate is the percentage of processed URLs that failed
+	FailureRate float64 `json:"failure_rate"`
+	// Throughput is processed URLs per second
+	Throughput float64 `json:"throughput"`
+	Version    string  `json:"version,omitempty"`
+}
+
+// runTrend compares the earlier and later halves of the runs.
+type runTrend struct {
+	FailureRateBefore float64 `json:"failure_rate_before"`
+	FailureRateAfter  float64 `json:"failure_rate_after"`
+	ThroughputBefore  float64 `json:"throughput_before"`
+	ThroughputAfter   float64 `json:"throughput_after"`
+}
+
+type aggregateReport struct {
+	Runs        int           `json:"runs"`
+	Processed   int           `json:"processed"`
+	Saved       int           `json:"saved"`
+	Failed      int           `json:"failed"`
+	Skipped     int           `json:"skipped"`
+	FailureRate float64       `json:"failure_rate"`
+	Throughput  float64       `json:"throughput"`
+	Trend       *runTrend     `json:"trend,omitempty"`
+	History     []*runSummary `json:"history"`
+}
+
+func runAggregate(args []string) error {
+	fs := flag.NewFlagSet("aggregate", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Output directory whose runs are summarized")
+	last := fs.Int("last", 0, "Only summarize the most recent N runs (0 for all)")
+	format := fs.String("format", "text", "Output format: text or json")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+	if *last < 0 {
+		return fmt.Errorf("last must not be negative")
+	}
+
+	runs, err := storage.ReadRuns(*dir)
+	if err != nil {
+		return err
+	}
+	if len(runs) == 0 {
+		return fmt.Errorf("no runs recorded in %s", *dir)
+	}
+	if *last > 0 && len(runs) > *last {
+		runs = runs[len(runs)-*last:]
+	}
+
+	report := aggregateRuns(runs)
+
+	return writeOutput(*out, func(w io.Writer) error {
+		if *format == "json" {
+			return storage.EncodeJSON(w, report)
+		}
+		return writeAggregateText(w, report)
+	})
+}
+
+func summarizeRun(run *storage.RunStats) *runSummary {
+	summary := &runSummary{
+		RunID:     run.RunID,
+		StartTime: run.StartTime,
+		Duration:  run.Duration,
+		Processed: run.Saved + run.Failed + run.Skipped,
+		Saved:     run.Saved,
+		Failed:    run.Failed,
+		Skipped:   run.Skipped,
+		Version:   run.Crawler.Version,
+	}
+	if summary.RunID == "" {
+		summary.RunID = run.StartTime.UTC().Format("20060102T150405Z")
+	}
+	summary.FailureRate = percent(summary.Failed, summary.Processed)
+	if d := run.EndTime.Sub(run.StartTime); d > 0 {
+		summary.Throughput = round1(float64(summary.Processed) / d.Seconds())
+	}
+	return summary
+}
+
+func aggregateRuns(runs []*storage.RunStats) *aggregateReport {
+	report := &aggregateReport{Runs: len(runs)}
+	var elapsed time.Duration
+	for _, run := range runs {
+		summary := summarizeRun(run)
+		report.History = append(report.History, summary)
+		report.Processed += summary.Processed
+		report.Saved += summary.Saved
+		report.Failed += summary.Failed
+		report.Skipped += summary.Skipped
+		elapsed += run.EndTime.Sub(run.StartTime)
+	}
+	report.FailureRate = percent(report.Failed, report.Processed)
+	if elapsed > 0 {
+		report.Throughput = round1(float64(report.Processed) / elapsed.Seconds())
+	}
+
+	if len(runs) >= 2 {
+		half := len(runs) / 2
+		before, after := aggregateRuns(runs[:half]), aggregateRuns(runs[len(runs)-half:])
+		report.Trend = &runTrend{
+			FailureRateBefore: before.FailureRate,
+			FailureRateAfter:  after.FailureRate,
+			ThroughputBefore:  before.Throughput,
+			ThroughputAfter:   after.Throughput,
+		}
+	}
+
+	return report
+}
+
+func writeAggregateText(w io.Writer, report *aggregateReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "run\tduration\tprocessed\tsaved\tfailed\tskipped\tfailure %\turls/s\tversion")
+	for _, run := range report.History {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%d\t%d\t%.1f\t%.1f\t%s\n",
+			run.RunID, run.Duration, run.Processed, run.Saved, run.Failed, run.Skipped,
+			run.FailureRate, run.Throughput, run.Version)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	lines := []string{
+		"",
+		fmt.Sprintf("Runs: %d", report.Runs),
+		fmt.Sprintf("Processed: %d (%d saved, %d failed, %d skipped)", report.Processed, report.Saved, report.Failed, report.Skipped),
+		fmt.Sprintf("Failure rate: %.1f%%", report.FailureRate),
+		fmt.Sprintf("Throughput: %.1f URLs/s", report.Throughput),
+	}
+	if t := report.Trend; t != nil {
+		lines = append(lines,
+			fmt.Sprintf("Failure rate trend (earlier half -> later half): %.1f%% -> %.1f%%", t.FailureRateBefore, t.FailureRateAfter),
+			fmt.Sprintf("Throughput trend (earlier half -> later half): %.1f -> %.1f URLs/s", t.ThroughputBefore, t.ThroughputAfter))
+	}
+	_, err := fmt.Fprintln(w, strings.Join(lines, "\n"))
+	return err
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return round1(float64(n) / float64(total) * 100)
+}
+
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
@@ -0,0 +1,38 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "authors",
+		Summary: "Build an author index with likely-identical names grouped",
+		Run:     runAuthors,
+	})
+}
+
+func runAuthors(args []string) error {
+	fs := flag.NewFlagSet("authors", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	out := fs.String("out", "authors.json", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	records, _ = corpus.Dedupe(records)
+
+	idx := index.BuildAuthors(records)
+
+	return writeOutput(*out, func(w io.Writer) error {
+		return storage.EncodeJSON(w, idx)
+	})
+}
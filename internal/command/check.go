@@ -0,0 +1,195 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/fetcher"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/storage"
+	"gtft-crawler/internal/worker"
+)
+
+func init() {
+	register(&Command{
+		Name:    "check",
+		Summary: "Check the article, PDF and DOI links of stored records and report dead ones",
+		Run:     runCheck,
+	})
+}
+
+// linkRef is one place a link appears in the corpus.
+type linkRef struct {
+	ID   string `json:"id"`
+	Kind string `json:"kind"`
+}
+
+// deadLink is a link that couldn't be fetched, with every record using it.
+type deadLink struct {
+	URL    string    `json:"url"`
+	Status int       `json:"status,omitempty"`
+	Error  string    `json:"error"`
+	Refs   []linkRef `json:"refs"`
+}
+
+type checkReport struct {
+	Records int         `json:"records"`
+	Links   int         `json:"links"`
+	Dead    []*deadLink `json:"dead"`
+}
+
+func runCheck(args []string) error {
+	fs := flag.NewFlagSet("check", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Output directory whose records are checked")
+	kinds := fs.String("links", "url,pdf,doi", "Comma-separated links to check: url, pdf, doi")
+	resolver := fs.String("doi-resolver", "https://doi.org/", "Resolver DOIs are checked through")
+	workers := fs.Int("workers", 4, "Number of concurrent checks")
+	rate := fs.Int("rate", 2, "Maximum requests per second")
+	timeout := fs.Duration("timeout", 30*time.Second, "HTTP request timeout")
+	retries := fs.Int("retries", 2, "Maximum retry attempts when confirming a failed link")
+	format := fs.String("format", "text", "Output format: text or json")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+	if *workers <= 0 || *rate <= 0 {
+		return fmt.Errorf("workers and rate must be greater than 0")
+	}
+	enabled := make(map[string]bool)
+	for _, kind := range strings.Split(*kinds, ",") {
+		kind = strings.TrimSpace(kind)
+		switch kind {
+		case "url", "pdf", "doi":
+			enabled[kind] = true
+		case "":
+		default:
+			return fmt.Errorf("unknown link kind %q (want url, pdf or doi)", kind)
+		}
+	}
+
+	report := &checkReport{}
+	refs := make(map[string][]linkRef)
+	err := corpus.Walk(*dir, func(path string, metadata *parser.PaperMetadata) error {
+		report.Records++
+		for kind, link := range recordLinks(metadata, *resolver) {
+			if enabled[kind] {
+				refs[link] = append(refs[link], linkRef{ID: metadata.ID, Kind: kind})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if report.Records == 0 {
+		return fmt.Errorf("no records found in %s", *dir)
+	}
+
+	links := make([]string, 0, len(refs))
+	for link := range refs {
+		links = append(links, link)
+	}
+	sort.Strings(links)
+	report.Links = len(links)
+
+	f := fetcher.NewFetcher(*timeout, *retries, *rate, false, fetcher.DefaultTransportOptions())
+	pool := worker.NewPool(*workers, *rate, false)
+	var mu sync.Mutex
+	pool.SetOnResult(func(result worker.Result) {
+		if result.Error == nil {
+			return
+		}
+		dead := &deadLink{URL: result.Task.URL, Error: result.Error.Error(), Refs: refs[result.Task.URL]}
+		if status, ok := result.Data.(int); ok {
+			dead.Status = status
+		}
+		mu.Lock()
+		report.Dead = append(report.Dead, dead)
+		mu.Unlock()
+	})
+	pool.DiscardResults()
+	pool.Process(links, func(url string) (any, error) {
+		return checkLink(f, url)
+	})
+	pool.Stop()
+
+	sort.Slice(report.Dead, func(i, j int) bool {
+		return report.Dead[i].URL < report.Dead[j].URL
+	})
+
+	err = writeOutput(*out, func(w io.Writer) error {
+		if *format == "json" {
+			return storage.EncodeJSON(w, report)
+		}
+		return writeCheckText(w, report)
+	})
+	if err != nil {
+		return err
+	}
+	if len(report.Dead) > 0 {
+		return fmt.Errorf("%d of %d links are dead", len(report.Dead), report.Links)
+	}
+	return nil
+}
+
+// recordLinks returns a record's checkable links by kind.
+func recordLinks(metadata *parser.PaperMetadata, resolver string) map[string]string {
+	links := make(map[string]string)
+	if metadata.URL != "" {
+		links["url"] = metadata.URL
+	}
+	if metadata.PDFURL != "" {
+		links["pdf"] = metadata.PDFURL
+	}
+	if doi := parser.NormalizeDOI(metadata.DOI); doi != "" {
+		links["doi"] = resolver + doi
+	}
+	return links
+}
+
+// checkLink sends a HEAD request for url. Some servers reject or mishandle
+// HEAD, so a failure is confirmed with a GET before the link counts as dead;
+// the final status code is returned with the error.
+func checkLink(f *fetcher.Fetcher, url string) (any, error) {
+	head, err := f.Head(url)
+	if err == nil && head.Error == nil {
+		return head.StatusCode, nil
+	}
+
+	result, err := f.Fetch(url)
+	if err != nil {
+		return nil, err
+	}
+	if result.Error != nil {
+		return result.StatusCode, result.Error
+	}
+	return result.StatusCode, nil
+}
+
+func writeCheckText(w io.Writer, report *checkReport) error {
+	if len(report.Dead) > 0 {
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "id\tlink\turl\terror")
+		for _, dead := range report.Dead {
+			for _, ref := range dead.Refs {
+				fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", ref.ID, ref.Kind, dead.URL, dead.Error)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	_, err := fmt.Fprintf(w, "Checked %d links in %d records: %d dead\n", report.Links, report.Records, len(report.Dead))
+	return err
+}
@@ -0,0 +1,41 @@
+package command
+
+import (
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Command is a subcommand dispatched from the first command-line argument,
+// e.g. `gtft-crawler version`.
+type Command struct {
+	Name    string
+	Summary string
+	Run     func(args []string) error
+}
+
+var commands = map[string]*Command{}
+
+func register(cmd *Command) {
+	commands[cmd.Name] = cmd
+}
+
+// Lookup returns the subcommand with the given name.
+func Lookup(name string) (*Command, bool) {
+	cmd, ok := commands[name]
+	return cmd, ok
+}
+
+// PrintUsage writes a summary of all subcommands to w.
+func PrintUsage(w io.Writer) {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(w, "Commands:\n")
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-10s %s\n", name, commands[name].Summary)
+	}
+}
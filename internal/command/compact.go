@@ -0,0 +1,71 @@
+package command
+
+import (
+	"compress/gzip"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/export"
+)
+
+func init() {
+	register(&Command{
+		Name:    "compact",
+		Summary: "Merge all records into one sorted, deduplicated dataset file",
+		Run:     runCompact,
+	})
+}
+
+func runCompact(args []string) error {
+	fs := flag.NewFlagSet("compact", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	format := fs.String("format", "jsonl", "Dataset format: jsonl or json (array)")
+	out := fs.String("out", "", "Output file (default: corpus.<format> in the current directory, - for stdout)")
+	compress := fs.Bool("gzip", false, "Gzip the dataset (implied by a .gz output name)")
+	fs.Parse(args)
+
+	if *format != "jsonl" && *format != "json" {
+		return fmt.Errorf("unsupported compact format %q (use jsonl or json)", *format)
+	}
+	writer, err := export.Lookup(*format)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		*out = "corpus." + *format
+		if *compress {
+			*out += ".gz"
+		}
+	}
+	if strings.HasSuffix(*out, ".gz") {
+		*compress = true
+	}
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+
+	records, dropped := corpus.Dedupe(records)
+	fmt.Fprintf(os.Stderr, "Compacting %d records (%d duplicates dropped)\n", len(records), dropped)
+
+	return writeOutput(*out, func(w io.Writer) error {
+		if !*compress {
+			return writer(w, records)
+		}
+
+		gz := gzip.NewWriter(w)
+		if err := writer(gz, records); err != nil {
+			return err
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("failed to finish gzip stream: %w", err)
+		}
+		return nil
+	})
+}
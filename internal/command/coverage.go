@@ -0,0 +1,46 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "coverage",
+		Summary: "Report the share of records missing each field, by publication year",
+		Run:     runCoverage,
+	})
+}
+
+func runCoverage(args []string) error {
+	fs := flag.NewFlagSet("coverage", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	format := fs.String("format", "text", "Output format: text or json")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	records, _ = corpus.Dedupe(records)
+
+	coverage := index.BuildCoverage(records)
+
+	return writeOutput(*out, func(w io.Writer) error {
+		if *format == "json" {
+			return storage.EncodeJSON(w, coverage)
+		}
+		return coverage.WriteText(w)
+	})
+}
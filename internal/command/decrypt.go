@@ -0,0 +1,79 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "decrypt",
+		Summary: "Decrypt an output directory written with -encrypt",
+		Run:     runDecrypt,
+	})
+}
+
+func runDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of encrypted output")
+	out := fs.String("out", "", "Directory to write decrypted files to (required)")
+	keyFile := fs.String("key-file", "", "File holding the encryption key (default: $GTFT_ENCRYPTION_KEY)")
+	fs.Parse(args)
+
+	if *out == "" {
+		return fmt.Errorf("usage: decrypt [-dir DIR] [-key-file FILE] -out DIR")
+	}
+
+	key, err := storage.LoadKey(*keyFile)
+	if err != nil {
+		return err
+	}
+	aead, err := storage.NewCipher(key)
+	if err != nil {
+		return err
+	}
+
+	plain := storage.NewLocalBackend(*out)
+	count := 0
+
+	err = filepath.WalkDir(*dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() || !strings.HasSuffix(path, storage.EncryptedSuffix) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(*dir, path)
+		if err != nil {
+			return err
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), storage.EncryptedSuffix)
+
+		sealed, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		data, err := storage.Open(aead, name, sealed)
+		if err != nil {
+			return err
+		}
+
+		if err := plain.WriteFile(name, data); err != nil {
+			return err
+		}
+		count++
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "Decrypted %d files to %s\n", count, *out)
+	return nil
+}
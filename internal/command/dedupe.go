@@ -0,0 +1,151 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "dedupe",
+		Summary: "Find records of the same article under different IDs or files, and optionally merge or remove them",
+		Run:     runDedupe,
+	})
+}
+
+type duplicateRecord struct {
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	Path         string `json:"path"`
+	Completeness int    `json:"completeness"`
+}
+
+type duplicateReport struct {
+	Match      []string          `json:"match"`
+	Keep       duplicateRecord   `json:"keep"`
+	Duplicates []duplicateRecord `json:"duplicates"`
+}
+
+func runDedupe(args []string) error {
+	fs := flag.NewFlagSet("dedupe", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	format := fs.String("format", "text", "Report format: text or json")
+	out := fs.String("out", "-", "Report file (- for stdout)")
+	remove := fs.Bool("remove", false, "Delete every duplicate's file, keeping one record per article")
+	merge := fs.Bool("merge", false, "Fill the kept record's missing fields from its duplicates before deleting them (implies -remove)")
+	dryRun := fs.Bool("dry-run", false, "With -remove or -merge, report what would change without changing any records")
+	fs.Parse(args)
+
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+	*remove = *remove || *merge
+
+	var paths []string
+	var records []*parser.PaperMetadata
+	err := corpus.Walk(*dir, func(path string, metadata *parser.PaperMetadata) error {
+		paths = append(paths, path)
+		records = append(records, metadata)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no records found in %s", *dir)
+	}
+
+	entry := func(i int) duplicateRecord {
+		return duplicateRecord{ID: records[i].ID, URL: records[i].URL, Path: paths[i], Completeness: records[i].Completeness}
+	}
+	groups := index.FindDuplicates(records)
+	reports := make([]duplicateReport, len(groups))
+	duplicates := 0
+	for g, group := range groups {
+		reports[g] = duplicateReport{Match: group.Match, Keep: entry(group.Records[0])}
+		for _, i := range group.Records[1:] {
+			reports[g].Duplicates = append(reports[g].Duplicates, entry(i))
+		}
+		duplicates += len(group.Records) - 1
+	}
+
+	err = writeOutput(*out, func(w io.Writer) error {
+		if *format == "json" {
+			return storage.EncodeJSON(w, reports)
+		}
+		for _, report := range reports {
+			fmt.Fprintf(w, "keep %s %s (completeness %d, same %s)\n", report.Keep.ID, report.Keep.Path, report.Keep.Completeness, strings.Join(report.Match, ", "))
+			for _, dup := range report.Duplicates {
+				fmt.Fprintf(w, "  duplicate %s %s (completeness %d)\n", dup.ID, dup.Path, dup.Completeness)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Found %d duplicate records of %d articles among %d records\n", duplicates, len(groups), len(records))
+	if !*remove {
+		return nil
+	}
+
+	backend := storage.NewLocalBackend(*dir)
+	merged, removed := 0, 0
+	for _, group := range groups {
+		keep := group.Records[0]
+		if *merge {
+			var before bytes.Buffer
+			if err := storage.EncodeJSON(&before, records[keep]); err != nil {
+				return err
+			}
+			for _, i := range group.Records[1:] {
+				if err := index.MergeDuplicate(records[keep], records[i]); err != nil {
+					return err
+				}
+			}
+			var after bytes.Buffer
+			if err := storage.EncodeJSON(&after, records[keep]); err != nil {
+				return err
+			}
+			if !bytes.Equal(before.Bytes(), after.Bytes()) {
+				merged++
+				if !*dryRun {
+					name, err := filepath.Rel(*dir, paths[keep])
+					if err != nil {
+						return fmt.Errorf("failed to locate %s: %w", paths[keep], err)
+					}
+					if err := backend.WriteFile(filepath.ToSlash(name), after.Bytes()); err != nil {
+						return fmt.Errorf("failed to update %s: %w", paths[keep], err)
+					}
+				}
+			}
+		}
+
+		for _, i := range group.Records[1:] {
+			removed++
+			if *dryRun {
+				continue
+			}
+			if err := os.Remove(paths[i]); err != nil {
+				return fmt.Errorf("failed to remove %s: %w", paths[i], err)
+			}
+		}
+	}
+
+	if *dryRun {
+		fmt.Fprintf(os.Stderr, "%d kept records would gain fields from their duplicates and %d duplicates would be removed\n", merged, removed)
+	} else {
+		fmt.Fprintf(os.Stderr, "Merged fields into %d kept records and removed %d duplicates\n", merged, removed)
+	}
+	return nil
+}
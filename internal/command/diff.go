@@ -0,0 +1,232 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"path"
+	"slices"
+	"strings"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/manifest"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "diff",
+		Summary: "Report the records added, removed and changed between two crawls, as a changeset",
+		Run:     runDiff,
+	})
+}
+
+// changeEvent is one line of a jsonl changeset.
+type changeEvent struct {
+	Op     string                `json:"op"`
+	ID     string                `json:"id"`
+	Fields []index.FieldChange   `json:"fields,omitempty"`
+	Record *parser.PaperMetadata `json:"record,omitempty"`
+}
+
+func runDiff(args []string) error {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	oldPath := fs.String("old", "", "Earlier crawl: an output directory or its SHA256SUMS manifest")
+	newPath := fs.String("new", "data/output/all", "Later crawl: an output directory or its SHA256SUMS manifest")
+	format := fs.String("format", "json", "Changeset format: json, jsonl (one change per line, with the new record) or text")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	ignore := fs.String("ignore", "parsed_at", "Comma-separated fields whose changes don't count")
+	fs.Parse(args)
+
+	if *oldPath == "" {
+		return fmt.Errorf("-old is required")
+	}
+	if *format != "json" && *format != "jsonl" && *format != "text" {
+		return fmt.Errorf("unknown format %q (want json, jsonl or text)", *format)
+	}
+
+	oldIsDir, err := isDir(*oldPath)
+	if err != nil {
+		return err
+	}
+	newIsDir, err := isDir(*newPath)
+	if err != nil {
+		return err
+	}
+
+	var changes *index.Changeset
+	records := make(map[string]*parser.PaperMetadata)
+	if oldIsDir && newIsDir {
+		before, err := loadUnique(*oldPath)
+		if err != nil {
+			return err
+		}
+		after, err := loadUnique(*newPath)
+		if err != nil {
+			return err
+		}
+		changes, err = index.DiffRecords(before, after, strings.Split(*ignore, ","))
+		if err != nil {
+			return err
+		}
+		for _, m := range after {
+			records[corpus.Key(m)] = m
+		}
+	} else {
+		// A manifest only tells which records changed, so both sides are
+		// compared by checksum
+		before, err := recordSums(*oldPath, oldIsDir)
+		if err != nil {
+			return err
+		}
+		after, err := recordSums(*newPath, newIsDir)
+		if err != nil {
+			return err
+		}
+		changes = index.DiffChecksums(before, after)
+	}
+
+	err = writeOutput(*out, func(w io.Writer) error {
+		switch *format {
+		case "json":
+			return storage.EncodeJSON(w, changes)
+		case "jsonl":
+			return writeChangeEvents(w, changes, records)
+		}
+		for _, id := range changes.Added {
+			fmt.Fprintf(w, "+ %s\n", id)
+		}
+		for _, id := range changes.Removed {
+			fmt.Fprintf(w, "- %s\n", id)
+		}
+		for _, change := range changes.Changed {
+			fields := make([]string, len(change.Fields))
+			for i, field := range change.Fields {
+				fields[i] = field.Field
+			}
+			fmt.Fprintln(w, strings.TrimSpace("~ "+change.ID+" "+strings.Join(fields, ",")))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(os.Stderr, "%d added, %d removed, %d changed, %d unchanged\n",
+		len(changes.Added), len(changes.Removed), len(changes.Changed), changes.Unchanged)
+	return nil
+}
+
+// writeChangeEvents writes one JSON line per change, with the record as it
+// is now for additions and changes when it was loaded.
+func writeChangeEvents(w io.Writer, changes *index.Changeset, records map[string]*parser.PaperMetadata) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	var events []changeEvent
+	for _, id := range changes.Added {
+		events = append(events, changeEvent{Op: "added", ID: id, Record: records[id]})
+	}
+	for _, id := range changes.Removed {
+		events = append(events, changeEvent{Op: "removed", ID: id})
+	}
+	for _, change := range changes.Changed {
+		events = append(events, changeEvent{Op: "changed", ID: change.ID, Fields: change.Fields, Record: records[change.ID]})
+	}
+	for _, event := range events {
+		if err := encoder.Encode(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func isDir(path string) (bool, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return info.IsDir(), nil
+}
+
+// loadUnique reads the records under dir, one per journal and ID, keeping
+// the most recently parsed of any duplicates.
+func loadUnique(dir string) ([]*parser.PaperMetadata, error) {
+	byKey := make(map[string]*parser.PaperMetadata)
+	err := corpus.Walk(dir, func(path string, metadata *parser.PaperMetadata) error {
+		key := corpus.Key(metadata)
+		if known, ok := byKey[key]; !ok || metadata.ParsedAt > known.ParsedAt {
+			byKey[key] = metadata
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to load records: %w", err)
+	}
+
+	records := make([]*parser.PaperMetadata, 0, len(byKey))
+	for _, key := range slices.Sorted(maps.Keys(byKey)) {
+		records = append(records, byKey[key])
+	}
+	return records, nil
+}
+
+// recordSums returns the checksums of the record files of an output
+// directory or manifest, keyed by journal and ID as corpus.Key does.
+func recordSums(location string, dir bool) (map[string]string, error) {
+	var sums map[string]string
+	var err error
+	if dir {
+		sums, err = manifest.Sums(location)
+	} else {
+		sums, err = manifest.Read(location)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	records := make(map[string]string)
+	for name, sum := range sums {
+		if key, ok := recordKey(name); ok {
+			records[key] = sum
+		}
+	}
+	return records, nil
+}
+
+// recordKey returns the corpus.Key of an output file path, for record files
+// in the flat or sharded layout, either at the top or in a journal's
+// subdirectory of a multi-journal run.
+func recordKey(name string) (string, bool) {
+	dir, file := path.Split(name)
+	id, ok := strings.CutSuffix(file, ".json")
+	if !ok || file == "stats.json" || file == "crawl_state.json" || strings.HasPrefix(file, ".") {
+		return "", false
+	}
+
+	var dirs []string
+	if dir = strings.TrimSuffix(dir, "/"); dir != "" {
+		dirs = strings.Split(dir, "/")
+	}
+	if n := len(dirs); n > 0 && isShard(dirs[n-1]) {
+		dirs = dirs[:n-1]
+	}
+	switch {
+	case len(dirs) == 0:
+		return id, true
+	case len(dirs) == 1 && dirs[0] != storage.RunsDir && !strings.HasPrefix(dirs[0], "."):
+		return dirs[0] + "/" + id, true
+	default:
+		return "", false
+	}
+}
+
+// isShard reports whether dir is a -shard subdirectory: two hex digits.
+func isShard(dir string) bool {
+	return len(dir) == 2 && strings.Trim(dir, "0123456789abcdef") == ""
+}
@@ -0,0 +1,128 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/embed"
+)
+
+func init() {
+	register(&Command{
+		Name:    "embed",
+		Summary: "Embed abstracts through an embeddings API into a FAISS-readable vector file, for semantic search",
+		Run:     runEmbed,
+	})
+}
+
+func runEmbed(args []string) error {
+	fs := flag.NewFlagSet("embed", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	endpoint := fs.String("url", "", "OpenAI-compatible embeddings endpoint, e.g. http://localhost:11434/v1/embeddings (required; key from $GTFT_EMBED_API_KEY)")
+	model := fs.String("model", "", "Embedding model name sent to the endpoint")
+	lang := fs.String("lang", "zh", "Abstract to embed: zh or en")
+	out := fs.String("out", "embeddings", "Path prefix of the .fvecs, .ids and .json files written")
+	batch := fs.Int("batch", 64, "Abstracts sent per request")
+	fs.Parse(args)
+
+	if *endpoint == "" {
+		return fmt.Errorf("-url is required")
+	}
+	if *lang != "zh" && *lang != "en" {
+		return fmt.Errorf("-lang must be zh or en, got %q", *lang)
+	}
+	if *batch < 1 {
+		return fmt.Errorf("-batch must be at least 1")
+	}
+	field := "abstract_cn"
+	if *lang == "en" {
+		field = "abstract_en"
+	}
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	records, _ = corpus.Dedupe(records)
+	sort.Slice(records, func(i, j int) bool { return records[i].ID < records[j].ID })
+
+	// Vectors of abstracts unchanged since the last run are kept
+	previous := map[string][]float32{}
+	old, err := embed.Read(*out)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+	case err != nil:
+		return err
+	case old.Model == *model && old.Field == field:
+		for i, id := range old.IDs {
+			previous[old.Hashes[id]+id] = old.Vectors[i]
+		}
+	}
+
+	client := embed.New(*endpoint, *model, os.Getenv("GTFT_EMBED_API_KEY"))
+	store := &embed.Store{Model: *model, Field: field}
+	var ids, hashes, texts []string
+	var missing, reused int
+	flush := func() error {
+		if len(texts) == 0 {
+			return nil
+		}
+		vectors, err := client.Embed(texts)
+		if err != nil {
+			return err
+		}
+		for i, vector := range vectors {
+			if err := store.Add(ids[i], hashes[i], vector); err != nil {
+				return err
+			}
+		}
+		ids, hashes, texts = ids[:0], hashes[:0], texts[:0]
+		return nil
+	}
+
+	for _, metadata := range records {
+		text := metadata.AbstractCN
+		if *lang == "en" {
+			text = metadata.AbstractEN
+		}
+		if text = strings.TrimSpace(text); text == "" {
+			missing++
+			continue
+		}
+		hash := embed.TextHash(text)
+		if vector, ok := previous[hash+metadata.ID]; ok {
+			if err := flush(); err != nil {
+				return err
+			}
+			if err := store.Add(metadata.ID, hash, vector); err != nil {
+				return err
+			}
+			reused++
+			continue
+		}
+		ids, hashes, texts = append(ids, metadata.ID), append(hashes, hash), append(texts, text)
+		if len(texts) == *batch {
+			if err := flush(); err != nil {
+				return err
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return err
+	}
+	if store.Count == 0 {
+		return fmt.Errorf("no records in %s have a %s abstract", *dir, *lang)
+	}
+
+	if err := store.Write(*out); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d embeddings of dimension %d to %s.fvecs (%d reused, %d records without a %s abstract)\n",
+		store.Count, store.Dimension, *out, reused, missing, *lang)
+	return nil
+}
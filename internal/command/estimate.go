@@ -0,0 +1,69 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"gtft-crawler/internal/estimate"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "estimate",
+		Summary: "Predict the requests, duration and disk usage of crawling a URL list",
+		Run:     runEstimate,
+	})
+}
+
+func runEstimate(args []string) error {
+	fs := flag.NewFlagSet("estimate", flag.ExitOnError)
+	input := fs.String("input", "", "File containing URLs, as passed to a crawl (required)")
+	dir := fs.String("dir", "data/output/all", "Output directory the crawl would write to")
+	rate := fs.Int("rate", 5, "Maximum requests per second, as passed to the crawl")
+	retries := fs.Int("retries", 3, "Maximum retry attempts, as passed to the crawl")
+	refresh := fs.Bool("refresh", false, "Estimate a -refresh run, which rewrites existing records")
+	pdf := fs.Bool("pdf", false, "Estimate a -pdf run")
+	pdfSize := fs.Bool("pdf-size", false, "Estimate a -pdf-size run")
+	images := fs.Bool("images", false, "Estimate an -images run")
+	figures := fs.Bool("figures", false, "Estimate a -figures run")
+	format := fs.String("format", "text", "Output format: text or json")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	if *input == "" {
+		return fmt.Errorf("usage: estimate -input FILE [-dir DIR] [-rate N] [-retries N] [-refresh] [-pdf] [-format text|json]")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+	if *rate <= 0 {
+		return fmt.Errorf("rate must be greater than 0")
+	}
+
+	urls, err := readURLList(*input)
+	if err != nil {
+		return err
+	}
+
+	e, err := estimate.Compute(urls, *dir, estimate.Settings{
+		RateLimit:  *rate,
+		MaxRetries: *retries,
+		Refresh:    *refresh,
+		PDF:        *pdf,
+		PDFSize:    *pdfSize,
+		Images:     *images,
+		Figures:    *figures,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, func(w io.Writer) error {
+		if *format == "json" {
+			return storage.EncodeJSON(w, e)
+		}
+		return e.Write(w)
+	})
+}
@@ -0,0 +1,75 @@
+package command
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/export"
+)
+
+func init() {
+	register(&Command{
+		Name:    "export",
+		Summary: "Convert a crawled JSON directory to csv, bibtex, json, jsonl, parquet or zotero",
+		Run:     runExport,
+	})
+}
+
+func runExport(args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	format := fs.String("format", "jsonl", "Output format: csv, bibtex, json, jsonl, parquet, zotero")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	writer, err := export.Lookup(*format)
+	if err != nil {
+		return err
+	}
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+
+	return writeOutput(*out, func(w io.Writer) error {
+		return writer(w, records)
+	})
+}
+
+// writeOutput runs write against the named file, or stdout for "-",
+// buffering output and reporting close errors.
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "-" || path == "" {
+		bw := bufio.NewWriter(os.Stdout)
+		if err := write(bw); err != nil {
+			return err
+		}
+		return bw.Flush()
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %w", err)
+	}
+
+	bw := bufio.NewWriter(file)
+	if err := write(bw); err != nil {
+		file.Close()
+		return err
+	}
+	if err := bw.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write output file: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close output file: %w", err)
+	}
+
+	fmt.Fprintf(os.Stderr, "Wrote %s\n", path)
+	return nil
+}
@@ -0,0 +1,110 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"gtft-crawler/internal/fetcher"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "fixture",
+		Summary: "Capture a page and its parsed JSON as a regression fixture (fixture add <url>)",
+		Run:     runFixture,
+	})
+}
+
+var unsafeNameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+func runFixture(args []string) error {
+	if len(args) == 0 || args[0] != "add" {
+		return fmt.Errorf("usage: fixture add [options] <url>")
+	}
+
+	fs := flag.NewFlagSet("fixture add", flag.ExitOnError)
+	dir := fs.String("dir", "internal/parser/testdata/fixtures", "Directory to write fixture files to")
+	name := fs.String("name", "", "Fixture name (default: derived from the article ID)")
+	timeout := fs.Duration("timeout", 30*time.Second, "HTTP request timeout")
+	retries := fs.Int("retries", 3, "Maximum retry attempts")
+	fs.Parse(args[1:])
+
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: fixture add [options] <url>")
+	}
+	url := fs.Arg(0)
+
+	f := fetcher.NewFetcher(*timeout, *retries, 1, false, fetcher.DefaultTransportOptions())
+	fetchResult, err := f.Fetch(url)
+	if err != nil {
+		return fmt.Errorf("fetch failed: %w", err)
+	}
+	if fetchResult.Error != nil {
+		return fmt.Errorf("HTTP error: %w", fetchResult.Error)
+	}
+
+	metadata, err := parser.NewParser(false).Parse(fetchResult.Body, url)
+	if err != nil {
+		return fmt.Errorf("parse failed: %w", err)
+	}
+	// Keep captured JSON stable across re-captures of an unchanged page
+	metadata.ParsedAt = ""
+
+	if *name == "" {
+		*name = fixtureName(url)
+	} else if unsafeNameChars.MatchString(*name) || strings.Trim(*name, ".") == "" {
+		// Names become file names in -dir and must not lead out of it
+		return fmt.Errorf("invalid -name %q: use only letters, digits, '.', '_' and '-'", *name)
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create fixture directory: %w", err)
+	}
+
+	htmlPath := filepath.Join(*dir, *name+".html")
+	if err := os.WriteFile(htmlPath, fetchResult.Body, 0o644); err != nil {
+		return fmt.Errorf("failed to write HTML fixture: %w", err)
+	}
+
+	jsonPath := filepath.Join(*dir, *name+".json")
+	var buf bytes.Buffer
+	if err := storage.EncodeJSON(&buf, metadata); err != nil {
+		return err
+	}
+	if err := os.WriteFile(jsonPath, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write JSON fixture: %w", err)
+	}
+
+	fmt.Printf("Saved fixture %s (%s, %s)\n", *name, htmlPath, jsonPath)
+	if !metadata.Validate() {
+		fmt.Println("Warning: parsed metadata does not pass validation")
+	}
+
+	return nil
+}
+
+// fixtureName derives a filesystem-safe name from the article's UUID or DOI
+// so the same URL always maps to the same fixture files.
+func fixtureName(url string) string {
+	id := url
+	for _, marker := range []string{"/article/id/", "/article/doi/"} {
+		if idx := strings.LastIndex(url, marker); idx != -1 {
+			id = url[idx+len(marker):]
+			break
+		}
+	}
+
+	name := strings.Trim(unsafeNameChars.ReplaceAllString(id, "_"), "_")
+	if name == "" {
+		return "fixture"
+	}
+	return name
+}
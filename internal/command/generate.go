@@ -0,0 +1,112 @@
+package command
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/urlgen"
+)
+
+func init() {
+	register(&Command{
+		Name:    "generate",
+		Summary: "Expand a URL template over ranges or ID lists into a crawl input file",
+		Run:     runGenerate,
+	})
+}
+
+// stringList collects a repeatable string flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, " ") }
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+func runGenerate(args []string) error {
+	fs := flag.NewFlagSet("generate", flag.ExitOnError)
+	template := fs.String("template", "", "URL template with {name} placeholders (required)")
+	var varSpecs stringList
+	fs.Var(&varSpecs, "var", "Placeholder values: name=1-45, name=a,b,c or name=@file (repeatable)")
+	dir := fs.String("dir", "data/output/all", "Skip URLs whose record already exists in this directory (empty to disable)")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	if *template == "" {
+		return fmt.Errorf("usage: generate -template URL -var name=values [-var ...] [-dir DIR] [-out FILE]")
+	}
+
+	vars := make([]urlgen.Var, 0, len(varSpecs))
+	for _, spec := range varSpecs {
+		v, err := urlgen.ParseVar(spec)
+		if err != nil {
+			return err
+		}
+		vars = append(vars, v)
+	}
+
+	candidates, err := urlgen.Expand(*template, vars)
+	if err != nil {
+		return err
+	}
+
+	crawled, err := crawledIDs(*dir)
+	if err != nil {
+		return err
+	}
+
+	seen := make(map[string]bool, len(candidates))
+	urls := make([]string, 0, len(candidates))
+	skipped := 0
+	for _, url := range candidates {
+		if seen[url] {
+			continue
+		}
+		seen[url] = true
+
+		if crawled[url] || crawled[parser.IDFromURL(url)] {
+			skipped++
+			continue
+		}
+		urls = append(urls, url)
+	}
+
+	fmt.Fprintf(os.Stderr, "Generated %d URLs (%d already crawled)\n", len(urls), skipped)
+
+	return writeOutput(*out, func(w io.Writer) error {
+		for _, url := range urls {
+			if _, err := fmt.Fprintln(w, url); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// crawledIDs returns the IDs and URLs of records already in dir. A missing
+// directory is treated as empty.
+func crawledIDs(dir string) (map[string]bool, error) {
+	crawled := make(map[string]bool)
+	if dir == "" {
+		return crawled, nil
+	}
+
+	err := corpus.Walk(dir, func(path string, metadata *parser.PaperMetadata) error {
+		crawled[metadata.ID] = true
+		crawled[metadata.URL] = true
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load crawled records: %w", err)
+	}
+
+	return crawled, nil
+}
@@ -0,0 +1,69 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "impact",
+		Summary: "Compute citation distributions by year, authors' h-indexes within the journal and self-citation rates",
+		Run:     runImpact,
+	})
+}
+
+func runImpact(args []string) error {
+	fs := flag.NewFlagSet("impact", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records, linked by the link command")
+	format := fs.String("format", "json", "Output format: json or csv")
+	table := fs.String("table", "years", "Table written as CSV: years or authors")
+	top := fs.Int("top", 0, "Only list the N authors with the highest h-index (0 for all)")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	fs.Parse(args)
+
+	if *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q (want json or csv)", *format)
+	}
+	if *table != "years" && *table != "authors" {
+		return fmt.Errorf("unknown table %q (want years or authors)", *table)
+	}
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	records, _ = corpus.Dedupe(records)
+	if len(records) == 0 {
+		return fmt.Errorf("no records found in %s", *dir)
+	}
+
+	impact := index.BuildImpact(records)
+	if *top > 0 && len(impact.Authors) > *top {
+		impact.Authors = impact.Authors[:*top]
+	}
+
+	self := impact.SelfCitations
+	if self.References > 0 && self.Journal == 0 {
+		fmt.Fprintf(os.Stderr, "No references are linked to corpus articles; run link first for citations within the journal\n")
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d references cite the journal (%.1f%%), %d of them by a shared author (%.1f%%)\n",
+		self.Journal, self.References, self.JournalRate*100, self.Author, self.AuthorRate*100)
+
+	return writeOutput(*out, func(w io.Writer) error {
+		switch {
+		case *format == "json":
+			return storage.EncodeJSON(w, impact)
+		case *table == "authors":
+			return impact.WriteAuthorsCSV(w)
+		default:
+			return impact.WriteYearsCSV(w)
+		}
+	})
+}
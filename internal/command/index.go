@@ -0,0 +1,57 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "index",
+		Summary: "Build an inverted keyword index with per-year counts (JSON or SQLite)",
+		Run:     runIndex,
+	})
+}
+
+func runIndex(args []string) error {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	format := fs.String("format", "json", "Index format: json or sqlite")
+	out := fs.String("out", "", "Output file (default: keywords.json or keywords.db)")
+	fs.Parse(args)
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	records, _ = corpus.Dedupe(records)
+
+	idx := index.BuildKeywords(records)
+
+	switch *format {
+	case "json":
+		if *out == "" {
+			*out = "keywords.json"
+		}
+		return writeOutput(*out, func(w io.Writer) error {
+			return storage.EncodeJSON(w, idx)
+		})
+	case "sqlite":
+		if *out == "" {
+			*out = "keywords.db"
+		}
+		if err := idx.WriteSQLite(*out); err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s (%d keywords from %d records)\n", *out, len(idx.Keywords), idx.Records)
+		return nil
+	default:
+		return fmt.Errorf("unsupported index format %q (use json or sqlite)", *format)
+	}
+}
@@ -0,0 +1,87 @@
+package command
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"path/filepath"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "link",
+		Summary: "Link references to the corpus articles they cite, and notices to the articles they concern, storing the links in the records",
+		Run:     runLink,
+	})
+}
+
+func runLink(args []string) error {
+	fs := flag.NewFlagSet("link", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records, updated in place")
+	dryRun := fs.Bool("dry-run", false, "Report what would be linked without changing any records")
+	fs.Parse(args)
+
+	var paths []string
+	var records []*parser.PaperMetadata
+	var before [][]byte
+	err := corpus.Walk(*dir, func(path string, metadata *parser.PaperMetadata) error {
+		var buf bytes.Buffer
+		if err := storage.EncodeJSON(&buf, metadata); err != nil {
+			return err
+		}
+		paths = append(paths, path)
+		records = append(records, metadata)
+		before = append(before, buf.Bytes())
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("no records found in %s", *dir)
+	}
+
+	stats := index.LinkCitations(records)
+	notices := index.LinkNotices(records)
+
+	// Only rewrite records whose links changed
+	backend := storage.NewLocalBackend(*dir)
+	updated := 0
+	for i, metadata := range records {
+		var buf bytes.Buffer
+		if err := storage.EncodeJSON(&buf, metadata); err != nil {
+			return err
+		}
+		if bytes.Equal(buf.Bytes(), before[i]) {
+			continue
+		}
+		updated++
+		if *dryRun {
+			continue
+		}
+
+		name, err := filepath.Rel(*dir, paths[i])
+		if err != nil {
+			return fmt.Errorf("failed to locate %s: %w", paths[i], err)
+		}
+		if err := backend.WriteFile(filepath.ToSlash(name), buf.Bytes()); err != nil {
+			return fmt.Errorf("failed to update %s: %w", paths[i], err)
+		}
+	}
+
+	fmt.Printf("Linked %d of %d references in %d records to corpus articles (%d by DOI, %d by title and year; %d ambiguous left unlinked)\n",
+		stats.Resolved(), stats.References, stats.Records, stats.ByDOI, stats.ByTitle, stats.Ambiguous)
+	fmt.Printf("Linked %d of %d retraction and correction notices to the articles they concern (%d retractions, %d corrections)\n",
+		notices.Linked, notices.Retractions+notices.Corrections, notices.Retractions, notices.Corrections)
+	if *dryRun {
+		fmt.Printf("%d records would be updated\n", updated)
+	} else {
+		fmt.Printf("Updated %d records\n", updated)
+	}
+	return nil
+}
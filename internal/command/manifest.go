@@ -0,0 +1,41 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"gtft-crawler/internal/manifest"
+)
+
+func init() {
+	register(&Command{
+		Name:    "manifest",
+		Summary: "Write (and optionally sign) a SHA256SUMS manifest of an output directory",
+		Run:     runManifest,
+	})
+}
+
+func runManifest(args []string) error {
+	fs := flag.NewFlagSet("manifest", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Output directory to checksum")
+	sign := fs.String("sign", "", "Sign the manifest with minisign or gpg")
+	key := fs.String("sign-key", "", "minisign secret key file, or gpg key ID")
+	fs.Parse(args)
+
+	path, count, err := manifest.Write(*dir)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s (%d files)\n", path, count)
+
+	if *sign != "" {
+		sigPath, err := manifest.Sign(path, *sign, *key)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "Wrote %s\n", sigPath)
+	}
+
+	return nil
+}
@@ -0,0 +1,222 @@
+package command
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/state"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "plan",
+		Summary: "Show what a crawl of a URL list would do against an output directory",
+		Run:     runPlan,
+	})
+}
+
+// Plan actions, in the order they are summarised.
+const (
+	actionNew        = "new"
+	actionFailed     = "previously-failed"
+	actionRefreshDue = "refresh-due"
+	actionSkip       = "skip"
+)
+
+var planActions = []string{actionNew, actionFailed, actionRefreshDue, actionSkip}
+
+// planItem is what a crawl would do with one input URL.
+type planItem struct {
+	URL    string `json:"url"`
+	Action string `json:"action"`
+	ID     string `json:"id,omitempty"`
+	Detail string `json:"detail,omitempty"`
+}
+
+func runPlan(args []string) error {
+	fs := flag.NewFlagSet("plan", flag.ExitOnError)
+	input := fs.String("input", "", "File containing URLs, as passed to a crawl (required)")
+	dir := fs.String("dir", "data/output/all", "Output directory the crawl would write to")
+	refresh := fs.Bool("refresh", false, "Plan a -refresh run: every existing record is re-crawled")
+	refreshAfter := fs.Duration("refresh-after", 0, "Treat records parsed longer ago than this as due for a refresh, e.g. 720h (0 disables)")
+	minCompleteness := fs.Int("min-completeness", 0, "Treat records scoring below this completeness (0-100) as due for a refresh (0 disables)")
+	skipGone := fs.Bool("skip-gone", false, "Skip previously-failed URLs whose last response was 404 Not Found or 410 Gone")
+	format := fs.String("format", "text", "Output format: text or json")
+	out := fs.String("out", "-", "Output file for the plan (- for stdout)")
+	urlsOut := fs.String("urls", "", "Also write the URLs a crawl should fetch (all but skip) to this file, for use as -input")
+	fs.Parse(args)
+
+	if *input == "" {
+		return fmt.Errorf("usage: plan -input FILE [-dir DIR] [-refresh | -refresh-after DURATION] [-min-completeness N] [-skip-gone] [-format text|json] [-urls FILE]")
+	}
+	if *format != "text" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want text or json)", *format)
+	}
+	if *refreshAfter < 0 {
+		return fmt.Errorf("refresh-after must not be negative")
+	}
+	if *minCompleteness < 0 || *minCompleteness > 100 {
+		return fmt.Errorf("min-completeness must be between 0 and 100")
+	}
+
+	urls, err := readURLList(*input)
+	if err != nil {
+		return err
+	}
+
+	crawlState, err := storage.ReadState(*dir)
+	if err != nil {
+		return err
+	}
+
+	// Record IDs, their parse times and completeness, and the URLs they were
+	// crawled from
+	parsedAt := make(map[string]string)
+	completeness := make(map[string]int)
+	idByURL := make(map[string]string)
+	err = corpus.Walk(*dir, func(path string, metadata *parser.PaperMetadata) error {
+		parsedAt[metadata.ID] = metadata.ParsedAt
+		completeness[metadata.ID] = metadata.Completeness
+		idByURL[metadata.URL] = metadata.ID
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to load crawled records: %w", err)
+	}
+
+	now := time.Now()
+	seen := make(map[string]bool, len(urls))
+	items := make([]planItem, 0, len(urls))
+	for _, url := range urls {
+		item := planItem{URL: url}
+
+		entry, known := crawlState.Get(url)
+		item.ID = entry.ID
+		if item.ID == "" {
+			item.ID = idByURL[url]
+		}
+		if item.ID == "" {
+			item.ID = parser.IDFromURL(url)
+		}
+		parsed, exists := parsedAt[item.ID]
+
+		switch {
+		case seen[url]:
+			item.Action = actionSkip
+			item.Detail = "duplicate in input"
+		case known && *skipGone && entry.Gone():
+			item.Action = actionSkip
+			item.Detail = fmt.Sprintf("gone (HTTP %d)", entry.StatusCode)
+		case known && entry.Status == state.StatusFailed:
+			item.Action = actionFailed
+			last := entry.LastAttempt.Format(time.RFC3339)
+			if entry.FetchAttempts > 0 {
+				last += fmt.Sprintf(" after %d requests", entry.FetchAttempts)
+			}
+			item.Detail = fmt.Sprintf("%d attempts, last %s: %s", entry.Attempts, last, entry.Error)
+		case !exists:
+			item.Action = actionNew
+		case *refresh:
+			item.Action = actionRefreshDue
+			item.Detail = "-refresh"
+		case *refreshAfter > 0 && olderThan(parsed, now.Add(-*refreshAfter)):
+			item.Action = actionRefreshDue
+			item.Detail = "parsed " + parsed
+		case completeness[item.ID] < *minCompleteness:
+			item.Action = actionRefreshDue
+			item.Detail = fmt.Sprintf("completeness %d", completeness[item.ID])
+		default:
+			item.Action = actionSkip
+			item.Detail = "parsed " + parsed
+		}
+		seen[url] = true
+		items = append(items, item)
+	}
+
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[item.Action]++
+	}
+	summary := make([]string, 0, len(planActions))
+	for _, action := range planActions {
+		summary = append(summary, fmt.Sprintf("%d %s", counts[action], action))
+	}
+	fmt.Fprintf(os.Stderr, "Planned %d URLs: %s\n", len(items), strings.Join(summary, ", "))
+
+	if *urlsOut != "" {
+		err := writeOutput(*urlsOut, func(w io.Writer) error {
+			for _, item := range items {
+				if item.Action == actionSkip {
+					continue
+				}
+				if _, err := fmt.Fprintln(w, item.URL); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeOutput(*out, func(w io.Writer) error {
+		if *format == "json" {
+			encoder := json.NewEncoder(w)
+			encoder.SetIndent("", "  ")
+			encoder.SetEscapeHTML(false)
+			return encoder.Encode(items)
+		}
+
+		for _, item := range items {
+			line := item.Action + "\t" + item.URL + "\t" + item.ID
+			if item.Detail != "" {
+				line += "\t" + item.Detail
+			}
+			if _, err := fmt.Fprintln(w, line); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// olderThan reports whether an RFC 3339 parse time is before cutoff. Records
+// with an unreadable time are treated as due.
+func olderThan(parsedAt string, cutoff time.Time) bool {
+	t, err := time.Parse(time.RFC3339, parsedAt)
+	return err != nil || t.Before(cutoff)
+}
+
+// readURLList reads a crawl input file, skipping blank lines and comments
+// the same way a crawl does.
+func readURLList(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open input: %w", err)
+	}
+	defer file.Close()
+
+	var urls []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		url := strings.TrimSpace(scanner.Text())
+		if url != "" && !strings.HasPrefix(url, "#") {
+			urls = append(urls, url)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read input: %w", err)
+	}
+	return urls, nil
+}
@@ -0,0 +1,54 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/publish"
+)
+
+func init() {
+	register(&Command{
+		Name:    "publish",
+		Summary: "Render the corpus as a static HTML site: an index by year and issue, and a page per article",
+		Run:     runPublish,
+	})
+}
+
+func runPublish(args []string) error {
+	fs := flag.NewFlagSet("publish", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	out := fs.String("out", "site", "Directory to write the site to")
+	title := fs.String("title", "钢铁钒钛", "Site title shown on every page")
+	baseURL := fs.String("base-url", "", "Address the site will be served from, e.g. https://lib.example.edu/gtft/, for canonical links and sitemap.xml")
+	fs.Parse(args)
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	records, _ = corpus.Dedupe(records)
+	if len(records) == 0 {
+		return fmt.Errorf("no records found in %s", *dir)
+	}
+
+	if *baseURL != "" {
+		u, err := url.Parse(*baseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("-base-url must be an http or https URL, got %q", *baseURL)
+		}
+	}
+
+	stats, err := publish.Write(*out, publish.Options{Title: *title, BaseURL: *baseURL}, records)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d article pages and %d issue pages to %s\n", stats.Articles, stats.Issues, *out)
+	if stats.Sitemaps > 0 {
+		fmt.Fprintf(os.Stderr, "Listed them in %d sitemap files\n", stats.Sitemaps)
+	}
+	return nil
+}
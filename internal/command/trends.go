@@ -0,0 +1,73 @@
+package command
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/storage"
+)
+
+func init() {
+	register(&Command{
+		Name:    "trends",
+		Summary: "Tabulate the top keywords' article counts and shares by year, as CSV or JSON, with an optional SVG chart",
+		Run:     runTrends,
+	})
+}
+
+func runTrends(args []string) error {
+	fs := flag.NewFlagSet("trends", flag.ExitOnError)
+	dir := fs.String("dir", "data/output/all", "Directory of crawled JSON records")
+	format := fs.String("format", "csv", "Output format: csv or json")
+	out := fs.String("out", "-", "Output file (- for stdout)")
+	lang := fs.String("lang", "zh", "Keywords to count: zh, en or all")
+	top := fs.Int("top", 30, "Number of keywords, the most used first (0 for all)")
+	shares := fs.Bool("shares", false, "Give each year's share of articles in percent instead of counts in the CSV")
+	chart := fs.String("chart", "", "Also draw the top ten keywords' shares as an SVG line chart to this file")
+	fs.Parse(args)
+
+	if *format != "csv" && *format != "json" {
+		return fmt.Errorf("unknown format %q (want csv or json)", *format)
+	}
+	language := *lang
+	switch language {
+	case "zh", "en":
+	case "all":
+		language = ""
+	default:
+		return fmt.Errorf("-lang must be zh, en or all, got %q", *lang)
+	}
+
+	records, err := corpus.Load(*dir)
+	if err != nil {
+		return fmt.Errorf("failed to load records: %w", err)
+	}
+	records, _ = corpus.Dedupe(records)
+
+	trends := index.BuildKeywordTrends(records, language, *top)
+	if len(trends.Years) == 0 {
+		return fmt.Errorf("no records with a publication year in %s", *dir)
+	}
+
+	if *chart != "" {
+		if err := writeOutput(*chart, trends.WriteSVG); err != nil {
+			return err
+		}
+	}
+	if err := writeOutput(*out, func(w io.Writer) error {
+		if *format == "json" {
+			return storage.EncodeJSON(w, trends)
+		}
+		return trends.WriteCSV(w, *shares)
+	}); err != nil {
+		return err
+	}
+	if trends.Undated > 0 {
+		fmt.Fprintf(os.Stderr, "Left out %d records without a publication year\n", trends.Undated)
+	}
+	return nil
+}
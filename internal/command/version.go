@@ -0,0 +1,35 @@
+package command
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"gtft-crawler/internal/version"
+)
+
+func init() {
+	register(&Command{
+		Name:    "version",
+		Summary: "Print build and parser rules version",
+		Run:     runVersion,
+	})
+}
+
+func runVersion(args []string) error {
+	fs := flag.NewFlagSet("version", flag.ExitOnError)
+	asJSON := fs.Bool("json", false, "Print version information as JSON")
+	fs.Parse(args)
+
+	info := version.Get()
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(info)
+	}
+
+	fmt.Println(info)
+	return nil
+}
@@ -0,0 +1,111 @@
+package corpus
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Walk calls fn for every article record under dir, in lexical path order.
+// Non-record files (stats.json, the runs/ history, hidden files, files
+// without an ID) are skipped.
+func Walk(dir string, fn func(path string, metadata *parser.PaperMetadata) error) error {
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if d.IsDir() {
+			if path != dir && (strings.HasPrefix(name, ".") || path == filepath.Join(dir, "runs")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if strings.HasPrefix(name, ".") || filepath.Ext(name) != ".json" || name == "stats.json" {
+			return nil
+		}
+
+		metadata, err := ReadFile(path)
+		if err != nil {
+			return err
+		}
+		if metadata.ID == "" {
+			return nil
+		}
+
+		return fn(path, metadata)
+	})
+}
+
+// Load reads every article record under dir, sorted by ID.
+func Load(dir string) ([]*parser.PaperMetadata, error) {
+	var records []*parser.PaperMetadata
+
+	err := Walk(dir, func(path string, metadata *parser.PaperMetadata) error {
+		records = append(records, metadata)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(records, func(i, j int) bool {
+		return records[i].ID < records[j].ID
+	})
+
+	return records, nil
+}
+
+// ReadFile decodes a single record file.
+func ReadFile(path string) (*parser.PaperMetadata, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read record: %w", err)
+	}
+
+	var metadata parser.PaperMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+
+	return &metadata, nil
+}
+
+// Key identifies a record across journals: its ID, prefixed with the
+// journal's profile name ("gtft/<id>") for records of runs crawling several
+// journals, whose IDs may collide.
+func Key(metadata *parser.PaperMetadata) string {
+	if metadata.Site == "" {
+		return metadata.ID
+	}
+	return metadata.Site + "/" + metadata.ID
+}
+
+// Dedupe collapses records sharing an ID, keeping the most recently parsed
+// one. records must be sorted by ID; the number of dropped duplicates is
+// returned alongside the result.
+func Dedupe(records []*parser.PaperMetadata) ([]*parser.PaperMetadata, int) {
+	var unique []*parser.PaperMetadata
+	dropped := 0
+
+	for _, record := range records {
+		if n := len(unique); n > 0 && unique[n-1].ID == record.ID {
+			if record.ParsedAt > unique[n-1].ParsedAt {
+				unique[n-1] = record
+			}
+			dropped++
+			continue
+		}
+		unique = append(unique, record)
+	}
+
+	return unique, dropped
+}
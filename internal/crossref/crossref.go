@@ -0,0 +1,136 @@
+// Package crossref fills in the reference lists of records whose pages
+// don't show one, from the references publishers deposit with Crossref.
+package crossref
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"gtft-crawler/internal/parser"
+)
+
+// DefaultAPI is Crossref's public REST API.
+const DefaultAPI = "https://api.crossref.org"
+
+// Source marks references taken from Crossref.
+const Source = "crossref"
+
+// Client looks up works in the Crossref REST API.
+type Client struct {
+	api    string
+	mailto string
+	client *http.Client
+}
+
+// New returns a Client for the API at api. mailto, when set, is sent with
+// every request, which Crossref routes to its faster "polite" pool.
+func New(api, mailto string) *Client {
+	return &Client{api: api, mailto: mailto, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// work is the part of a /works/{doi} response the client reads.
+type work struct {
+	Message struct {
+		Reference []reference `json:"reference"`
+	} `json:"message"`
+}
+
+type reference struct {
+	DOI          string `json:"DOI"`
+	Unstructured string `json:"unstructured"`
+	ArticleTitle string `json:"article-title"`
+	VolumeTitle  string `json:"volume-title"`
+	JournalTitle string `json:"journal-title"`
+	Author       string `json:"author"`
+	Year         string `json:"year"`
+	Volume       string `json:"volume"`
+	FirstPage    string `json:"first-page"`
+}
+
+// Apply fills the references of a record that has a DOI but no references
+// from its page, marking them with Source. Works Crossref doesn't know, or
+// whose publisher deposited no references, leave the record as it is.
+func (c *Client) Apply(metadata *parser.PaperMetadata) error {
+	if len(metadata.References) > 0 || metadata.DOI == "" {
+		return nil
+	}
+
+	refs, err := c.References(metadata.DOI)
+	if err != nil {
+		return err
+	}
+	metadata.References = refs
+	return nil
+}
+
+// References returns the references deposited for doi, nil when there are
+// none or Crossref doesn't know the DOI.
+func (c *Client) References(doi string) ([]parser.Reference, error) {
+	endpoint, err := url.JoinPath(c.api, "works", parser.NormalizeDOI(doi))
+	if err != nil {
+		return nil, fmt.Errorf("invalid crossref API URL: %w", err)
+	}
+	if c.mailto != "" {
+		endpoint += "?mailto=" + url.QueryEscape(c.mailto)
+	}
+
+	resp, err := c.client.Get(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("crossref request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// DOIs registered with other agencies, such as most Chinese DOIs, are
+	// unknown to Crossref
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("crossref lookup of %s failed: HTTP %d", doi, resp.StatusCode)
+	}
+
+	var result work
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid crossref response: %w", err)
+	}
+
+	var refs []parser.Reference
+	for i, r := range result.Message.Reference {
+		ref := parser.Reference{
+			Number: i + 1,
+			Text:   r.text(),
+			Title:  r.ArticleTitle,
+			Year:   r.Year,
+			DOI:    parser.NormalizeDOI(r.DOI),
+			Source: Source,
+		}
+		if ref.Title == "" {
+			ref.Title = r.VolumeTitle
+		}
+		if ref.Text == "" && ref.DOI == "" {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs, nil
+}
+
+// text is the reference as printed when the publisher deposited it that
+// way, and otherwise assembled from its parts.
+func (r reference) text() string {
+	if text := strings.TrimSpace(r.Unstructured); text != "" {
+		return text
+	}
+
+	var parts []string
+	for _, part := range []string{r.Author, r.ArticleTitle, r.VolumeTitle, r.JournalTitle, r.Year, r.Volume, r.FirstPage} {
+		if part = strings.TrimSpace(part); part != "" {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ". ")
+}
@@ -0,0 +1,94 @@
+// Package embed turns record abstracts into vector embeddings through an
+// embeddings endpoint, and stores them in files FAISS and numpy can read,
+// for semantic search over the corpus.
+package embed
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Client sends texts to an OpenAI-compatible embeddings endpoint, which
+// takes
+//
+//	{"model": "...", "input": ["...", ...]}
+//
+// and answers {"data": [{"index": 0, "embedding": [...]}, ...]}. OpenAI,
+// Ollama, vLLM and the text-embeddings servers that run ONNX models locally
+// all speak it.
+type Client struct {
+	url    string
+	model  string
+	apiKey string
+	client *http.Client
+}
+
+// New returns a Client posting to url for model, sending apiKey as a bearer
+// token when it isn't empty.
+func New(url, model, apiKey string) *Client {
+	return &Client{url: url, model: model, apiKey: apiKey, client: &http.Client{Timeout: 120 * time.Second}}
+}
+
+type request struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type response struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float32 `json:"embedding"`
+	} `json:"data"`
+}
+
+// Embed returns the embeddings of texts, in order, from one request. All
+// of them have the same dimension.
+func (c *Client) Embed(texts []string) ([][]float32, error) {
+	body, err := json.Marshal(request{Model: c.model, Input: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode embedding request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create embedding request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("embedding rejected: HTTP %d", resp.StatusCode)
+	}
+
+	var result response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid embedding response: %w", err)
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding response has %d embeddings, want %d", len(result.Data), len(texts))
+	}
+
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		// Entries carry their position, which servers needn't keep in order
+		if item.Index < 0 || item.Index >= len(texts) || vectors[item.Index] != nil {
+			return nil, fmt.Errorf("embedding response has a bad index %d", item.Index)
+		}
+		if len(item.Embedding) == 0 || len(item.Embedding) != len(result.Data[0].Embedding) {
+			return nil, fmt.Errorf("embedding response has vectors of different dimensions")
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
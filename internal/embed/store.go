@@ -0,0 +1,134 @@
+package embed
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gtft-crawler/internal/storage"
+)
+
+// Store is a set of embeddings and the records they belong to, kept as
+// three files sharing a prefix: prefix.fvecs holds the vectors in the
+// .fvecs format FAISS reads (each a little-endian int32 dimension followed
+// by that many float32s), prefix.ids the record ID of each vector, one per
+// line in the same order, and prefix.json what was embedded.
+type Store struct {
+	Model     string `json:"model"`
+	Field     string `json:"field"`
+	Dimension int    `json:"dimension"`
+	Count     int    `json:"count"`
+	// Hashes are the TextHash of each record's embedded text, by ID, for
+	// telling which vectors a later run can keep
+	Hashes map[string]string `json:"hashes"`
+
+	IDs     []string    `json:"-"`
+	Vectors [][]float32 `json:"-"`
+}
+
+// TextHash identifies an embedded text.
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:16])
+}
+
+// Add appends a record's vector.
+func (s *Store) Add(id, hash string, vector []float32) error {
+	if s.Dimension == 0 {
+		s.Dimension = len(vector)
+	}
+	if len(vector) != s.Dimension {
+		return fmt.Errorf("embedding of %s has dimension %d, want %d", id, len(vector), s.Dimension)
+	}
+	if s.Hashes == nil {
+		s.Hashes = make(map[string]string)
+	}
+	s.IDs = append(s.IDs, id)
+	s.Vectors = append(s.Vectors, vector)
+	s.Hashes[id] = hash
+	s.Count = len(s.IDs)
+	return nil
+}
+
+// Read loads the store written with prefix. A missing store is an error
+// satisfying errors.Is(err, os.ErrNotExist).
+func Read(prefix string) (*Store, error) {
+	meta, err := os.ReadFile(prefix + ".json")
+	if err != nil {
+		return nil, err
+	}
+	var s Store
+	if err := json.Unmarshal(meta, &s); err != nil {
+		return nil, fmt.Errorf("invalid %s.json: %w", prefix, err)
+	}
+
+	ids, err := os.ReadFile(prefix + ".ids")
+	if err != nil {
+		return nil, err
+	}
+	s.IDs = strings.Fields(string(ids))
+
+	file, err := os.Open(prefix + ".fvecs")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	r := bufio.NewReader(file)
+	for {
+		var dimension int32
+		if err := binary.Read(r, binary.LittleEndian, &dimension); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("invalid %s.fvecs: %w", prefix, err)
+		}
+		if int(dimension) != s.Dimension {
+			return nil, fmt.Errorf("invalid %s.fvecs: vector of dimension %d, want %d", prefix, dimension, s.Dimension)
+		}
+		vector := make([]float32, dimension)
+		if err := binary.Read(r, binary.LittleEndian, vector); err != nil {
+			return nil, fmt.Errorf("invalid %s.fvecs: %w", prefix, err)
+		}
+		s.Vectors = append(s.Vectors, vector)
+	}
+
+	if len(s.IDs) != len(s.Vectors) || len(s.IDs) != s.Count {
+		return nil, fmt.Errorf("%s: %d IDs and %d vectors, want %d of each", prefix, len(s.IDs), len(s.Vectors), s.Count)
+	}
+	return &s, nil
+}
+
+// Write saves the store with prefix, replacing each file atomically.
+func (s *Store) Write(prefix string) error {
+	backend := storage.NewLocalBackend(filepath.Dir(prefix))
+	name := filepath.Base(prefix)
+
+	var vectors bytes.Buffer
+	for _, vector := range s.Vectors {
+		binary.Write(&vectors, binary.LittleEndian, int32(len(vector)))
+		binary.Write(&vectors, binary.LittleEndian, vector)
+	}
+	ids := strings.Join(s.IDs, "\n")
+	if ids != "" {
+		ids += "\n"
+	}
+	meta, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	if err := backend.WriteFile(name+".fvecs", vectors.Bytes()); err != nil {
+		return err
+	}
+	if err := backend.WriteFile(name+".ids", []byte(ids)); err != nil {
+		return err
+	}
+	return backend.WriteFile(name+".json", append(meta, '\n'))
+}
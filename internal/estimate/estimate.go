@@ -0,0 +1,225 @@
+// Package estimate predicts what a crawl of a URL list will cost before it
+// starts: requests sent, time taken and disk used.
+package estimate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/state"
+	"gtft-crawler/internal/storage"
+)
+
+// recentRuns is how many of the latest runs throughput and failure rates
+// are taken from.
+const recentRuns = 5
+
+// Settings are the crawl options the estimate depends on.
+type Settings struct {
+	RateLimit  int
+	MaxRetries int
+	Refresh    bool
+
+	PDF     bool
+	PDFSize bool
+	Images  bool
+	Figures bool
+}
+
+// Estimate is the predicted cost of a crawl.
+type Estimate struct {
+	URLs int `json:"urls"`
+	// New have no record yet and Existing are already saved; a crawl
+	// fetches both, but only writes existing records with -refresh
+	New      int `json:"new"`
+	Existing int `json:"existing"`
+	// Failed failed in an earlier run and are retried
+	Failed int `json:"failed"`
+
+	Requests int `json:"requests"`
+	// Throughput is the expected URLs per second, from recent runs capped
+	// by the rate limit, or the rate limit alone without history
+	Throughput float64 `json:"throughput"`
+	FromRuns   int     `json:"from_runs"`
+	Duration   string  `json:"duration"`
+	// DiskBytes is the expected growth of the output directory, measured
+	// from the records (and PDFs) already there; nil when there are none
+	DiskBytes *int64 `json:"disk_bytes"`
+}
+
+// corpusSample is what the existing records say about future ones.
+type corpusSample struct {
+	ids      map[string]bool
+	idByURL  map[string]string
+	records  int
+	bytes    int64
+	pdfs     int
+	pdfBytes int64
+	figures  int
+}
+
+// Compute estimates a crawl of urls into dir with the given settings.
+// Everything dir holds (records, crawl state and run history) informs the
+// estimate; an empty or missing dir gives one based on the settings alone.
+func Compute(urls []string, dir string, settings Settings) (*Estimate, error) {
+	sample, err := sampleCorpus(dir)
+	if err != nil {
+		return nil, err
+	}
+	crawlState, err := storage.ReadState(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &Estimate{URLs: len(urls)}
+	written := 0
+	for _, url := range urls {
+		entry, known := crawlState.Get(url)
+		id := entry.ID
+		if id == "" {
+			id = sample.idByURL[url]
+		}
+		if id == "" {
+			id = parser.IDFromURL(url)
+		}
+		switch {
+		case known && entry.Status == state.StatusFailed:
+			e.Failed++
+			written++
+		case sample.ids[id]:
+			e.Existing++
+			if settings.Refresh {
+				written++
+			}
+		default:
+			e.New++
+			written++
+		}
+	}
+
+	runs, err := storage.ReadRuns(dir)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) > recentRuns {
+		runs = runs[len(runs)-recentRuns:]
+	}
+	failureRate, throughput := runRates(runs)
+	e.FromRuns = len(runs)
+
+	// Every URL is fetched once, failing ones up to MaxRetries times, and
+	// each fetched article brings its asset requests
+	perURL := 1 + failureRate*float64(max(settings.MaxRetries-1, 0))
+	if settings.PDF || settings.PDFSize {
+		perURL++
+	}
+	if settings.Images {
+		perURL++
+	}
+	if settings.Figures && sample.records > 0 {
+		perURL += float64(sample.figures) / float64(sample.records)
+	}
+	e.Requests = int(float64(len(urls))*perURL + 0.5)
+
+	e.Throughput = float64(settings.RateLimit)
+	if throughput > 0 && throughput < e.Throughput {
+		e.Throughput = throughput
+	}
+	if e.Throughput > 0 {
+		e.Duration = time.Duration(float64(len(urls)) / e.Throughput * float64(time.Second)).Round(time.Second).String()
+	}
+
+	if sample.records > 0 {
+		disk := int64(written) * sample.bytes / int64(sample.records)
+		if settings.PDF && sample.pdfs > 0 {
+			disk += int64(written) * sample.pdfBytes / int64(sample.pdfs)
+		}
+		e.DiskBytes = &disk
+	}
+
+	return e, nil
+}
+
+// sampleCorpus measures the records already in dir.
+func sampleCorpus(dir string) (*corpusSample, error) {
+	sample := &corpusSample{ids: make(map[string]bool), idByURL: make(map[string]string)}
+	err := corpus.Walk(dir, func(path string, metadata *parser.PaperMetadata) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			return err
+		}
+		sample.ids[metadata.ID] = true
+		sample.idByURL[metadata.URL] = metadata.ID
+		sample.records++
+		sample.bytes += info.Size()
+		if metadata.PDFBytes > 0 {
+			sample.pdfs++
+			sample.pdfBytes += metadata.PDFBytes
+		}
+		sample.figures += len(metadata.Figures)
+		return nil
+	})
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to load crawled records: %w", err)
+	}
+	return sample, nil
+}
+
+// runRates returns the share of processed URLs that failed and the URLs
+// processed per second across runs.
+func runRates(runs []*storage.RunStats) (failureRate, throughput float64) {
+	processed, failed := 0, 0
+	var elapsed time.Duration
+	for _, run := range runs {
+		processed += run.Saved + run.Failed + run.Skipped
+		failed += run.Failed
+		elapsed += run.EndTime.Sub(run.StartTime)
+	}
+	if processed == 0 {
+		return 0, 0
+	}
+	failureRate = float64(failed) / float64(processed)
+	if elapsed > 0 {
+		throughput = float64(processed) / elapsed.Seconds()
+	}
+	return failureRate, throughput
+}
+
+// Write prints the estimate for people.
+func (e *Estimate) Write(w io.Writer) error {
+	fmt.Fprintf(w, "URLs: %d (%d new, %d previously failed, %d already saved)\n", e.URLs, e.New, e.Failed, e.Existing)
+	fmt.Fprintf(w, "Requests: about %d\n", e.Requests)
+	basis := "the rate limit"
+	switch {
+	case e.FromRuns == 1:
+		basis = "the last run and the rate limit"
+	case e.FromRuns > 1:
+		basis = fmt.Sprintf("the last %d runs and the rate limit", e.FromRuns)
+	}
+	fmt.Fprintf(w, "Duration: about %s at %.1f URLs/s (from %s)\n", e.Duration, e.Throughput, basis)
+	if e.DiskBytes != nil {
+		fmt.Fprintf(w, "Disk usage: about %s\n", formatBytes(*e.DiskBytes))
+	} else {
+		fmt.Fprintf(w, "Disk usage: unknown (no records to measure)\n")
+	}
+	return nil
+}
+
+// formatBytes formats a size with a binary unit, e.g. "12.5 MiB".
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,86 @@
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+var bibKeyChars = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// WriteBibTeX writes one @article entry per record.
+func WriteBibTeX(w io.Writer, records []*parser.PaperMetadata) error {
+	bw := bufio.NewWriter(w)
+
+	for _, m := range records {
+		fmt.Fprintf(bw, "@article{%s,\n", bibKey(m))
+
+		title := m.TitleCN
+		if title == "" {
+			title = m.TitleEN
+		}
+		journal := m.JournalCN
+		if journal == "" {
+			journal = m.JournalEN
+		}
+
+		writeBibField(bw, "title", title)
+		writeBibField(bw, "author", strings.Join(authorNames(m), " and "))
+		writeBibField(bw, "journal", journal)
+		writeBibField(bw, "year", m.Year)
+		writeBibField(bw, "volume", m.Volume)
+		writeBibField(bw, "number", m.Issue)
+		writeBibField(bw, "pages", strings.Replace(m.Pages, "-", "--", 1))
+		writeBibField(bw, "issn", m.ISSN)
+		writeBibVerbatim(bw, "doi", m.DOI)
+		writeBibVerbatim(bw, "url", m.URL)
+		writeBibField(bw, "keywords", strings.Join(m.KeywordsCN, ", "))
+		writeBibField(bw, "abstract", m.AbstractCN)
+		writeBibField(bw, "language", m.Language)
+
+		fmt.Fprintf(bw, "}\n\n")
+	}
+
+	return bw.Flush()
+}
+
+func bibKey(m *parser.PaperMetadata) string {
+	if m.DOI != "" {
+		return strings.Trim(bibKeyChars.ReplaceAllString(m.DOI, "_"), "_")
+	}
+	return "gtft_" + strings.Trim(bibKeyChars.ReplaceAllString(m.ID, "_"), "_")
+}
+
+func writeBibField(w io.Writer, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "  %s = {%s},\n", name, escapeBibTeX(value))
+}
+
+// writeBibVerbatim writes identifiers such as DOIs and URLs unescaped, as
+// BibTeX styles print those fields literally.
+func writeBibVerbatim(w io.Writer, name, value string) {
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "  %s = {%s},\n", name, value)
+}
+
+func escapeBibTeX(value string) string {
+	replacer := strings.NewReplacer(
+		`\`, `\textbackslash{}`,
+		"{", `\{`,
+		"}", `\}`,
+		"&", `\&`,
+		"%", `\%`,
+		"$", `\$`,
+		"#", `\#`,
+		"_", `\_`,
+	)
+	return replacer.Replace(strings.Join(strings.Fields(value), " "))
+}
@@ -0,0 +1,42 @@
+package export
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+var csvHeader = []string{
+	"id", "url", "language", "title_cn", "title_en", "authors",
+	"journal_cn", "journal_en", "issn", "volume", "issue", "pages", "year", "date",
+	"doi", "keywords_cn", "keywords_en", "abstract_cn", "abstract_en",
+	"views", "downloads", "citations", "pdf_url",
+}
+
+// WriteCSV writes one row per record. Multi-valued fields are joined with "; ".
+func WriteCSV(w io.Writer, records []*parser.PaperMetadata) error {
+	writer := csv.NewWriter(w)
+
+	if err := writer.Write(csvHeader); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	for _, m := range records {
+		row := []string{
+			m.ID, m.URL, m.Language, m.TitleCN, m.TitleEN, strings.Join(authorNames(m), "; "),
+			m.JournalCN, m.JournalEN, m.ISSN, m.Volume, m.Issue, m.Pages, m.Year, m.Date,
+			m.DOI, strings.Join(m.KeywordsCN, "; "), strings.Join(m.KeywordsEN, "; "), m.AbstractCN, m.AbstractEN,
+			strconv.Itoa(m.Views), strconv.Itoa(m.Downloads), strconv.Itoa(m.Citations), m.PDFURL,
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row for %s: %w", m.ID, err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
@@ -0,0 +1,49 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Writer encodes a set of records to w in one output format.
+type Writer func(w io.Writer, records []*parser.PaperMetadata) error
+
+var formats = map[string]Writer{
+	"csv":     WriteCSV,
+	"bibtex":  WriteBibTeX,
+	"json":    WriteJSON,
+	"jsonl":   WriteJSONL,
+	"parquet": WriteParquet,
+	"zotero":  WriteZoteroRDF,
+}
+
+// Lookup returns the writer for a format name.
+func Lookup(format string) (Writer, error) {
+	writer, ok := formats[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown export format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return writer, nil
+}
+
+// Formats lists the supported format names.
+func Formats() []string {
+	names := make([]string, 0, len(formats))
+	for name := range formats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func authorNames(metadata *parser.PaperMetadata) []string {
+	names := make([]string, 0, len(metadata.Authors))
+	for _, author := range metadata.Authors {
+		names = append(names, author.Name)
+	}
+	return names
+}
@@ -0,0 +1,40 @@
+package export
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"gtft-crawler/internal/parser"
+)
+
+// WriteJSONL writes one compact JSON record per line.
+func WriteJSONL(w io.Writer, records []*parser.PaperMetadata) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+
+	for _, m := range records {
+		if err := encoder.Encode(m); err != nil {
+			return fmt.Errorf("failed to encode record %s: %w", m.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// WriteJSON writes all records as a single indented JSON array.
+func WriteJSON(w io.Writer, records []*parser.PaperMetadata) error {
+	if records == nil {
+		records = []*parser.PaperMetadata{}
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+
+	if err := encoder.Encode(records); err != nil {
+		return fmt.Errorf("failed to encode records: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,81 @@
+package export
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/parquet-go/parquet-go"
+
+	"gtft-crawler/internal/parser"
+)
+
+// parquetRow is the flattened column layout used for Parquet output.
+type parquetRow struct {
+	ID         string   `parquet:"id"`
+	URL        string   `parquet:"url"`
+	Language   string   `parquet:"language"`
+	TitleCN    string   `parquet:"title_cn"`
+	TitleEN    string   `parquet:"title_en,optional"`
+	Authors    []string `parquet:"authors,list"`
+	JournalCN  string   `parquet:"journal_cn"`
+	JournalEN  string   `parquet:"journal_en,optional"`
+	ISSN       string   `parquet:"issn,optional"`
+	Volume     string   `parquet:"volume"`
+	Issue      string   `parquet:"issue"`
+	Pages      string   `parquet:"pages"`
+	Year       string   `parquet:"year"`
+	Date       string   `parquet:"date"`
+	DOI        string   `parquet:"doi,optional"`
+	KeywordsCN []string `parquet:"keywords_cn,list"`
+	KeywordsEN []string `parquet:"keywords_en,list"`
+	AbstractCN string   `parquet:"abstract_cn"`
+	AbstractEN string   `parquet:"abstract_en,optional"`
+	Views      int64    `parquet:"views"`
+	Downloads  int64    `parquet:"downloads"`
+	Citations  int64    `parquet:"citations"`
+	PDFURL     string   `parquet:"pdf_url,optional"`
+	ParsedAt   string   `parquet:"parsed_at"`
+}
+
+// WriteParquet writes all records as a single Parquet file.
+func WriteParquet(w io.Writer, records []*parser.PaperMetadata) error {
+	rows := make([]parquetRow, 0, len(records))
+	for _, m := range records {
+		rows = append(rows, parquetRow{
+			ID:         m.ID,
+			URL:        m.URL,
+			Language:   m.Language,
+			TitleCN:    m.TitleCN,
+			TitleEN:    m.TitleEN,
+			Authors:    authorNames(m),
+			JournalCN:  m.JournalCN,
+			JournalEN:  m.JournalEN,
+			ISSN:       m.ISSN,
+			Volume:     m.Volume,
+			Issue:      m.Issue,
+			Pages:      m.Pages,
+			Year:       m.Year,
+			Date:       m.Date,
+			DOI:        m.DOI,
+			KeywordsCN: m.KeywordsCN,
+			KeywordsEN: m.KeywordsEN,
+			AbstractCN: m.AbstractCN,
+			AbstractEN: m.AbstractEN,
+			Views:      int64(m.Views),
+			Downloads:  int64(m.Downloads),
+			Citations:  int64(m.Citations),
+			PDFURL:     m.PDFURL,
+			ParsedAt:   m.ParsedAt,
+		})
+	}
+
+	writer := parquet.NewGenericWriter[parquetRow](w)
+	if _, err := writer.Write(rows); err != nil {
+		return fmt.Errorf("failed to write Parquet rows: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to finish Parquet file: %w", err)
+	}
+
+	return nil
+}
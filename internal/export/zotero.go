@@ -0,0 +1,105 @@
+package export
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+const zoteroHeader = `<?xml version="1.0" encoding="UTF-8"?>
+<rdf:RDF
+ xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+ xmlns:z="http://www.zotero.org/namespaces/export#"
+ xmlns:dc="http://purl.org/dc/elements/1.1/"
+ xmlns:dcterms="http://purl.org/dc/terms/"
+ xmlns:bib="http://purl.org/net/biblio#"
+ xmlns:foaf="http://xmlns.com/foaf/0.1/"
+ xmlns:prism="http://prismstandard.org/namespaces/1.2/basic/">
+`
+
+// WriteZoteroRDF writes the records as Zotero RDF, importable into a Zotero
+// library via File → Import. Author names are written as single-field names,
+// which is how Zotero stores Chinese names.
+func WriteZoteroRDF(w io.Writer, records []*parser.PaperMetadata) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString(zoteroHeader)
+
+	for _, m := range records {
+		fmt.Fprintf(bw, "  <bib:Article rdf:about=\"%s\">\n", xmlEscape(m.URL))
+		bw.WriteString("    <z:itemType>journalArticle</z:itemType>\n")
+
+		bw.WriteString("    <dcterms:isPartOf>\n      <bib:Journal>\n")
+		writeXMLElement(bw, 8, "prism:volume", m.Volume)
+		writeXMLElement(bw, 8, "prism:number", m.Issue)
+		writeXMLElement(bw, 8, "dc:title", m.JournalCN)
+		writeXMLElement(bw, 8, "dcterms:alternative", m.JournalAbbr)
+		if m.DOI != "" {
+			writeXMLElement(bw, 8, "dc:identifier", "DOI "+m.DOI)
+		}
+		if m.ISSN != "" {
+			writeXMLElement(bw, 8, "dc:identifier", "ISSN "+m.ISSN)
+		}
+		bw.WriteString("      </bib:Journal>\n    </dcterms:isPartOf>\n")
+
+		if len(m.Authors) > 0 {
+			bw.WriteString("    <bib:authors>\n      <rdf:Seq>\n")
+			for _, author := range m.Authors {
+				bw.WriteString("        <rdf:li>\n          <foaf:Person>\n")
+				writeXMLElement(bw, 12, "foaf:surname", author.Name)
+				bw.WriteString("          </foaf:Person>\n        </rdf:li>\n")
+			}
+			bw.WriteString("      </rdf:Seq>\n    </bib:authors>\n")
+		}
+
+		title := m.TitleCN
+		if title == "" {
+			title = m.TitleEN
+		}
+		writeXMLElement(bw, 4, "dc:title", title)
+		writeXMLElement(bw, 4, "dcterms:abstract", m.AbstractCN)
+		for _, keyword := range m.KeywordsCN {
+			writeXMLElement(bw, 4, "dc:subject", keyword)
+		}
+		for _, keyword := range m.KeywordsEN {
+			writeXMLElement(bw, 4, "dc:subject", keyword)
+		}
+
+		date := m.Date
+		if date == "" {
+			date = m.Year
+		}
+		writeXMLElement(bw, 4, "dc:date", date)
+		writeXMLElement(bw, 4, "bib:pages", m.Pages)
+		writeXMLElement(bw, 4, "z:language", m.Language)
+		writeXMLElement(bw, 4, "dc:rights", m.License)
+
+		if m.URL != "" {
+			bw.WriteString("    <dc:identifier>\n      <dcterms:URI>\n")
+			writeXMLElement(bw, 8, "rdf:value", m.URL)
+			bw.WriteString("      </dcterms:URI>\n    </dc:identifier>\n")
+		}
+
+		bw.WriteString("  </bib:Article>\n")
+	}
+
+	bw.WriteString("</rdf:RDF>\n")
+	return bw.Flush()
+}
+
+func writeXMLElement(w *bufio.Writer, indent int, name, value string) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return
+	}
+	fmt.Fprintf(w, "%s<%s>%s</%s>\n", strings.Repeat(" ", indent), name, xmlEscape(value), name)
+}
+
+func xmlEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
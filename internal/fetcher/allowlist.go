@@ -0,0 +1,55 @@
+package fetcher
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Allowlist restricts crawling to a set of hosts. A listed host also
+// permits its subdomains, so "gtft.cn" allows "www.gtft.cn".
+type Allowlist struct {
+	hosts []string
+	any   bool
+}
+
+// NewAllowlist builds an allowlist from host names. "*" allows every host.
+func NewAllowlist(hosts []string) *Allowlist {
+	a := &Allowlist{}
+	for _, host := range hosts {
+		host = strings.ToLower(strings.TrimSpace(host))
+		switch host {
+		case "":
+		case "*":
+			a.any = true
+		default:
+			a.hosts = append(a.hosts, strings.TrimPrefix(host, "."))
+		}
+	}
+	return a
+}
+
+// Allows reports whether rawURL is an http(s) URL on a permitted host.
+func (a *Allowlist) Allows(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return false
+	}
+	if a.any {
+		return true
+	}
+
+	host := strings.ToLower(u.Hostname())
+	for _, allowed := range a.hosts {
+		if host == allowed || strings.HasSuffix(host, "."+allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *Allowlist) String() string {
+	if a.any {
+		return "*"
+	}
+	return strings.Join(a.hosts, ",")
+}
@@ -0,0 +1,102 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// Credential authenticates requests to one host, with either HTTP Basic
+// authentication or a bearer token.
+type Credential struct {
+	Username string
+	Password string
+	Token    string
+}
+
+func (c Credential) apply(req *http.Request) {
+	if c.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.Token)
+		return
+	}
+	req.SetBasicAuth(c.Username, c.Password)
+}
+
+// ParseCredentials reads per-host credentials, one per line or separated by
+// semicolons, in the form
+//
+//	host basic user:password
+//	host bearer token
+//
+// host may include a port, which must then match too. Blank lines and lines
+// starting with # are ignored.
+func ParseCredentials(text string) (map[string]Credential, error) {
+	creds := make(map[string]Credential)
+	for _, entry := range strings.FieldsFunc(text, func(r rune) bool { return r == '\n' || r == ';' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		fields := strings.Fields(entry)
+		if len(fields) != 3 {
+			return nil, fmt.Errorf("invalid credential %q: want \"host basic user:password\" or \"host bearer token\"", redactCredential(fields))
+		}
+		host, scheme, secret := strings.ToLower(fields[0]), strings.ToLower(fields[1]), fields[2]
+
+		var cred Credential
+		switch scheme {
+		case "basic":
+			user, password, ok := strings.Cut(secret, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid credential for %s: basic needs user:password", host)
+			}
+			cred = Credential{Username: user, Password: password}
+		case "bearer":
+			cred = Credential{Token: secret}
+		default:
+			return nil, fmt.Errorf("invalid credential for %s: unknown scheme %q (want basic or bearer)", host, scheme)
+		}
+		creds[host] = cred
+	}
+	return creds, nil
+}
+
+// LoadCredentials reads credentials from a file in the ParseCredentials
+// format.
+func LoadCredentials(path string) (map[string]Credential, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+	return ParseCredentials(string(data))
+}
+
+// redactCredential shows the host of a malformed entry without its secret.
+func redactCredential(fields []string) string {
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0] + " ..."
+}
+
+// SetCredentials authenticates requests to the given hosts. Credentials are
+// only sent to the exact host they were given for; the HTTP client drops
+// them when a redirect leads to another host.
+func (f *Fetcher) SetCredentials(creds map[string]Credential) {
+	f.credentials = creds
+}
+
+// credentialFor returns the credential for a request's host:port, falling
+// back to the host without port.
+func (f *Fetcher) credentialFor(req *http.Request) (Credential, bool) {
+	if len(f.credentials) == 0 {
+		return Credential{}, false
+	}
+	if cred, ok := f.credentials[strings.ToLower(req.URL.Host)]; ok {
+		return cred, true
+	}
+	cred, ok := f.credentials[strings.ToLower(req.URL.Hostname())]
+	return cred, ok
+}
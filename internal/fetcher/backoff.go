@@ -0,0 +1,79 @@
+package fetcher
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+)
+
+// Backoff jitter modes: none doubles the delay each retry (base, 2×base,
+// 4×base, ...); full waits a random time up to that; decorrelated waits a
+// random time between base and three times the previous wait.
+const (
+	JitterNone         = "none"
+	JitterFull         = "full"
+	JitterDecorrelated = "decorrelated"
+)
+
+// Default backoff: full jitter on 1s, 2s, 4s, ... capped at 30s.
+const (
+	DefaultBackoffBase = time.Second
+	DefaultBackoffCap  = 30 * time.Second
+)
+
+// backoff is the wait between retries. Jitter spreads out the retries of
+// workers that failed together, which would otherwise hit the server in
+// bursts.
+type backoff struct {
+	base, cap time.Duration
+	jitter    string
+}
+
+// SetBackoff sets the wait before each retry: base for the first, growing
+// to at most cap, randomized by jitter.
+func (f *Fetcher) SetBackoff(base, cap time.Duration, jitter string) error {
+	if base <= 0 || cap < base {
+		return fmt.Errorf("backoff base must be positive and at most the cap (got %v and %v)", base, cap)
+	}
+	if jitter != JitterNone && jitter != JitterFull && jitter != JitterDecorrelated {
+		return fmt.Errorf("unknown jitter %q (want %s, %s or %s)", jitter, JitterNone, JitterFull, JitterDecorrelated)
+	}
+	f.backoff = backoff{base: base, cap: cap, jitter: jitter}
+	return nil
+}
+
+// backoffDuration returns the wait before retry number attempt (from 1).
+func (f *Fetcher) backoffDuration(attempt int) time.Duration {
+	b := f.backoff
+	switch b.jitter {
+	case JitterFull:
+		return randomDuration(0, b.exponential(attempt))
+	case JitterDecorrelated:
+		// Each wait depends only on the one before, so replaying the walk
+		// from the first retry gives the same distribution as keeping it
+		wait := b.base
+		for range attempt - 1 {
+			wait = min(b.cap, randomDuration(b.base, 3*wait))
+		}
+		return wait
+	default:
+		return b.exponential(attempt)
+	}
+}
+
+// exponential returns base doubled for each retry after the first, capped.
+func (b backoff) exponential(attempt int) time.Duration {
+	wait := b.base
+	for i := 1; i < attempt && wait < b.cap; i++ {
+		wait *= 2
+	}
+	return min(wait, b.cap)
+}
+
+// randomDuration returns a uniformly random duration in [lo, hi].
+func randomDuration(lo, hi time.Duration) time.Duration {
+	if hi <= lo {
+		return lo
+	}
+	return lo + rand.N(hi-lo+1)
+}
@@ -0,0 +1,133 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var responsesBucket = []byte("responses")
+
+// ResponseCache stores successful GET responses, keyed by URL, so repeated
+// runs over the same URLs don't hit the server again. Get and Fresh ignore
+// expired entries.
+type ResponseCache interface {
+	Get(url string) (*FetchResult, error)
+	Fresh(url string) bool
+	Put(result *FetchResult) error
+	Close() error
+}
+
+// Cache is a ResponseCache in a single bbolt database file.
+type Cache struct {
+	db  *bolt.DB
+	ttl time.Duration
+}
+
+// cachedResponse is the stored form of a FetchResult.
+type cachedResponse struct {
+	FetchedAt     time.Time  `json:"fetched_at"`
+	StatusCode    int        `json:"status_code"`
+	ContentType   string     `json:"content_type"`
+	ContentLength int64      `json:"content_length"`
+	FinalURL      string     `json:"final_url"`
+	Redirects     []Redirect `json:"redirects,omitempty"`
+	Body          []byte     `json:"body"`
+}
+
+// OpenCache opens or creates the cache database at path. Entries older than
+// ttl are ignored and refetched; a ttl of 0 keeps entries forever.
+func OpenCache(path string, ttl time.Duration) (*Cache, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open cache %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(responsesBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize cache: %w", err)
+	}
+
+	return &Cache{db: db, ttl: ttl}, nil
+}
+
+// Get returns the cached response for url, or nil if there is none or it
+// has expired.
+func (c *Cache) Get(url string) (*FetchResult, error) {
+	var entry *cachedResponse
+	err := c.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(responsesBucket).Get([]byte(url))
+		if data == nil {
+			return nil
+		}
+		entry = &cachedResponse{}
+		return json.Unmarshal(data, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+	if entry == nil || (c.ttl > 0 && time.Since(entry.FetchedAt) > c.ttl) {
+		return nil, nil
+	}
+
+	return &FetchResult{
+		URL:           url,
+		StatusCode:    entry.StatusCode,
+		ContentType:   entry.ContentType,
+		ContentLength: entry.ContentLength,
+		Body:          entry.Body,
+		FinalURL:      entry.FinalURL,
+		Redirects:     entry.Redirects,
+		Cached:        true,
+	}, nil
+}
+
+// Fresh reports whether the cache holds an unexpired response for url,
+// without decoding the body.
+func (c *Cache) Fresh(url string) bool {
+	var entry struct {
+		FetchedAt time.Time `json:"fetched_at"`
+	}
+	found := false
+	c.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(responsesBucket).Get([]byte(url))
+		found = data != nil && json.Unmarshal(data, &entry) == nil
+		return nil
+	})
+	return found && (c.ttl <= 0 || time.Since(entry.FetchedAt) <= c.ttl)
+}
+
+// Put stores a successful response.
+func (c *Cache) Put(result *FetchResult) error {
+	data, err := json.Marshal(cachedResponse{
+		FetchedAt:     time.Now(),
+		StatusCode:    result.StatusCode,
+		ContentType:   result.ContentType,
+		ContentLength: result.ContentLength,
+		FinalURL:      result.FinalURL,
+		Redirects:     result.Redirects,
+		Body:          result.Body,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+
+	err = c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(responsesBucket).Put([]byte(result.URL), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Close closes the cache database.
+func (c *Cache) Close() error {
+	return c.db.Close()
+}
@@ -0,0 +1,80 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// Transcoded returns how many pages were converted to UTF-8 from another
+// charset.
+func (f *Fetcher) Transcoded() int64 {
+	return f.transcoded.Load()
+}
+
+// toUTF8 converts an HTML page to UTF-8 in place, recording the charset it
+// was in. Other responses, such as images and PDFs, are left alone, as is a
+// page that can't be decoded.
+func (f *Fetcher) toUTF8(result *FetchResult) {
+	if !isHTML(result.ContentType, result.Body) {
+		return
+	}
+	body, name, err := transcode(result.Body, result.ContentType)
+	if err != nil {
+		if f.verbose {
+			fmt.Printf("[Charset] %s: %v\n", result.URL, err)
+		}
+		return
+	}
+	if name == "" {
+		return
+	}
+
+	f.transcoded.Add(1)
+	result.Body = body
+	result.Charset = name
+	if f.verbose {
+		fmt.Printf("[Charset] Transcoded %s from %s\n", result.URL, name)
+	}
+}
+
+// transcode returns an HTML body as UTF-8, with the name of the charset it
+// was converted from, or "" when it was UTF-8 already. The charset is taken
+// from a byte-order mark, the Content-Type header or the page's own <meta>
+// declaration. Failing those, or when a page declared UTF-8 isn't, a body
+// that isn't valid UTF-8 is taken to be GB18030, the superset of the GB2312
+// and GBK the site's older pages are served in.
+func transcode(body []byte, contentType string) ([]byte, string, error) {
+	encoding, name, certain := charset.DetermineEncoding(body, contentType)
+	utf8Body := utf8.Valid(body)
+	switch {
+	case name == "utf-8" && utf8Body:
+		return body, "", nil
+	case name == "utf-8", !certain && name == "windows-1252":
+		// DetermineEncoding guesses windows-1252 when nothing declares a
+		// charset, which is never right for this site
+		if utf8Body {
+			return body, "", nil
+		}
+		encoding, name = simplifiedchinese.GB18030, "gb18030"
+	}
+
+	decoded, err := encoding.NewDecoder().Bytes(body)
+	if err != nil {
+		return nil, "", fmt.Errorf("decode %s: %w", name, err)
+	}
+	return decoded, name, nil
+}
+
+// isHTML reports whether a response is an HTML page, by its Content-Type or,
+// without one, its content.
+func isHTML(contentType string, body []byte) bool {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	return strings.Contains(strings.ToLower(contentType), "html")
+}
@@ -0,0 +1,156 @@
+package fetcher
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+)
+
+var validatorsBucket = []byte("validators")
+
+// Validators stores the ETag and Last-Modified headers of fetched pages,
+// keyed by URL, so later runs can ask the server whether a page changed
+// instead of downloading it again.
+type Validators struct {
+	db *bolt.DB
+}
+
+// validator is the stored form of one page's validators.
+type validator struct {
+	ETag         string    `json:"etag,omitempty"`
+	LastModified string    `json:"last_modified,omitempty"`
+	FetchedAt    time.Time `json:"fetched_at"`
+}
+
+// OpenValidators opens or creates the validator database at path.
+func OpenValidators(path string) (*Validators, error) {
+	db, err := bolt.Open(path, 0o644, &bolt.Options{Timeout: 5 * time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open validator database %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(validatorsBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize validator database: %w", err)
+	}
+
+	return &Validators{db: db}, nil
+}
+
+// get returns the validators stored for url, or nil if there are none.
+func (v *Validators) get(url string) (*validator, error) {
+	var entry *validator
+	err := v.db.View(func(tx *bolt.Tx) error {
+		data := tx.Bucket(validatorsBucket).Get([]byte(url))
+		if data == nil {
+			return nil
+		}
+		entry = &validator{}
+		return json.Unmarshal(data, entry)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read validators: %w", err)
+	}
+	return entry, nil
+}
+
+// put stores the validators of a successful response, or forgets those of
+// a page that no longer sends any.
+func (v *Validators) put(url string, header http.Header) error {
+	entry := validator{
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		FetchedAt:    time.Now(),
+	}
+	if entry.ETag == "" && entry.LastModified == "" {
+		return v.Forget(url)
+	}
+
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to encode validators: %w", err)
+	}
+	err = v.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(validatorsBucket).Put([]byte(url), data)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write validators: %w", err)
+	}
+	return nil
+}
+
+// Forget drops the validators stored for url, so its next fetch downloads
+// the page in full.
+func (v *Validators) Forget(url string) error {
+	err := v.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(validatorsBucket).Delete([]byte(url))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete validators: %w", err)
+	}
+	return nil
+}
+
+// Close closes the validator database.
+func (v *Validators) Close() error {
+	return v.db.Close()
+}
+
+type conditionalKey struct{}
+
+// SetValidators stores validators for FetchConditional.
+func (f *Fetcher) SetValidators(validators *Validators) {
+	f.validators = validators
+}
+
+// FetchConditional is Fetch made conditional on the validators stored for
+// url, which it updates from every successful response. A page the server
+// reports unchanged comes back with NotModified set and no body. Without
+// validators set it is the same as Fetch.
+func (f *Fetcher) FetchConditional(url string) (*FetchResult, error) {
+	return f.sharedFetch(url, true)
+}
+
+// NotModified returns how many fetches the server answered with 304 Not
+// Modified.
+func (f *Fetcher) NotModified() int64 {
+	return f.notModified.Load()
+}
+
+// ForgetValidators drops the validators stored for url, so the next
+// FetchConditional downloads the page in full, e.g. when the record a 304
+// would keep is gone.
+func (f *Fetcher) ForgetValidators(url string) error {
+	if f.validators == nil {
+		return nil
+	}
+	return f.validators.Forget(url)
+}
+
+// addValidators makes req conditional on the validators stored for its URL.
+func (f *Fetcher) addValidators(req *http.Request, url string) {
+	if f.validators == nil {
+		return
+	}
+
+	entry, err := f.validators.get(url)
+	if err != nil && f.verbose {
+		fmt.Printf("[Conditional] %s: %v\n", url, err)
+	}
+	if entry == nil {
+		return
+	}
+	if entry.ETag != "" {
+		req.Header.Set("If-None-Match", entry.ETag)
+	}
+	if entry.LastModified != "" {
+		req.Header.Set("If-Modified-Since", entry.LastModified)
+	}
+}
@@ -0,0 +1,131 @@
+package fetcher
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/publicsuffix"
+)
+
+// httpOnlyPrefix marks HttpOnly cookies in cookies.txt files written by
+// browsers and curl; such lines are not comments.
+const httpOnlyPrefix = "#HttpOnly_"
+
+// LoadCookies adds the cookies in a Netscape-format cookies.txt file, as
+// exported by browser extensions or written by curl -c, to the fetcher's
+// cookie jar. From then on cookies set by responses are kept as well, as in
+// a browser session. It returns the number of cookies loaded; expired
+// cookies are skipped.
+func (f *Fetcher) LoadCookies(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open cookies file: %w", err)
+	}
+	defer file.Close()
+
+	if err := f.EnableCookies(); err != nil {
+		return 0, err
+	}
+
+	now := time.Now()
+	loaded := 0
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		httpOnly := strings.HasPrefix(line, httpOnlyPrefix)
+		if httpOnly {
+			line = strings.TrimPrefix(line, httpOnlyPrefix)
+		} else if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			return loaded, fmt.Errorf("%s:%d: expected 7 tab-separated fields, got %d", path, lineNo, len(fields))
+		}
+		domain, includeSubdomains, cookiePath, secure, expires, name, value := fields[0], fields[1], fields[2], fields[3], fields[4], fields[5], fields[6]
+
+		cookie := &http.Cookie{
+			Name:     name,
+			Value:    value,
+			Path:     cookiePath,
+			Secure:   strings.EqualFold(secure, "TRUE"),
+			HttpOnly: httpOnly,
+		}
+		// Host-only cookies have no Domain attribute
+		host := strings.TrimPrefix(domain, ".")
+		if strings.EqualFold(includeSubdomains, "TRUE") {
+			cookie.Domain = host
+		}
+		if expires != "" && expires != "0" {
+			seconds, err := strconv.ParseInt(expires, 10, 64)
+			if err != nil {
+				return loaded, fmt.Errorf("%s:%d: invalid expiry %q", path, lineNo, expires)
+			}
+			cookie.Expires = time.Unix(seconds, 0)
+			if cookie.Expires.Before(now) {
+				continue
+			}
+		}
+
+		scheme := "http"
+		if cookie.Secure {
+			scheme = "https"
+		}
+		f.client.Jar.SetCookies(&url.URL{Scheme: scheme, Host: host, Path: "/"}, []*http.Cookie{cookie})
+		loaded++
+	}
+	if err := scanner.Err(); err != nil {
+		return loaded, fmt.Errorf("failed to read cookies file: %w", err)
+	}
+
+	return loaded, nil
+}
+
+// EnableCookies gives the fetcher a cookie jar, so cookies set by responses
+// are sent with later requests, as in a browser session. The jar lives only
+// as long as the fetcher.
+func (f *Fetcher) EnableCookies() error {
+	if f.client.Jar != nil {
+		return nil
+	}
+	jar, err := cookiejar.New(&cookiejar.Options{PublicSuffixList: publicsuffix.List})
+	if err != nil {
+		return fmt.Errorf("failed to create cookie jar: %w", err)
+	}
+	f.client.Jar = jar
+	return nil
+}
+
+// WarmUp visits rawURL, typically a site's homepage, so the session cookies
+// it sets are sent with the article requests that follow. It bypasses the
+// response cache, which keeps no cookies, and returns how many cookies the
+// jar then holds for rawURL.
+func (f *Fetcher) WarmUp(rawURL string) (int, error) {
+	if err := f.EnableCookies(); err != nil {
+		return 0, err
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return 0, fmt.Errorf("invalid warm-up URL %q: %w", rawURL, err)
+	}
+
+	result, err := f.fetch(rawURL, false, 0, nil)
+	if err != nil {
+		return 0, err
+	}
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if f.verbose {
+		fmt.Printf("[Session] Warmed up with %s (HTTP %d)\n", rawURL, result.StatusCode)
+	}
+	return len(f.client.Jar.Cookies(u)), nil
+}
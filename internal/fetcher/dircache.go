@@ -0,0 +1,171 @@
+package fetcher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// DirCache is a ResponseCache in a directory of plain files. Bodies are
+// stored once per content under bodies/, named by their SHA-256, and each
+// URL has a small JSON entry under urls/ pointing at its body, so cached
+// pages can be inspected, diffed or fed to the parser directly.
+type DirCache struct {
+	dir string
+	ttl time.Duration
+}
+
+// dirCacheEntry is the stored form of a FetchResult without its body.
+type dirCacheEntry struct {
+	URL           string     `json:"url"`
+	FetchedAt     time.Time  `json:"fetched_at"`
+	StatusCode    int        `json:"status_code"`
+	ContentType   string     `json:"content_type"`
+	ContentLength int64      `json:"content_length"`
+	FinalURL      string     `json:"final_url"`
+	Redirects     []Redirect `json:"redirects,omitempty"`
+	BodySHA256    string     `json:"body_sha256"`
+}
+
+// OpenDirCache opens or creates a cache in dir. Entries older than ttl are
+// ignored and refetched; a ttl of 0 keeps entries forever.
+func OpenDirCache(dir string, ttl time.Duration) (*DirCache, error) {
+	for _, sub := range []string{"bodies", "urls"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create cache directory %s: %w", dir, err)
+		}
+	}
+	return &DirCache{dir: dir, ttl: ttl}, nil
+}
+
+// Get returns the cached response for url, or nil if there is none, it has
+// expired or its body is gone.
+func (c *DirCache) Get(url string) (*FetchResult, error) {
+	entry, err := c.entry(url)
+	if entry == nil || err != nil || c.expired(entry) {
+		return nil, err
+	}
+
+	body, err := os.ReadFile(c.bodyPath(entry.BodySHA256))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached body: %w", err)
+	}
+
+	return &FetchResult{
+		URL:           url,
+		StatusCode:    entry.StatusCode,
+		ContentType:   entry.ContentType,
+		ContentLength: entry.ContentLength,
+		Body:          body,
+		FinalURL:      entry.FinalURL,
+		Redirects:     entry.Redirects,
+		Cached:        true,
+	}, nil
+}
+
+// Fresh reports whether the cache holds an unexpired response for url,
+// without reading the body.
+func (c *DirCache) Fresh(url string) bool {
+	entry, err := c.entry(url)
+	return entry != nil && err == nil && !c.expired(entry)
+}
+
+// Put stores a successful response. A body already in the cache, from this
+// URL or another, isn't written again.
+func (c *DirCache) Put(result *FetchResult) error {
+	sum := sha256.Sum256(result.Body)
+	digest := hex.EncodeToString(sum[:])
+
+	bodyPath := c.bodyPath(digest)
+	if _, err := os.Stat(bodyPath); errors.Is(err, os.ErrNotExist) {
+		if err := writeFileAtomic(bodyPath, result.Body); err != nil {
+			return fmt.Errorf("failed to write cached body: %w", err)
+		}
+	}
+
+	data, err := json.MarshalIndent(dirCacheEntry{
+		URL:           result.URL,
+		FetchedAt:     time.Now(),
+		StatusCode:    result.StatusCode,
+		ContentType:   result.ContentType,
+		ContentLength: result.ContentLength,
+		FinalURL:      result.FinalURL,
+		Redirects:     result.Redirects,
+		BodySHA256:    digest,
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode cache entry: %w", err)
+	}
+	if err := writeFileAtomic(c.entryPath(result.URL), append(data, '\n')); err != nil {
+		return fmt.Errorf("failed to write cache entry: %w", err)
+	}
+	return nil
+}
+
+// Close does nothing; every entry is written as soon as it is put.
+func (c *DirCache) Close() error {
+	return nil
+}
+
+func (c *DirCache) entry(url string) (*dirCacheEntry, error) {
+	data, err := os.ReadFile(c.entryPath(url))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cache entry: %w", err)
+	}
+
+	var entry dirCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("failed to decode cache entry: %w", err)
+	}
+	return &entry, nil
+}
+
+func (c *DirCache) expired(entry *dirCacheEntry) bool {
+	return c.ttl > 0 && time.Since(entry.FetchedAt) > c.ttl
+}
+
+// bodyPath and entryPath spread files over 256 subdirectories by the first
+// two hex digits of their name.
+func (c *DirCache) bodyPath(digest string) string {
+	return filepath.Join(c.dir, "bodies", digest[:2], digest)
+}
+
+func (c *DirCache) entryPath(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	name := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, "urls", name[:2], name+".json")
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames
+// it into place, so concurrent readers and writers never see a partial file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+
+	temp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
@@ -2,140 +2,468 @@ package fetcher
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"io"
 	"net/http"
+	"slices"
+	"strings"
+	"sync/atomic"
 	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
+const defaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
 type Fetcher struct {
-	client     *http.Client
-	userAgent  string
+	client    *http.Client
+	userAgent string
+	// userAgents, when set, replace userAgent with a rotating list
+	userAgents *userAgents
+	// headers are added to every request, overriding the defaults
+	headers    http.Header
 	timeout    time.Duration
 	maxRetries int
+	backoff    backoff
 	verbose    bool
+	hedge      *hedger
+	// hedgeAllow, when set, must grant each hedge a rate limiter token
+	hedgeAllow func() bool
+
+	// inflight collapses concurrent fetches of the same URL into one request
+	inflight singleflight.Group
+	shared   atomic.Int64
+
+	cache     ResponseCache
+	cacheHits atomic.Int64
+
+	// requests and bytesRead count every request sent, including retries
+	// and hedges, and the body bytes they returned
+	requests  atomic.Int64
+	bytesRead atomic.Int64
+
+	// credentials authenticate requests, keyed by host or host:port
+	credentials map[string]Credential
+
+	// proxies, when set, carry every request in rotation
+	proxies *proxyPool
+
+	// validators, when set, make GET requests conditional
+	validators  *Validators
+	notModified atomic.Int64
+
+	// renderer, when set, renders HTML pages lacking text under any of
+	// renderSelectors
+	renderer        *Renderer
+	renderSelectors []string
+	rendered        atomic.Int64
+
+	// transcoded counts pages converted to UTF-8
+	transcoded atomic.Int64
+
+	// observers are told about every request and retry
+	observers []FetchObserver
+
+	// retryBudget caps retries at this fraction of fetches (0 for no cap)
+	retryBudget   float64
+	fetches       atomic.Int64
+	retries       atomic.Int64
+	retriesDenied atomic.Int64
+
+	// throttledUntil is when the pause a 429 or Retry-After asked for ends,
+	// in Unix nanoseconds
+	throttledUntil atomic.Int64
+	throttled      atomic.Int64
 }
 
 type FetchResult struct {
+	URL         string
+	StatusCode  int
+	ContentType string
+	// ContentLength is the declared body size, or -1 when unknown
+	ContentLength int64
+	Body          []byte
+	// Error is a *FetchError when the fetch failed
+	Error    error
+	Attempts int
+	// History lists every request made, in order
+	History  []Attempt
+	Duration time.Duration
+
+	// FinalURL is the URL the response came from after following redirects;
+	// Redirects lists each hop in order, empty when there were none
+	FinalURL  string
+	Redirects []Redirect
+
+	// Cached is set when the response came from the response cache
+	Cached bool
+	// NotModified is set when the server answered a conditional request
+	// with 304 Not Modified; Body is then empty
+	NotModified bool
+	// Rendered is set when Body is the page as rendered by the headless
+	// browser rather than the static HTML
+	Rendered bool
+	// Charset is the charset an HTML Body was converted to UTF-8 from,
+	// empty when the page was UTF-8 already
+	Charset string
+}
+
+// Redirect is one hop in a redirect chain: a response with StatusCode at URL
+// that pointed elsewhere.
+type Redirect struct {
 	URL        string
 	StatusCode int
-	Body       []byte
-	Error      error
-	Attempts   int
-	Duration   time.Duration
 }
 
-func NewFetcher(timeout time.Duration, maxRetries, rateLimit int, verbose bool) *Fetcher {
-	transport := &http.Transport{
-		MaxIdleConns:        100,
-		MaxIdleConnsPerHost: 10,
-		IdleConnTimeout:     90 * time.Second,
+// RedirectedAway reports whether an article URL was redirected to a page
+// that isn't an article, typically the journal homepage for a dead link.
+// Parsing such a page produces a junk record.
+func (r *FetchResult) RedirectedAway() bool {
+	if len(r.Redirects) == 0 {
+		return false
 	}
+	return strings.Contains(r.URL, "/article/") && !strings.Contains(r.FinalURL, "/article/")
+}
+
+// redirectChain walks back from the final response through the responses
+// that redirected to it.
+func redirectChain(resp *http.Response) []Redirect {
+	var chain []Redirect
+	for req := resp.Request; req != nil && req.Response != nil; req = req.Response.Request {
+		chain = append(chain, Redirect{
+			URL:        req.Response.Request.URL.String(),
+			StatusCode: req.Response.StatusCode,
+		})
+	}
+	slices.Reverse(chain)
+	return chain
+}
 
+// NewFetcher returns a Fetcher whose connections are tuned by transport,
+// normally DefaultTransportOptions.
+func NewFetcher(timeout time.Duration, maxRetries, rateLimit int, verbose bool, transport TransportOptions) *Fetcher {
 	return &Fetcher{
 		client: &http.Client{
 			Timeout:   timeout,
-			Transport: transport,
+			Transport: newTransport(transport),
 		},
-		userAgent:  "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		userAgent:  defaultUserAgent,
 		timeout:    timeout,
 		maxRetries: maxRetries,
+		backoff:    backoff{base: DefaultBackoffBase, cap: DefaultBackoffCap, jitter: JitterFull},
 		verbose:    verbose,
 	}
 }
 
+// Fetch GETs url, retrying failures. A fetch of a URL that is already being
+// fetched waits for and shares that response instead of sending another
+// request; the result must then be treated as read-only.
 func (f *Fetcher) Fetch(url string) (*FetchResult, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
-	defer cancel()
+	return f.sharedFetch(url, false)
+}
 
-	start := time.Now()
-	var lastError error
-	var attempts int
+func (f *Fetcher) sharedFetch(url string, conditional bool) (*FetchResult, error) {
+	key := url
+	if conditional {
+		key = "conditional " + url
+	}
 
-	for attempts = 1; attempts <= f.maxRetries; attempts++ {
+	leader := false
+	result, err, _ := f.inflight.Do(key, func() (any, error) {
+		leader = true
+		return f.cachedFetch(url, conditional)
+	})
+	if !leader {
+		f.shared.Add(1)
 		if f.verbose {
-			fmt.Printf("Fetching attempt %d/%d: %s\n", attempts, f.maxRetries, url)
+			fmt.Printf("[Dedup] Shared in-flight response for %s\n", url)
 		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result.(*FetchResult), nil
+}
 
-		req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-		if err != nil {
-			lastError = fmt.Errorf("create request failed: %w", err)
-			time.Sleep(f.backoffDuration(attempts))
-			continue
+// Deduplicated returns how many fetches shared another fetch's response.
+func (f *Fetcher) Deduplicated() int64 {
+	return f.shared.Load()
+}
+
+// Requests returns how many requests were sent, counting retries and
+// hedges, and how many body bytes they returned.
+func (f *Fetcher) Requests() (requests, bytes int64) {
+	return f.requests.Load(), f.bytesRead.Load()
+}
+
+// SetCache makes Fetch answer from cache when it holds a fresh response, and
+// store successful responses in it.
+func (f *Fetcher) SetCache(cache ResponseCache) {
+	f.cache = cache
+}
+
+// CacheHits returns how many fetches were answered from the cache.
+func (f *Fetcher) CacheHits() int64 {
+	return f.cacheHits.Load()
+}
+
+func (f *Fetcher) cachedFetch(url string, conditional bool) (*FetchResult, error) {
+	if f.cache == nil {
+		return f.fetchPage(url, conditional)
+	}
+
+	cached, err := f.cache.Get(url)
+	if err != nil && f.verbose {
+		fmt.Printf("[Cache] %s: %v\n", url, err)
+	}
+	if cached != nil {
+		f.cacheHits.Add(1)
+		if f.verbose {
+			fmt.Printf("[Cache] Hit: %s\n", url)
 		}
+		return cached, nil
+	}
 
-		req.Header.Set("User-Agent", f.userAgent)
-		req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
-		req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en-US;q=0.8,en;q=0.7")
-		// Don't set Accept-Encoding - let Go handle decompression automatically
-		req.Header.Set("Connection", "keep-alive")
-		req.Header.Set("Upgrade-Insecure-Requests", "1")
-		req.Header.Set("Sec-Fetch-Dest", "document")
-		req.Header.Set("Sec-Fetch-Mode", "navigate")
-		req.Header.Set("Sec-Fetch-Site", "none")
-		req.Header.Set("Sec-Fetch-User", "?1")
-		req.Header.Set("Cache-Control", "max-age=0")
-
-		resp, err := f.client.Do(req)
-		if err != nil {
-			lastError = fmt.Errorf("HTTP request failed: %w", err)
-			time.Sleep(f.backoffDuration(attempts))
-			continue
+	result, err := f.fetchPage(url, conditional)
+	if err == nil && result.Error == nil && !result.NotModified {
+		if err := f.cache.Put(result); err != nil && f.verbose {
+			fmt.Printf("[Cache] %s: %v\n", url, err)
 		}
+	}
+	return result, err
+}
 
-		defer resp.Body.Close()
+// fetchPage fetches url, rendering it when it's a page missing the render
+// selectors' content.
+func (f *Fetcher) fetchPage(url string, conditional bool) (*FetchResult, error) {
+	result, err := f.fetch(url, conditional, 0, nil)
+	if err == nil {
+		f.renderFallback(result)
+	}
+	return result, err
+}
 
-		body, err := io.ReadAll(resp.Body)
+// fetch GETs url, retrying failures. maxBytes, when positive, caps the body
+// as described for FetchLimited, and stream, when set, takes the body as
+// described for FetchStream.
+func (f *Fetcher) fetch(url string, conditional bool, maxBytes int64, stream *stream) (*FetchResult, error) {
+	conditional = conditional && f.validators != nil
+	newContext := func() (context.Context, context.CancelFunc) {
+		ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+		if conditional {
+			ctx = context.WithValue(ctx, conditionalKey{}, true)
+		}
+		if maxBytes > 0 {
+			ctx = context.WithValue(ctx, maxBodyKey{}, maxBytes)
+		}
+		if stream != nil {
+			ctx = context.WithValue(ctx, streamKey{}, stream)
+		}
+		return ctx, cancel
+	}
+	f.waitThrottle()
+	ctx, cancel := newContext()
+	defer func() { cancel() }()
+
+	f.fetches.Add(1)
+	start := time.Now()
+	var lastError, budgetErr error
+	var attempts, lastStatus int
+	var history []Attempt
+
+	for attempts = 1; attempts <= f.maxRetries; attempts++ {
+		if f.verbose {
+			fmt.Printf("Fetching attempt %d/%d: %s\n", attempts, f.maxRetries, url)
+		}
+
+		attemptStart := time.Now()
+		resp, body, err := f.hedgedAttempt(ctx, url)
 		if err != nil {
-			lastError = fmt.Errorf("read response body failed: %w", err)
-			time.Sleep(f.backoffDuration(attempts))
+			lastError = err
+			lastStatus = 0
+			history = append(history, Attempt{Error: err.Error(), Duration: time.Since(attemptStart)})
+			if errors.Is(err, ErrNoProxies) || errors.Is(err, ErrTooLarge) || errors.Is(err, errStreamed) || errors.Is(err, ErrRedirectRefused) {
+				// Retrying can't bring a proxy back, shrink the resource,
+				// give a consumer its body again or change where it redirects
+				break
+			}
+			var retry bool
+			if retry, budgetErr = f.retryAfter(url, attempts); !retry {
+				break
+			}
 			continue
 		}
 
 		if resp.StatusCode >= 400 {
 			lastError = fmt.Errorf("HTTP error: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
-			if resp.StatusCode == 404 || resp.StatusCode == 403 {
-				// Don't retry on 404 or 403
+			lastStatus = resp.StatusCode
+			history = append(history, Attempt{StatusCode: resp.StatusCode, Error: lastError.Error(), Duration: time.Since(attemptStart)})
+			if resp.StatusCode == 404 || resp.StatusCode == 410 || resp.StatusCode == 403 {
+				// Don't retry on 404, 410 or 403
 				break
 			}
-			time.Sleep(f.backoffDuration(attempts))
+			throttled := f.throttle(url, resp, attempts)
+			var retry bool
+			if retry, budgetErr = f.retryAfter(url, attempts); !retry {
+				break
+			}
+			if throttled {
+				// The pause doesn't count against the request timeout
+				cancel()
+				ctx, cancel = newContext()
+			}
 			continue
 		}
 
 		duration := time.Since(start)
+		history = append(history, Attempt{StatusCode: resp.StatusCode, Duration: time.Since(attemptStart)})
 
-		return &FetchResult{
-			URL:        url,
-			StatusCode: resp.StatusCode,
-			Body:       body,
-			Error:      nil,
-			Attempts:   attempts,
-			Duration:   duration,
-		}, nil
+		if resp.StatusCode == http.StatusNotModified {
+			f.notModified.Add(1)
+		} else if conditional {
+			if err := f.validators.put(url, resp.Header); err != nil && f.verbose {
+				fmt.Printf("[Conditional] %s: %v\n", url, err)
+			}
+		}
+
+		result := &FetchResult{
+			URL:           url,
+			StatusCode:    resp.StatusCode,
+			ContentType:   resp.Header.Get("Content-Type"),
+			ContentLength: resp.ContentLength,
+			Body:          body,
+			Error:         nil,
+			Attempts:      attempts,
+			History:       history,
+			Duration:      duration,
+			FinalURL:      resp.Request.URL.String(),
+			Redirects:     redirectChain(resp),
+			NotModified:   resp.StatusCode == http.StatusNotModified,
+		}
+		if stream == nil {
+			f.toUTF8(result)
+		}
+		return result, nil
 	}
 
 	duration := time.Since(start)
 
+	fetchErr := &FetchError{
+		StatusCode: lastStatus,
+		History:    history,
+		err:        fmt.Errorf("max retries exceeded, last error: %w", lastError),
+	}
+	if budgetErr != nil {
+		fetchErr.err = fmt.Errorf("%w, last error: %w", budgetErr, lastError)
+	}
+
 	return &FetchResult{
 		URL:        url,
-		StatusCode: 0,
+		StatusCode: lastStatus,
 		Body:       nil,
-		Error:      fmt.Errorf("max retries exceeded, last error: %w", lastError),
-		Attempts:   attempts - 1,
+		Error:      fetchErr,
+		Attempts:   min(attempts, f.maxRetries),
+		History:    history,
 		Duration:   duration,
 	}, nil
 }
 
-func (f *Fetcher) backoffDuration(attempt int) time.Duration {
-	// Exponential backoff: 1s, 2s, 4s, 8s, etc.
-	backoff := time.Duration(1<<uint(attempt-1)) * time.Second
+// attempt makes a single GET request and reads the whole body, unless it
+// is streamed.
+func (f *Fetcher) attempt(ctx context.Context, url string) (*http.Response, []byte, error) {
+	req, err := f.newRequest(ctx, "GET", url)
+	if err != nil {
+		return nil, nil, fmt.Errorf("create request failed: %w", err)
+	}
+	if ctx.Value(conditionalKey{}) != nil {
+		f.addValidators(req, url)
+	}
+
+	resp, err := f.do(req)
+	if err != nil {
+		return nil, nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if streamed, err := f.streamBody(ctx, url, resp); streamed {
+		if err != nil {
+			return nil, nil, err
+		}
+		return resp, nil, nil
+	}
+
+	body, err := readBody(ctx, resp)
+	f.bytesRead.Add(int64(len(body)))
+	if errors.Is(err, ErrTooLarge) {
+		return nil, nil, err
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("read response body failed: %w", err)
+	}
+
+	return resp, body, nil
+}
+
+// Head issues a single HEAD request, for cheap checks such as resource size.
+// The returned result has no body.
+func (f *Fetcher) Head(url string) (*FetchResult, error) {
+	f.waitThrottle()
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	start := time.Now()
+
+	req, err := f.newRequest(ctx, "HEAD", url)
+	if err != nil {
+		return nil, fmt.Errorf("create request failed: %w", err)
+	}
+
+	resp, err := f.do(req)
+	if err != nil {
+		return nil, fmt.Errorf("HTTP request failed: %w", err)
+	}
+	resp.Body.Close()
+
+	result := &FetchResult{
+		URL:           url,
+		StatusCode:    resp.StatusCode,
+		ContentType:   resp.Header.Get("Content-Type"),
+		ContentLength: resp.ContentLength,
+		Attempts:      1,
+		Duration:      time.Since(start),
+	}
+	if resp.StatusCode >= 400 {
+		result.Error = fmt.Errorf("HTTP error: %d %s", resp.StatusCode, http.StatusText(resp.StatusCode))
+		f.throttle(url, resp, 1)
+	}
+
+	return result, nil
+}
+
+func (f *Fetcher) newRequest(ctx context.Context, method, url string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", f.userAgentFor(req.URL.Hostname()))
+	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
+	req.Header.Set("Accept-Language", "zh-CN,zh;q=0.9,en-US;q=0.8,en;q=0.7")
+	// Don't set Accept-Encoding - let Go handle decompression automatically
+	req.Header.Set("Connection", "keep-alive")
+	req.Header.Set("Upgrade-Insecure-Requests", "1")
+	req.Header.Set("Sec-Fetch-Dest", "document")
+	req.Header.Set("Sec-Fetch-Mode", "navigate")
+	req.Header.Set("Sec-Fetch-Site", "none")
+	req.Header.Set("Sec-Fetch-User", "?1")
+	req.Header.Set("Cache-Control", "max-age=0")
+	f.applyHeaders(req)
 
-	// Cap at 30 seconds
-	if backoff > 30*time.Second {
-		return 30 * time.Second
+	if cred, ok := f.credentialFor(req); ok {
+		cred.apply(req)
 	}
 
-	return backoff
+	return req, nil
 }
 
 func (f *Fetcher) SetUserAgent(userAgent string) {
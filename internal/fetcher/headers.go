@@ -0,0 +1,69 @@
+package fetcher
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// ParseHeaders reads extra request headers written "Name: value", one per
+// entry. A header with an empty value removes that header from requests.
+// Blank entries and entries starting with # are ignored.
+func ParseHeaders(entries []string) (http.Header, error) {
+	headers := make(http.Header)
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+
+		name, value, ok := strings.Cut(entry, ":")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" || strings.ContainsAny(name, " \t") {
+			return nil, fmt.Errorf("invalid header %q: want \"Name: value\"", entry)
+		}
+		headers.Add(name, strings.TrimSpace(value))
+	}
+	return headers, nil
+}
+
+// LoadHeaders reads a file of extra request headers, one "Name: value" per
+// line.
+func LoadHeaders(path string) (http.Header, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open header file: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read header file: %w", err)
+	}
+	return ParseHeaders(lines)
+}
+
+// SetHeaders adds headers to every request, replacing the fetcher's own
+// headers of the same name; an empty value removes the header instead.
+// Credentials set for a host still take precedence.
+func (f *Fetcher) SetHeaders(headers http.Header) {
+	f.headers = headers
+}
+
+// applyHeaders sets the extra headers on req.
+func (f *Fetcher) applyHeaders(req *http.Request) {
+	for name, values := range f.headers {
+		req.Header.Del(name)
+		for _, value := range values {
+			if value != "" {
+				req.Header.Add(name, value)
+			}
+		}
+	}
+}
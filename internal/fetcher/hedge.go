@@ -0,0 +1,166 @@
+package fetcher
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// latencyWindowSize is how many recent response times the hedging
+	// threshold is computed from
+	latencyWindowSize = 256
+
+	// minLatencySamples is how many responses must be seen before hedging
+	// starts, so early outliers don't set the threshold
+	minLatencySamples = 20
+)
+
+// latencyWindow keeps the most recent response times.
+type latencyWindow struct {
+	mu      sync.Mutex
+	samples []time.Duration
+	next    int
+}
+
+func (w *latencyWindow) add(d time.Duration) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.samples) < latencyWindowSize {
+		w.samples = append(w.samples, d)
+		return
+	}
+	w.samples[w.next] = d
+	w.next = (w.next + 1) % latencyWindowSize
+}
+
+// percentile returns the given percentile of the recent response times, or
+// false while there are too few samples.
+func (w *latencyWindow) percentile(p float64) (time.Duration, bool) {
+	w.mu.Lock()
+	sorted := slices.Clone(w.samples)
+	w.mu.Unlock()
+
+	if len(sorted) < minLatencySamples {
+		return 0, false
+	}
+	slices.Sort(sorted)
+	i := int(float64(len(sorted)-1) * p / 100)
+	return sorted[i], true
+}
+
+// hedger sends a second request when the first is slower than a percentile
+// of recent response times, and takes whichever response arrives first.
+type hedger struct {
+	percentile float64
+	latencies  latencyWindow
+	sent       atomic.Int64
+	won        atomic.Int64
+}
+
+type attemptResult struct {
+	resp *http.Response
+	body []byte
+	err  error
+	// hedge marks the result of the second request
+	hedge bool
+}
+
+// SetHedge enables hedged requests: when a response takes longer than the
+// given percentile (e.g. 95) of recent response times, a second request is
+// sent and the slower of the two is cancelled. 0 disables hedging.
+func (f *Fetcher) SetHedge(percentile float64) {
+	if percentile <= 0 {
+		f.hedge = nil
+		return
+	}
+	f.hedge = &hedger{percentile: percentile}
+}
+
+// SetHedgeRateLimit makes each hedge take a token from the crawl's rate
+// limiter with allow, which reports false when none is available right away.
+// The hedge is then skipped, so hedging never pushes the crawl over its rate.
+func (f *Fetcher) SetHedgeRateLimit(allow func() bool) {
+	f.hedgeAllow = allow
+}
+
+// HedgeStats returns how many hedged requests were sent and how many of them
+// answered before the original request.
+func (f *Fetcher) HedgeStats() (sent, won int64) {
+	if f.hedge == nil {
+		return 0, 0
+	}
+	return f.hedge.sent.Load(), f.hedge.won.Load()
+}
+
+// hedgedAttempt is attempt with hedging, when enabled.
+func (f *Fetcher) hedgedAttempt(ctx context.Context, url string) (*http.Response, []byte, error) {
+	// A streamed body can only be handed to its consumer once
+	if f.hedge == nil || ctx.Value(streamKey{}) != nil {
+		return f.attempt(ctx, url)
+	}
+
+	start := time.Now()
+	record := func(r attemptResult) (*http.Response, []byte, error) {
+		if r.err == nil {
+			f.hedge.latencies.add(time.Since(start))
+			if r.hedge {
+				f.hedge.won.Add(1)
+			}
+		}
+		return r.resp, r.body, r.err
+	}
+
+	delay, ok := f.hedge.latencies.percentile(f.hedge.percentile)
+	if !ok {
+		resp, body, err := f.attempt(ctx, url)
+		return record(attemptResult{resp: resp, body: body, err: err})
+	}
+
+	// Cancelling on return stops whichever request is still running
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan attemptResult, 2)
+	run := func(hedge bool) {
+		resp, body, err := f.attempt(ctx, url)
+		results <- attemptResult{resp: resp, body: body, err: err, hedge: hedge}
+	}
+
+	go run(false)
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case r := <-results:
+		return record(r)
+	case <-timer.C:
+	}
+
+	if f.hedgeAllow != nil && !f.hedgeAllow() {
+		if f.verbose {
+			fmt.Printf("[Hedge] No response from %s after %v, but no rate to spare for a second request\n", url, delay.Round(time.Millisecond))
+		}
+		return record(<-results)
+	}
+	if f.verbose {
+		fmt.Printf("[Hedge] No response from %s after %v, sending a second request\n", url, delay.Round(time.Millisecond))
+	}
+	f.hedge.sent.Add(1)
+	go run(true)
+
+	// Take the first response; if it failed, wait for the other
+	r := <-results
+	if r.err != nil {
+		if other := <-results; other.err == nil {
+			r = other
+		}
+	}
+	return record(r)
+}
@@ -0,0 +1,38 @@
+package fetcher
+
+import "time"
+
+// Attempt is one request made by a fetch.
+type Attempt struct {
+	// StatusCode is the response status, or 0 when no response arrived
+	StatusCode int
+	// Error is why the attempt failed, empty for the one that succeeded
+	Error    string
+	Duration time.Duration
+}
+
+// FetchError is the error of a fetch that failed after all its attempts.
+type FetchError struct {
+	// StatusCode is the status of the last response, or 0 when the last
+	// attempt got none
+	StatusCode int
+	History    []Attempt
+	err        error
+}
+
+func (e *FetchError) Error() string {
+	return e.err.Error()
+}
+
+func (e *FetchError) Unwrap() error {
+	return e.err
+}
+
+// AttemptHistory returns how many requests the fetch made, why each one
+// failed and the last response status.
+func (e *FetchError) AttemptHistory() (attempts int, errs []string, status int) {
+	for _, attempt := range e.History {
+		errs = append(errs, attempt.Error)
+	}
+	return len(e.History), errs, e.StatusCode
+}
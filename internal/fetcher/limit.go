@@ -0,0 +1,59 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ErrTooLarge is wrapped by failures of FetchLimited for a response over
+// its size cap. They are not retried.
+var ErrTooLarge = errors.New("response too large")
+
+type maxBodyKey struct{}
+
+// maxPresize bounds the buffer a declared Content-Length reserves up front,
+// so a bogus header can't make it huge.
+const maxPresize = 64 << 20
+
+// FetchLimited is Fetch for a resource that is only wanted up to maxBytes,
+// such as an image: a response declaring a larger Content-Length is dropped
+// before its body is read, and one without stops being read past the cap,
+// so an oversized body is never held in memory. It neither shares in-flight
+// fetches nor uses the response cache.
+func (f *Fetcher) FetchLimited(url string, maxBytes int64) (*FetchResult, error) {
+	return f.fetch(url, false, maxBytes, nil)
+}
+
+// readBody reads resp's body, up to the cap FetchLimited put in ctx.
+func readBody(ctx context.Context, resp *http.Response) ([]byte, error) {
+	limit, _ := ctx.Value(maxBodyKey{}).(int64)
+	if limit <= 0 {
+		return readAll(resp)
+	}
+	if resp.ContentLength > limit {
+		return nil, fmt.Errorf("%w: Content-Length %d over the %d byte cap", ErrTooLarge, resp.ContentLength, limit)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err == nil && int64(len(body)) > limit {
+		return body, fmt.Errorf("%w: body over the %d byte cap", ErrTooLarge, limit)
+	}
+	return body, err
+}
+
+// readAll reads resp's whole body into a buffer sized from its
+// Content-Length, when the server sent one, rather than growing it (and
+// copying the body again) as the body arrives.
+func readAll(resp *http.Response) ([]byte, error) {
+	if resp.ContentLength <= 0 || resp.ContentLength > maxPresize {
+		return io.ReadAll(resp.Body)
+	}
+	var buf bytes.Buffer
+	buf.Grow(int(resp.ContentLength) + bytes.MinRead)
+	_, err := buf.ReadFrom(resp.Body)
+	return buf.Bytes(), err
+}
@@ -0,0 +1,61 @@
+package fetcher
+
+import (
+	"net/http"
+	"time"
+)
+
+// FetchObserver is told about every request the fetcher sends, so callers
+// can record latencies, status codes and retries without changing the
+// fetcher. Methods are called from several goroutines at once, on the
+// request's own goroutine, and should return quickly.
+type FetchObserver interface {
+	// OnRequest is called as each request is sent: first attempts,
+	// retries, hedges and HEAD requests alike
+	OnRequest(req *http.Request)
+	// OnResponse is called when a request gets a response, with its status
+	// and the time until its headers arrived
+	OnResponse(req *http.Request, statusCode int, latency time.Duration)
+	// OnRetry is called before a failed fetch of url is tried again, with
+	// the number of the attempt about to be made and the wait before it
+	OnRetry(url string, attempt int, wait time.Duration)
+	// OnError is called when a request gets no response, with the time it
+	// took to fail
+	OnError(req *http.Request, err error, latency time.Duration)
+}
+
+// AddObserver registers o to be told about every request from now on.
+// Observers must be added before fetching starts.
+func (f *Fetcher) AddObserver(o FetchObserver) {
+	f.observers = append(f.observers, o)
+}
+
+// observedDo is send with the observers told about the request and its
+// outcome.
+func (f *Fetcher) observedDo(req *http.Request, send func(*http.Request) (*http.Response, error)) (*http.Response, error) {
+	if len(f.observers) == 0 {
+		return send(req)
+	}
+
+	for _, o := range f.observers {
+		o.OnRequest(req)
+	}
+	start := time.Now()
+	resp, err := send(req)
+	latency := time.Since(start)
+	for _, o := range f.observers {
+		if err != nil {
+			o.OnError(req, err, latency)
+		} else {
+			o.OnResponse(req, resp.StatusCode, latency)
+		}
+	}
+	return resp, err
+}
+
+// observeRetry tells the observers a fetch of url is about to be retried.
+func (f *Fetcher) observeRetry(url string, attempt int, wait time.Duration) {
+	for _, o := range f.observers {
+		o.OnRetry(url, attempt, wait)
+	}
+}
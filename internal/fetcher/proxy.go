@@ -0,0 +1,219 @@
+package fetcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+)
+
+// ErrNoProxies is returned for requests made after every proxy in the pool
+// was removed as dead. Requests are never sent directly instead, since the
+// pool may be what keeps the crawler's own address off the site.
+var ErrNoProxies = errors.New("no live proxies left")
+
+// DefaultProxyFailures is how many consecutive failures remove a proxy.
+const DefaultProxyFailures = 3
+
+// ProxyStatus is the health of one proxy in the pool.
+type ProxyStatus struct {
+	// URL is the proxy with any password redacted
+	URL      string
+	Requests int64
+	Failures int64
+	Dead     bool
+}
+
+type proxy struct {
+	url         *url.URL
+	requests    int64
+	failures    int64
+	consecutive int
+	dead        bool
+}
+
+// proxyPool hands out proxies in rotation, skipping dead ones.
+type proxyPool struct {
+	mu          sync.Mutex
+	proxies     []*proxy
+	next        int
+	maxFailures int
+	verbose     bool
+}
+
+type proxyKey struct{}
+
+// ParseProxies parses proxy URLs, separated by commas or newlines. Entries
+// without a scheme are taken as http://host:port; blank lines and lines
+// starting with # are ignored.
+func ParseProxies(text string) ([]*url.URL, error) {
+	var proxies []*url.URL
+	for _, entry := range strings.FieldsFunc(text, func(r rune) bool { return r == ',' || r == '\n' }) {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if !strings.Contains(entry, "://") {
+			entry = "http://" + entry
+		}
+
+		u, err := url.Parse(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy %q: %w", entry, err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" {
+			return nil, fmt.Errorf("invalid proxy %s: unsupported scheme %q (want http or https)", u.Redacted(), u.Scheme)
+		}
+		if u.Host == "" {
+			return nil, fmt.Errorf("invalid proxy %s: missing host", u.Redacted())
+		}
+		proxies = append(proxies, u)
+	}
+	return proxies, nil
+}
+
+// LoadProxies reads proxies from a file, one per line, in the ParseProxies
+// format.
+func LoadProxies(path string) ([]*url.URL, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open proxy list: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read proxy list: %w", err)
+	}
+	return ParseProxies(strings.Join(lines, "\n"))
+}
+
+// SetProxies spreads requests over proxies in rotation. A proxy that fails
+// maxFailures requests in a row (connection errors, or 407, 429, 502, 503 or
+// 504 responses) is removed from the rotation for the rest of the run.
+func (f *Fetcher) SetProxies(proxies []*url.URL, maxFailures int) {
+	if len(proxies) == 0 {
+		f.proxies = nil
+		return
+	}
+
+	pool := &proxyPool{maxFailures: maxFailures, verbose: f.verbose}
+	for _, u := range proxies {
+		pool.proxies = append(pool.proxies, &proxy{url: u})
+	}
+	f.proxies = pool
+
+	if transport, ok := f.client.Transport.(*http.Transport); ok {
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if p, ok := req.Context().Value(proxyKey{}).(*proxy); ok {
+				return p.url, nil
+			}
+			return nil, nil
+		}
+	}
+}
+
+// ProxyStatus reports the health of every proxy, in the order given.
+func (f *Fetcher) ProxyStatus() []ProxyStatus {
+	if f.proxies == nil {
+		return nil
+	}
+
+	f.proxies.mu.Lock()
+	defer f.proxies.mu.Unlock()
+
+	status := make([]ProxyStatus, 0, len(f.proxies.proxies))
+	for _, p := range f.proxies.proxies {
+		status = append(status, ProxyStatus{
+			URL:      p.url.Redacted(),
+			Requests: p.requests,
+			Failures: p.failures,
+			Dead:     p.dead,
+		})
+	}
+	return status
+}
+
+// pick returns the next live proxy.
+func (pool *proxyPool) pick() (*proxy, error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	for range pool.proxies {
+		p := pool.proxies[pool.next]
+		pool.next = (pool.next + 1) % len(pool.proxies)
+		if !p.dead {
+			p.requests++
+			return p, nil
+		}
+	}
+	return nil, ErrNoProxies
+}
+
+// report records the outcome of a request sent through p.
+func (pool *proxyPool) report(p *proxy, resp *http.Response, err error) {
+	pool.mu.Lock()
+	defer pool.mu.Unlock()
+
+	if err == nil && !proxyFailureStatus(resp.StatusCode) {
+		p.consecutive = 0
+		return
+	}
+
+	p.failures++
+	p.consecutive++
+	if p.dead || p.consecutive < pool.maxFailures {
+		return
+	}
+	p.dead = true
+
+	alive := 0
+	for _, other := range pool.proxies {
+		if !other.dead {
+			alive++
+		}
+	}
+	fmt.Printf("[Proxy] Removed %s after %d consecutive failures; %d of %d proxies left\n", p.url.Redacted(), p.consecutive, alive, len(pool.proxies))
+}
+
+// proxyFailureStatus reports whether a status code suggests the proxy,
+// rather than the page, is at fault: the proxy refused us, couldn't reach
+// the site, or its address is being rate limited.
+func proxyFailureStatus(code int) bool {
+	switch code {
+	case http.StatusProxyAuthRequired, http.StatusTooManyRequests,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do sends req, through the next proxy when a pool is set, and counts it.
+func (f *Fetcher) do(req *http.Request) (*http.Response, error) {
+	if f.proxies == nil {
+		f.requests.Add(1)
+		return f.observedDo(req, f.client.Do)
+	}
+
+	p, err := f.proxies.pick()
+	if err != nil {
+		return nil, err
+	}
+	if f.verbose {
+		fmt.Printf("[Proxy] %s via %s\n", req.URL, p.url.Redacted())
+	}
+
+	f.requests.Add(1)
+	resp, err := f.observedDo(req.WithContext(context.WithValue(req.Context(), proxyKey{}, p)), f.client.Do)
+	f.proxies.report(p, resp, err)
+	return resp, err
+}
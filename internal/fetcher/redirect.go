@@ -0,0 +1,41 @@
+package fetcher
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ErrRedirectRefused is wrapped by failures of requests whose redirect the
+// redirect policy doesn't allow. They are not retried.
+var ErrRedirectRefused = errors.New("redirect refused")
+
+// DefaultMaxRedirects is how many redirects a request follows by default,
+// as net/http does.
+const DefaultMaxRedirects = 10
+
+// SetRedirectPolicy limits the redirects requests follow: at most
+// maxRedirects of them (0 follows none), and with sameHost only to the host
+// the request was sent to, ignoring a "www." prefix. A request redirected
+// beyond that fails with ErrRedirectRefused.
+func (f *Fetcher) SetRedirectPolicy(maxRedirects int, sameHost bool) error {
+	if maxRedirects < 0 {
+		return fmt.Errorf("max redirects must not be negative")
+	}
+	f.client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) > maxRedirects {
+			return fmt.Errorf("%w: more than %d redirects", ErrRedirectRefused, maxRedirects)
+		}
+		if from := via[0].URL.Hostname(); sameHost && bareHost(req.URL.Hostname()) != bareHost(from) {
+			return fmt.Errorf("%w: %s redirects to another host (%s)", ErrRedirectRefused, via[0].URL, req.URL)
+		}
+		return nil
+	}
+	return nil
+}
+
+// bareHost returns host lowercased and without a "www." prefix.
+func bareHost(host string) string {
+	return strings.TrimPrefix(strings.ToLower(host), "www.")
+}
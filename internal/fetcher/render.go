@@ -0,0 +1,174 @@
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/chromedp/chromedp"
+)
+
+// DefaultRenderSelectors are the elements whose text a page's static HTML
+// must have to be used without rendering: the abstract, which script-built
+// article pages leave empty along with the metrics.
+var DefaultRenderSelectors = []string{"[class*='abstract']"}
+
+// renderSettle is how long a rendered page gets to fill in the render
+// selectors after it has loaded.
+const renderSettle = 5 * time.Second
+
+// Renderer loads pages in a headless Chrome or Chromium and returns the
+// HTML after their scripts have run.
+type Renderer struct {
+	browser context.Context
+	cancel  func()
+	timeout time.Duration
+}
+
+// NewRenderer starts a headless browser: the one at path, or Chrome or
+// Chromium found on the PATH when path is empty. Each page gets timeout to
+// load.
+func NewRenderer(path string, timeout time.Duration) (*Renderer, error) {
+	opts := append(chromedp.DefaultExecAllocatorOptions[:], chromedp.UserAgent(defaultUserAgent))
+	if path != "" {
+		opts = append(opts, chromedp.ExecPath(path))
+	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	browser, cancelBrowser := chromedp.NewContext(allocCtx)
+	cancel := func() {
+		cancelBrowser()
+		cancelAlloc()
+	}
+
+	// Running no actions starts the browser
+	if err := chromedp.Run(browser); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to start headless browser: %w", err)
+	}
+	return &Renderer{browser: browser, cancel: cancel, timeout: timeout}, nil
+}
+
+// Render loads url in a new tab and returns its HTML once the page has
+// loaded and every selector matches an element with text, or renderSettle
+// has passed.
+func (r *Renderer) Render(url string, selectors []string) ([]byte, error) {
+	tab, closeTab := chromedp.NewContext(r.browser)
+	defer closeTab()
+	ctx, cancel := context.WithTimeout(tab, r.timeout)
+	defer cancel()
+
+	var html string
+	err := chromedp.Run(ctx,
+		chromedp.Navigate(url),
+		chromedp.WaitReady("body"),
+		waitForText(selectors),
+		chromedp.OuterHTML("html", &html),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render %s: %w", url, err)
+	}
+	return []byte(html), nil
+}
+
+// Close shuts the browser down.
+func (r *Renderer) Close() {
+	r.cancel()
+}
+
+// waitForText polls until every selector matches an element with text,
+// giving up quietly after renderSettle. Selectors the browser doesn't
+// understand, such as goquery's :contains, count as matched.
+func waitForText(selectors []string) chromedp.Action {
+	return chromedp.ActionFunc(func(ctx context.Context) error {
+		list, err := json.Marshal(selectors)
+		if err != nil {
+			return err
+		}
+		script := `(` + string(list) + `).every(s => {
+			try {
+				const e = document.querySelector(s);
+				return e !== null && e.innerText.trim() !== "";
+			} catch (_) {
+				return true;
+			}
+		})`
+
+		deadline := time.Now().Add(renderSettle)
+		for {
+			var ready bool
+			if err := chromedp.Evaluate(script, &ready).Do(ctx); err != nil {
+				return err
+			}
+			if ready || time.Now().After(deadline) {
+				return nil
+			}
+			select {
+			case <-time.After(200 * time.Millisecond):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	})
+}
+
+// SetRenderer makes Fetch and FetchConditional load an HTML page in the
+// renderer when its static HTML has no text under any of selectors, as
+// happens when the page fills them in with JavaScript.
+func (f *Fetcher) SetRenderer(renderer *Renderer, selectors []string) {
+	f.renderer = renderer
+	f.renderSelectors = selectors
+}
+
+// Rendered returns how many pages were loaded in the renderer.
+func (f *Fetcher) Rendered() int64 {
+	return f.rendered.Load()
+}
+
+// renderFallback replaces the body of an HTML page missing text under the
+// render selectors with the rendered page. A page that fails to render keeps
+// its static HTML.
+func (f *Fetcher) renderFallback(result *FetchResult) {
+	if f.renderer == nil || result.Error != nil || result.NotModified ||
+		!strings.Contains(result.ContentType, "html") || !missingText(result.Body, f.renderSelectors) {
+		return
+	}
+
+	url := result.FinalURL
+	if url == "" {
+		url = result.URL
+	}
+	if f.verbose {
+		fmt.Printf("[Render] Static HTML lacks key content, rendering %s\n", url)
+	}
+	start := time.Now()
+	body, err := f.renderer.Render(url, f.renderSelectors)
+	if err != nil {
+		fmt.Printf("[Render] %v; keeping the static HTML\n", err)
+		return
+	}
+
+	f.rendered.Add(1)
+	result.Body = body
+	result.ContentLength = int64(len(body))
+	result.Duration += time.Since(start)
+	result.Rendered = true
+}
+
+// missingText reports whether any selector matches no element with text in
+// the HTML.
+func missingText(body []byte, selectors []string) bool {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(body))
+	if err != nil {
+		return false
+	}
+	for _, selector := range selectors {
+		if strings.TrimSpace(doc.Find(selector).Text()) == "" {
+			return true
+		}
+	}
+	return false
+}
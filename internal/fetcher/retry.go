@@ -0,0 +1,53 @@
+package fetcher
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is wrapped by failures that were not retried
+// because the crawl-wide retry budget was used up.
+var ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+
+// minRetryBudget lets the first retries of a run through before enough
+// fetches have been made to take a share of.
+const minRetryBudget = 10
+
+// SetRetryBudget limits retries across all fetches to percent of the number
+// of fetches (plus a small allowance), so a systemic outage fails fast
+// instead of multiplying the run time by the retry count. 0 removes the
+// limit.
+func (f *Fetcher) SetRetryBudget(percent float64) {
+	f.retryBudget = percent / 100
+}
+
+// Retries returns how many retries were made and how many were refused by
+// the retry budget.
+func (f *Fetcher) Retries() (retries, denied int64) {
+	return f.retries.Load(), f.retriesDenied.Load()
+}
+
+// retryAfter decides whether a failed attempt at url is retried, spending
+// from the retry budget, and waits out the backoff if so, or the pause the
+// server asked for when that is longer.
+func (f *Fetcher) retryAfter(url string, attempt int) (bool, error) {
+	if attempt >= f.maxRetries {
+		return false, nil
+	}
+
+	for {
+		spent := f.retries.Load()
+		if f.retryBudget > 0 && float64(spent+1) > f.retryBudget*float64(f.fetches.Load())+minRetryBudget {
+			f.retriesDenied.Add(1)
+			return false, ErrRetryBudgetExhausted
+		}
+		if f.retries.CompareAndSwap(spent, spent+1) {
+			break
+		}
+	}
+
+	wait := max(f.backoffDuration(attempt), time.Until(f.ThrottledUntil()))
+	f.observeRetry(url, attempt+1, wait)
+	time.Sleep(wait)
+	return true, nil
+}
@@ -0,0 +1,133 @@
+package fetcher
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"unicode/utf8"
+
+	"golang.org/x/net/html/charset"
+	"golang.org/x/text/encoding/simplifiedchinese"
+	"golang.org/x/text/transform"
+)
+
+// errStreamed is wrapped by failures of a streamed body, which can't be
+// retried once the consumer has started reading it.
+var errStreamed = errors.New("streamed body failed")
+
+// sniffLen is how much of a streamed page is looked at for its charset.
+const sniffLen = 1024
+
+type streamKey struct{}
+
+// stream carries a FetchStream consumer to the attempt that gets the
+// response, and the charset that attempt transcoded from back.
+type stream struct {
+	consume func(body io.Reader, finalURL string) error
+	err     error
+	charset string
+}
+
+// FetchStream GETs url like Fetch, but hands a successful response's body
+// to consume as it arrives instead of reading it into memory first, so a
+// parser can build its document straight from the connection, along with
+// the URL the body came from after any redirects. The body is
+// converted to UTF-8 on the way, like Fetch's; the returned result has no
+// Body. Failed requests and error statuses are retried as usual, but once
+// consume has been called the fetch isn't: consume's error, or the read
+// error it reports, is returned. Streamed fetches bypass the response
+// cache, validators, hedging, rendering and the sharing of in-flight
+// fetches, all of which need the whole body.
+func (f *Fetcher) FetchStream(url string, consume func(body io.Reader, finalURL string) error) (*FetchResult, error) {
+	s := &stream{consume: consume}
+	result, err := f.fetch(url, false, 0, s)
+	if s.err != nil {
+		return nil, s.err
+	}
+	if err == nil {
+		result.Charset = s.charset
+	}
+	return result, err
+}
+
+// streamBody hands resp's body to the consumer in ctx, if any, reporting
+// whether there was one.
+func (f *Fetcher) streamBody(ctx context.Context, url string, resp *http.Response) (bool, error) {
+	s, ok := ctx.Value(streamKey{}).(*stream)
+	if !ok || resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return false, nil
+	}
+
+	counted := &countingReader{r: resp.Body}
+	body, name, err := utf8Reader(counted, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return true, fmt.Errorf("%w: %w", errStreamed, err)
+	}
+	if name != "" {
+		f.transcoded.Add(1)
+		s.charset = name
+		if f.verbose {
+			fmt.Printf("[Charset] Transcoding %s from %s\n", url, name)
+		}
+	}
+
+	s.err = s.consume(body, resp.Request.URL.String())
+	f.bytesRead.Add(counted.n)
+	if s.err != nil {
+		return true, fmt.Errorf("%w: %w", errStreamed, s.err)
+	}
+	return true, nil
+}
+
+// utf8Reader returns r decoding to UTF-8, with the name of the charset it
+// decodes from ("" for UTF-8), judged as transcode does but from the first
+// sniffLen bytes only. Responses that aren't HTML pass through as they are.
+func utf8Reader(r io.Reader, contentType string) (io.Reader, string, error) {
+	buffered := bufio.NewReaderSize(r, sniffLen)
+	head, err := buffered.Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return nil, "", err
+	}
+	if !isHTML(contentType, head) {
+		return buffered, "", nil
+	}
+
+	encoding, name, certain := charset.DetermineEncoding(head, contentType)
+	switch {
+	case name == "utf-8" && validPrefix(head):
+		return buffered, "", nil
+	case name == "utf-8", !certain && name == "windows-1252":
+		if validPrefix(head) {
+			return buffered, "", nil
+		}
+		encoding, name = simplifiedchinese.GB18030, "gb18030"
+	}
+	return transform.NewReader(buffered, encoding.NewDecoder()), name, nil
+}
+
+// validPrefix reports whether head is valid UTF-8, allowing for a character
+// cut off at its end.
+func validPrefix(head []byte) bool {
+	for i := 0; i < utf8.UTFMax && len(head) > 0; i++ {
+		if utf8.Valid(head) {
+			return true
+		}
+		head = head[:len(head)-1]
+	}
+	return utf8.Valid(head)
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,84 @@
+package fetcher
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxThrottle caps how long one response can pause fetching, whatever its
+// Retry-After asks for.
+const maxThrottle = 5 * time.Minute
+
+// Throttled returns how many responses asked the crawler to slow down: 429
+// Too Many Requests, or 503 with a Retry-After header.
+func (f *Fetcher) Throttled() int64 {
+	return f.throttled.Load()
+}
+
+// ThrottledUntil returns when the pause the server last asked for ends, or
+// the zero time when fetching isn't paused.
+func (f *Fetcher) ThrottledUntil() time.Time {
+	until := time.Unix(0, f.throttledUntil.Load())
+	if !until.After(time.Now()) {
+		return time.Time{}
+	}
+	return until
+}
+
+// throttle pauses every fetch when resp asks the crawler to slow down, for
+// as long as its Retry-After says, or the unjittered backoff for attempt
+// without one. It reports whether resp was such a response.
+func (f *Fetcher) throttle(url string, resp *http.Response, attempt int) bool {
+	if resp.StatusCode != http.StatusTooManyRequests && resp.StatusCode != http.StatusServiceUnavailable {
+		return false
+	}
+	delay, ok := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())
+	if !ok {
+		if resp.StatusCode == http.StatusServiceUnavailable {
+			// A plain 503 is an outage, not a request to slow down
+			return false
+		}
+		delay = f.backoff.exponential(attempt)
+	}
+	delay = min(delay, maxThrottle)
+	f.throttled.Add(1)
+
+	until := time.Now().Add(delay).UnixNano()
+	for {
+		current := f.throttledUntil.Load()
+		if current >= until {
+			return true
+		}
+		if f.throttledUntil.CompareAndSwap(current, until) {
+			break
+		}
+	}
+	fmt.Printf("[Throttle] %s answered %d; pausing requests for %v\n", url, resp.StatusCode, delay.Round(time.Second))
+	return true
+}
+
+// waitThrottle blocks until the pause the server asked for is over.
+func (f *Fetcher) waitThrottle() {
+	if until := f.ThrottledUntil(); !until.IsZero() {
+		time.Sleep(time.Until(until))
+	}
+}
+
+// parseRetryAfter reads a Retry-After header, which is either a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(max(seconds, 0)) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(date.Sub(now), 0), true
+	}
+	return 0, false
+}
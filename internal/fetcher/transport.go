@@ -0,0 +1,55 @@
+package fetcher
+
+import (
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportOptions tune the connections the fetcher keeps to the sites it
+// crawls.
+type TransportOptions struct {
+	// MaxIdleConnsPerHost is how many idle connections are kept open per
+	// host for reuse; runs with more workers than this open and close
+	// connections to a single host all the time
+	MaxIdleConnsPerHost int
+	// IdleConnTimeout closes idle connections after this long
+	IdleConnTimeout time.Duration
+	// ForceAttemptHTTP2 negotiates HTTP/2 where the server offers it, so
+	// requests to a host share one connection
+	ForceAttemptHTTP2 bool
+	// TLSHandshakeTimeout bounds the TLS handshake (0 for no limit)
+	TLSHandshakeTimeout time.Duration
+	// KeepAlive is the interval of TCP keep-alive probes; negative
+	// disables them
+	KeepAlive time.Duration
+	// DisableCompression stops asking for gzip-compressed responses
+	DisableCompression bool
+}
+
+// DefaultTransportOptions are the settings used unless configured.
+func DefaultTransportOptions() TransportOptions {
+	return TransportOptions{
+		MaxIdleConnsPerHost: 10,
+		IdleConnTimeout:     90 * time.Second,
+		TLSHandshakeTimeout: 10 * time.Second,
+		KeepAlive:           30 * time.Second,
+	}
+}
+
+// newTransport builds the fetcher's transport from opts.
+func newTransport(opts TransportOptions) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: opts.KeepAlive,
+	}
+	return &http.Transport{
+		DialContext:         dialer.DialContext,
+		MaxIdleConns:        max(100, opts.MaxIdleConnsPerHost),
+		MaxIdleConnsPerHost: opts.MaxIdleConnsPerHost,
+		IdleConnTimeout:     opts.IdleConnTimeout,
+		ForceAttemptHTTP2:   opts.ForceAttemptHTTP2,
+		TLSHandshakeTimeout: opts.TLSHandshakeTimeout,
+		DisableCompression:  opts.DisableCompression,
+	}
+}
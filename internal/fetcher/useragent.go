@@ -0,0 +1,122 @@
+package fetcher
+
+import (
+	"bufio"
+	"fmt"
+	"hash/fnv"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// UserAgentProfiles are built-in User-Agent lists, usable by name wherever
+// User-Agents are listed.
+var UserAgentProfiles = map[string][]string{
+	"desktop": {
+		defaultUserAgent,
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64; rv:121.0) Gecko/20100101 Firefox/121.0",
+		"Mozilla/5.0 (Macintosh; Intel Mac OS X 10_15_7) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Safari/605.1.15",
+		"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36 Edg/120.0.0.0",
+	},
+	"mobile": {
+		"Mozilla/5.0 (iPhone; CPU iPhone OS 17_2 like Mac OS X) AppleWebKit/605.1.15 (KHTML, like Gecko) Version/17.2 Mobile/15E148 Safari/604.1",
+		"Mozilla/5.0 (Linux; Android 14; Pixel 8) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+		"Mozilla/5.0 (Linux; Android 13; SM-S918B) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Mobile Safari/537.36",
+	},
+	"bot": {
+		"gtft-crawler (bibliographic metadata crawler)",
+	},
+}
+
+// UserAgentProfileNames returns the names of the built-in User-Agent lists,
+// sorted.
+func UserAgentProfileNames() []string {
+	return slices.Sorted(maps.Keys(UserAgentProfiles))
+}
+
+// User-Agent rotation modes: a different User-Agent for every request, or
+// the same one for every request to a host.
+const (
+	RotatePerRequest = "request"
+	RotatePerHost    = "host"
+)
+
+// userAgents is a list of User-Agents requests take turns using.
+type userAgents struct {
+	agents  []string
+	perHost bool
+	next    atomic.Uint64
+}
+
+// pick returns the User-Agent for a request to host: the next in turn, or
+// one chosen by the host's hash so that host always sees the same one.
+func (u *userAgents) pick(host string) string {
+	if u.perHost {
+		hash := fnv.New32a()
+		hash.Write([]byte(host))
+		return u.agents[hash.Sum32()%uint32(len(u.agents))]
+	}
+	return u.agents[(u.next.Add(1)-1)%uint64(len(u.agents))]
+}
+
+// ExpandUserAgents returns the User-Agents in entries, with built-in profile
+// names replaced by their lists. Blank entries and # comments are skipped.
+func ExpandUserAgents(entries []string) []string {
+	var agents []string
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" || strings.HasPrefix(entry, "#") {
+			continue
+		}
+		if profile, ok := UserAgentProfiles[entry]; ok {
+			agents = append(agents, profile...)
+			continue
+		}
+		agents = append(agents, entry)
+	}
+	return agents
+}
+
+// LoadUserAgents reads a file of User-Agents or built-in profile names, one
+// per line.
+func LoadUserAgents(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open User-Agent list: %w", err)
+	}
+	defer file.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lines = append(lines, scanner.Text())
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read User-Agent list: %w", err)
+	}
+	return ExpandUserAgents(lines), nil
+}
+
+// SetUserAgents makes requests rotate through agents instead of sending a
+// single User-Agent, per request or per host depending on rotation.
+func (f *Fetcher) SetUserAgents(agents []string, rotation string) error {
+	if len(agents) == 0 {
+		return fmt.Errorf("no User-Agents given")
+	}
+	if rotation != RotatePerRequest && rotation != RotatePerHost {
+		return fmt.Errorf("unknown rotation %q (want %s or %s)", rotation, RotatePerRequest, RotatePerHost)
+	}
+	f.userAgents = &userAgents{agents: agents, perHost: rotation == RotatePerHost}
+	return nil
+}
+
+// userAgentFor returns the User-Agent for a request to host.
+func (f *Fetcher) userAgentFor(host string) string {
+	if f.userAgents == nil {
+		return f.userAgent
+	}
+	return f.userAgents.pick(host)
+}
@@ -0,0 +1,214 @@
+package index
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gtft-crawler/internal/parser"
+)
+
+// AuthorEntry is one (probable) person in the corpus.
+type AuthorEntry struct {
+	Name         string   `json:"name"`
+	Affiliations []string `json:"affiliations,omitempty"`
+	Papers       int      `json:"papers"`
+	Articles     []string `json:"articles"`
+}
+
+// AuthorIndex aggregates authors across the corpus.
+type AuthorIndex struct {
+	Records int            `json:"records"`
+	Authors []*AuthorEntry `json:"authors"`
+}
+
+var affiliationSeparators = regexp.MustCompile(`[,，;；、]`)
+
+type authorCluster struct {
+	entry    *AuthorEntry
+	segments map[string]bool
+	articles map[string]bool
+}
+
+// BuildAuthors groups author occurrences into people. Occurrences with the
+// same normalized name are merged when their affiliations overlap (share an
+// institution segment, or one contains the other). Occurrences without an
+// affiliation join the name's only group when it is unambiguous, otherwise
+// a separate unaffiliated group. Entries are ordered by paper count.
+func BuildAuthors(records []*parser.PaperMetadata) *AuthorIndex {
+	type occurrence struct {
+		author parser.Author
+		id     string
+	}
+
+	byName := make(map[string][]occurrence)
+	var names []string
+	for _, m := range records {
+		for _, author := range m.Authors {
+			key := normalizeName(author.Name)
+			if key == "" {
+				continue
+			}
+			if _, ok := byName[key]; !ok {
+				names = append(names, key)
+			}
+			byName[key] = append(byName[key], occurrence{author: author, id: m.ID})
+		}
+	}
+
+	idx := &AuthorIndex{Records: len(records)}
+
+	for _, key := range names {
+		var clusters []*authorCluster
+		var unaffiliated []occurrence
+
+		for _, occ := range byName[key] {
+			segments := affiliationSegments(occ.author.Affiliation)
+			if len(segments) == 0 {
+				unaffiliated = append(unaffiliated, occ)
+				continue
+			}
+
+			var target *authorCluster
+			for _, cluster := range clusters {
+				if overlaps(cluster.segments, segments) {
+					target = cluster
+					break
+				}
+			}
+			if target == nil {
+				target = newAuthorCluster(occ.author.Name)
+				clusters = append(clusters, target)
+			}
+			target.add(occ.id, occ.author.Affiliation, segments)
+		}
+
+		if len(unaffiliated) > 0 {
+			var target *authorCluster
+			if len(clusters) == 1 {
+				target = clusters[0]
+			} else {
+				target = newAuthorCluster(unaffiliated[0].author.Name)
+				clusters = append(clusters, target)
+			}
+			for _, occ := range unaffiliated {
+				target.add(occ.id, "", nil)
+			}
+		}
+
+		for _, cluster := range clusters {
+			sort.Strings(cluster.entry.Articles)
+			cluster.entry.Papers = len(cluster.entry.Articles)
+			idx.Authors = append(idx.Authors, cluster.entry)
+		}
+	}
+
+	sort.SliceStable(idx.Authors, func(i, j int) bool {
+		a, b := idx.Authors[i], idx.Authors[j]
+		if a.Papers != b.Papers {
+			return a.Papers > b.Papers
+		}
+		return a.Name < b.Name
+	})
+
+	return idx
+}
+
+func newAuthorCluster(name string) *authorCluster {
+	return &authorCluster{
+		entry:    &AuthorEntry{Name: strings.TrimSpace(name)},
+		segments: make(map[string]bool),
+		articles: make(map[string]bool),
+	}
+}
+
+func (c *authorCluster) add(id, affiliation string, segments []string) {
+	if !c.articles[id] {
+		c.articles[id] = true
+		c.entry.Articles = append(c.entry.Articles, id)
+	}
+
+	if affiliation = strings.TrimSpace(affiliation); affiliation != "" {
+		known := false
+		for _, existing := range c.entry.Affiliations {
+			if existing == affiliation {
+				known = true
+				break
+			}
+		}
+		if !known {
+			c.entry.Affiliations = append(c.entry.Affiliations, affiliation)
+		}
+	}
+
+	for _, segment := range segments {
+		c.segments[segment] = true
+	}
+}
+
+// normalizeName case-folds Latin names, collapses whitespace and drops
+// spaces between CJK characters ("宋 立秋" and "宋立秋" are the same name).
+func normalizeName(name string) string {
+	fields := strings.Fields(strings.ToLower(name))
+	if len(fields) == 0 {
+		return ""
+	}
+
+	var b strings.Builder
+	for i, field := range fields {
+		if i > 0 && !(endsWithHan(fields[i-1]) && startsWithHan(field)) {
+			b.WriteByte(' ')
+		}
+		b.WriteString(field)
+	}
+	return b.String()
+}
+
+func affiliationSegments(affiliation string) []string {
+	var segments []string
+	for _, part := range affiliationSeparators.Split(affiliation, -1) {
+		part = strings.ToLower(strings.Join(strings.Fields(part), " "))
+		// Skip postcodes, cities and other fragments too short to identify an institution
+		if len([]rune(part)) < 4 || isDigits(part) {
+			continue
+		}
+		segments = append(segments, part)
+	}
+	return segments
+}
+
+func overlaps(known map[string]bool, segments []string) bool {
+	for _, segment := range segments {
+		if known[segment] {
+			return true
+		}
+		for existing := range known {
+			if strings.Contains(existing, segment) || strings.Contains(segment, existing) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func startsWithHan(s string) bool {
+	for _, r := range s {
+		return unicode.Is(unicode.Han, r)
+	}
+	return false
+}
+
+func endsWithHan(s string) bool {
+	runes := []rune(s)
+	return len(runes) > 0 && unicode.Is(unicode.Han, runes[len(runes)-1])
+}
+
+func isDigits(s string) bool {
+	for _, r := range s {
+		if !unicode.IsDigit(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,170 @@
+package index
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+
+	"gtft-crawler/internal/parser"
+)
+
+// minTitleKey is the shortest normalized title matched on, so generic
+// titles such as "前言" or "Editorial" don't link unrelated references.
+const minTitleKey = 6
+
+// CitationStats summarizes a LinkCitations pass.
+type CitationStats struct {
+	Records    int `json:"records"`
+	References int `json:"references"`
+	// ByDOI and ByTitle count references resolved to a corpus article
+	ByDOI   int `json:"by_doi"`
+	ByTitle int `json:"by_title"`
+	// Ambiguous counts references matching more than one article
+	Ambiguous int `json:"ambiguous"`
+}
+
+// Resolved is the number of references linked to a corpus article.
+func (s CitationStats) Resolved() int {
+	return s.ByDOI + s.ByTitle
+}
+
+type titleCandidate struct {
+	id   string
+	year string
+}
+
+// citationIndex finds corpus articles by DOI and by title.
+type citationIndex struct {
+	byDOI   map[string][]string
+	byTitle map[string][]titleCandidate
+}
+
+func newCitationIndex(records []*parser.PaperMetadata) *citationIndex {
+	idx := &citationIndex{
+		byDOI:   make(map[string][]string),
+		byTitle: make(map[string][]titleCandidate),
+	}
+	for _, m := range records {
+		if doi := parser.NormalizeDOI(m.DOI); doi != "" && !slices.Contains(idx.byDOI[doi], m.ID) {
+			idx.byDOI[doi] = append(idx.byDOI[doi], m.ID)
+		}
+		for _, title := range []string{m.TitleCN, m.TitleEN} {
+			key := titleKey(title)
+			if key == "" {
+				continue
+			}
+			candidate := titleCandidate{id: m.ID, year: m.Year}
+			if !slices.Contains(idx.byTitle[key], candidate) {
+				idx.byTitle[key] = append(idx.byTitle[key], candidate)
+			}
+		}
+	}
+	return idx
+}
+
+// resolve returns the article a reference cites. A DOI decides on its own;
+// otherwise the title must match, and the year too when the reference has
+// one. ambiguous is set when more than one article matches.
+func (idx *citationIndex) resolve(ref parser.Reference) (id string, byDOI, ambiguous bool) {
+	if doi := parser.NormalizeDOI(ref.DOI); doi != "" {
+		switch ids := idx.byDOI[doi]; len(ids) {
+		case 0:
+		case 1:
+			return ids[0], true, false
+		default:
+			return "", false, true
+		}
+	}
+
+	var ids []string
+	for _, candidate := range idx.byTitle[titleKey(ref.Title)] {
+		if ref.Year != "" && candidate.year != "" && candidate.year != ref.Year {
+			continue
+		}
+		if !slices.Contains(ids, candidate.id) {
+			ids = append(ids, candidate.id)
+		}
+	}
+	switch len(ids) {
+	case 0:
+		return "", false, false
+	case 1:
+		return ids[0], false, false
+	default:
+		return "", false, true
+	}
+}
+
+// LinkCitations resolves every record's references against the records
+// themselves, setting Reference.ArticleID for references to corpus articles
+// and each cited record's CitedBy. Links from earlier passes are replaced,
+// so the pass can be rerun as the corpus grows. Records sharing an ID (as
+// in a directory holding both layouts) are all updated alike.
+func LinkCitations(records []*parser.PaperMetadata) CitationStats {
+	idx := newCitationIndex(records)
+	stats := CitationStats{}
+
+	citedBy := make(map[string][]string)
+	counted := make(map[string]bool)
+	for _, m := range records {
+		// Duplicates are linked but only counted once
+		first := !counted[m.ID]
+		counted[m.ID] = true
+		if first {
+			stats.Records++
+		}
+
+		for i := range m.References {
+			ref := &m.References[i]
+			id, byDOI, ambiguous := idx.resolve(*ref)
+			// Self-citations are dropped: they are usually parse errors
+			if id == m.ID {
+				id = ""
+			}
+			ref.ArticleID = id
+
+			if !first {
+				continue
+			}
+			stats.References++
+			switch {
+			case ambiguous:
+				stats.Ambiguous++
+			case id != "" && byDOI:
+				stats.ByDOI++
+			case id != "":
+				stats.ByTitle++
+			}
+			if id != "" && !slices.Contains(citedBy[id], m.ID) {
+				citedBy[id] = append(citedBy[id], m.ID)
+			}
+		}
+	}
+
+	for _, m := range records {
+		m.CitedBy = slices.Sorted(slices.Values(citedBy[m.ID]))
+	}
+	return stats
+}
+
+// titleKey reduces a title to its lowercased letters and digits, so
+// punctuation, spacing and full-width forms don't prevent a match. Titles
+// too short to identify an article give "".
+func titleKey(title string) string {
+	var b strings.Builder
+	count := 0
+	for _, r := range strings.ToLower(title) {
+		// Full-width Latin letters and digits fold to their ASCII forms
+		if r >= '！' && r <= '～' {
+			r -= '！' - '!'
+		}
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToLower(r))
+			count++
+		}
+	}
+	if count < minTitleKey {
+		return ""
+	}
+	return b.String()
+}
@@ -0,0 +1,153 @@
+package index
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"gtft-crawler/internal/parser"
+)
+
+// coverageFields are the fields the coverage report checks, in report order.
+var coverageFields = []struct {
+	name    string
+	missing func(*parser.PaperMetadata) bool
+}{
+	{"title_cn", func(m *parser.PaperMetadata) bool { return m.TitleCN == "" }},
+	{"title_en", func(m *parser.PaperMetadata) bool { return m.TitleEN == "" }},
+	{"authors", func(m *parser.PaperMetadata) bool { return len(m.Authors) == 0 }},
+	{"affiliations", func(m *parser.PaperMetadata) bool {
+		for _, author := range m.Authors {
+			if author.Affiliation != "" {
+				return false
+			}
+		}
+		return true
+	}},
+	{"abstract_cn", func(m *parser.PaperMetadata) bool { return m.AbstractCN == "" }},
+	{"abstract_en", func(m *parser.PaperMetadata) bool { return m.AbstractEN == "" }},
+	{"keywords_cn", func(m *parser.PaperMetadata) bool { return len(m.KeywordsCN) == 0 }},
+	{"keywords_en", func(m *parser.PaperMetadata) bool { return len(m.KeywordsEN) == 0 }},
+	{"doi", func(m *parser.PaperMetadata) bool { return m.DOI == "" }},
+	{"volume", func(m *parser.PaperMetadata) bool { return m.Volume == "" }},
+	{"issue", func(m *parser.PaperMetadata) bool { return m.Issue == "" }},
+	{"pages", func(m *parser.PaperMetadata) bool { return m.Pages == "" }},
+	{"date", func(m *parser.PaperMetadata) bool { return m.Date == "" }},
+	{"submit_date", func(m *parser.PaperMetadata) bool { return m.SubmitDate == "" }},
+	{"online_date", func(m *parser.PaperMetadata) bool { return m.OnlineDate == "" }},
+	{"pdf_url", func(m *parser.PaperMetadata) bool { return m.PDFURL == "" }},
+	{"fund_project", func(m *parser.PaperMetadata) bool { return m.FundProject == "" }},
+	{"clc_code", func(m *parser.PaperMetadata) bool { return m.CLCCode == "" }},
+}
+
+// unknownYear groups records without a publication year.
+const unknownYear = "unknown"
+
+// CoverageRow counts the records of one publication year (or all records)
+// lacking each field.
+type CoverageRow struct {
+	Year    string         `json:"year,omitempty"`
+	Records int            `json:"records"`
+	Missing map[string]int `json:"missing"`
+	// MissingPercent is Missing as a percentage of Records
+	MissingPercent map[string]float64 `json:"missing_percent"`
+}
+
+// Coverage reports which fields records lack, overall and by publication
+// year. Records are added one at a time, so a crawl can build it as it goes.
+// It is not safe for concurrent use.
+type Coverage struct {
+	Fields []string       `json:"fields"`
+	Total  *CoverageRow   `json:"total"`
+	Years  []*CoverageRow `json:"years"`
+
+	byYear map[string]*CoverageRow
+}
+
+func NewCoverage() *Coverage {
+	fields := make([]string, len(coverageFields))
+	for i, f := range coverageFields {
+		fields[i] = f.name
+	}
+	return &Coverage{
+		Fields: fields,
+		Total:  newCoverageRow(""),
+		Years:  []*CoverageRow{},
+		byYear: make(map[string]*CoverageRow),
+	}
+}
+
+// BuildCoverage reports field coverage over records.
+func BuildCoverage(records []*parser.PaperMetadata) *Coverage {
+	c := NewCoverage()
+	for _, m := range records {
+		c.Add(m)
+	}
+	return c
+}
+
+func newCoverageRow(year string) *CoverageRow {
+	return &CoverageRow{
+		Year:           year,
+		Missing:        make(map[string]int, len(coverageFields)),
+		MissingPercent: make(map[string]float64, len(coverageFields)),
+	}
+}
+
+// Add counts the fields missing from one record.
+func (c *Coverage) Add(m *parser.PaperMetadata) {
+	year := m.Year
+	if year == "" {
+		year = unknownYear
+	}
+	row, ok := c.byYear[year]
+	if !ok {
+		row = newCoverageRow(year)
+		c.byYear[year] = row
+		c.Years = append(c.Years, row)
+		// Years ascending, unknown last
+		sort.Slice(c.Years, func(i, j int) bool {
+			a, b := c.Years[i].Year, c.Years[j].Year
+			if (a == unknownYear) != (b == unknownYear) {
+				return b == unknownYear
+			}
+			return a < b
+		})
+	}
+
+	for _, r := range []*CoverageRow{c.Total, row} {
+		r.Records++
+		for _, f := range coverageFields {
+			if f.missing(m) {
+				r.Missing[f.name]++
+			} else if _, ok := r.Missing[f.name]; !ok {
+				r.Missing[f.name] = 0
+			}
+			r.MissingPercent[f.name] = math.Round(float64(r.Missing[f.name])*1000/float64(r.Records)) / 10
+		}
+	}
+}
+
+// WriteText writes the report as a table of missing-field percentages, one
+// row per publication year followed by the total.
+func (c *Coverage) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprintf(tw, "year\trecords\t%s\t\n", strings.Join(c.Fields, "\t"))
+	for _, row := range append(c.Years, c.Total) {
+		year := row.Year
+		if row == c.Total {
+			year = "total"
+		}
+		cells := make([]string, len(c.Fields))
+		for i, field := range c.Fields {
+			cells[i] = fmt.Sprintf("%.1f", row.MissingPercent[field])
+		}
+		fmt.Fprintf(tw, "%s\t%d\t%s\t\n", year, row.Records, strings.Join(cells, "\t"))
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,123 @@
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"maps"
+	"slices"
+
+	"gtft-crawler/internal/corpus"
+	"gtft-crawler/internal/parser"
+)
+
+// FieldChange is one field that differs between two versions of a record.
+// Old or New is left out when the field is absent from that version.
+type FieldChange struct {
+	Field string          `json:"field"`
+	Old   json.RawMessage `json:"old,omitempty"`
+	New   json.RawMessage `json:"new,omitempty"`
+}
+
+// RecordChange is a record present in both crawls whose content differs.
+type RecordChange struct {
+	ID string `json:"id"`
+	// Fields lists what changed, when the records themselves were compared
+	// rather than their checksums
+	Fields []FieldChange `json:"fields,omitempty"`
+}
+
+// Changeset is the difference between two crawls' records, by corpus.Key:
+// the ID, prefixed with the journal for records of multi-journal runs.
+type Changeset struct {
+	Added     []string       `json:"added"`
+	Removed   []string       `json:"removed"`
+	Changed   []RecordChange `json:"changed"`
+	Unchanged int            `json:"unchanged"`
+}
+
+// DiffRecords compares two sets of records by corpus.Key, ignoring the
+// named fields (such as "parsed_at", which changes on every crawl). Each set
+// must hold one record per key.
+func DiffRecords(old, new []*parser.PaperMetadata, ignore []string) (*Changeset, error) {
+	oldFields, err := fieldMaps(old)
+	if err != nil {
+		return nil, err
+	}
+	newFields, err := fieldMaps(new)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := &Changeset{}
+	for _, id := range slices.Sorted(maps.Keys(newFields)) {
+		before, ok := oldFields[id]
+		if !ok {
+			changes.Added = append(changes.Added, id)
+			continue
+		}
+		after := newFields[id]
+
+		var fields []FieldChange
+		names := slices.Sorted(maps.Keys(before))
+		for name := range after {
+			if _, ok := before[name]; !ok {
+				names = append(names, name)
+			}
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			if slices.Contains(ignore, name) || bytes.Equal(before[name], after[name]) {
+				continue
+			}
+			fields = append(fields, FieldChange{Field: name, Old: before[name], New: after[name]})
+		}
+		if len(fields) == 0 {
+			changes.Unchanged++
+			continue
+		}
+		changes.Changed = append(changes.Changed, RecordChange{ID: id, Fields: fields})
+	}
+	for _, id := range slices.Sorted(maps.Keys(oldFields)) {
+		if _, ok := newFields[id]; !ok {
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+	return changes, nil
+}
+
+// DiffChecksums compares two sets of record checksums, keyed as DiffRecords
+// keys records, as read from manifests: it tells which records changed, but
+// not how.
+func DiffChecksums(old, new map[string]string) *Changeset {
+	changes := &Changeset{}
+	for _, id := range slices.Sorted(maps.Keys(new)) {
+		sum, ok := old[id]
+		switch {
+		case !ok:
+			changes.Added = append(changes.Added, id)
+		case sum != new[id]:
+			changes.Changed = append(changes.Changed, RecordChange{ID: id})
+		default:
+			changes.Unchanged++
+		}
+	}
+	for _, id := range slices.Sorted(maps.Keys(old)) {
+		if _, ok := new[id]; !ok {
+			changes.Removed = append(changes.Removed, id)
+		}
+	}
+	return changes
+}
+
+// fieldMaps returns each record's JSON fields, keyed by corpus.Key.
+func fieldMaps(records []*parser.PaperMetadata) (map[string]map[string]json.RawMessage, error) {
+	fields := make(map[string]map[string]json.RawMessage, len(records))
+	for _, m := range records {
+		f, err := fieldMap(m)
+		if err != nil {
+			return nil, err
+		}
+		fields[corpus.Key(m)] = f
+	}
+	return fields, nil
+}
@@ -0,0 +1,202 @@
+package index
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// How the records of a DuplicateGroup were matched.
+const (
+	MatchID    = "id"
+	MatchDOI   = "doi"
+	MatchTitle = "title_year_pages"
+)
+
+// DuplicateGroup is a set of records describing the same article.
+type DuplicateGroup struct {
+	// Records are indexes into the records given to FindDuplicates, the
+	// record to keep first
+	Records []int
+	// Match lists what members share with another: MatchID, MatchDOI or
+	// MatchTitle
+	Match []string
+}
+
+// FindDuplicates groups records describing the same article under
+// different IDs or files: those sharing an ID (as in a directory holding
+// both layouts), a DOI, or a title together with year and pages, which
+// catches articles crawled under two URL forms before canonical IDs. Each
+// group's first record is the one to keep: the most complete, preferring
+// live records and then the most recently parsed.
+func FindDuplicates(records []*parser.PaperMetadata) []DuplicateGroup {
+	parent := make([]int, len(records))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+
+	matched := make(map[int][]string)
+	first := make(map[string]int)
+	join := func(i int, match, key string) {
+		key = match + "\x00" + key
+		j, ok := first[key]
+		if !ok {
+			first[key] = i
+			return
+		}
+		parent[find(i)] = find(j)
+		for _, k := range []int{i, j} {
+			if !slices.Contains(matched[k], match) {
+				matched[k] = append(matched[k], match)
+			}
+		}
+	}
+
+	for i, m := range records {
+		join(i, MatchID, m.ID)
+		if doi := parser.NormalizeDOI(m.DOI); doi != "" {
+			join(i, MatchDOI, doi)
+		}
+		if m.Year == "" || m.Pages == "" {
+			continue
+		}
+		for _, title := range []string{m.TitleCN, m.TitleEN} {
+			if key := titleKey(title); key != "" {
+				join(i, MatchTitle, key+"\x00"+m.Year+"\x00"+m.Pages)
+			}
+		}
+	}
+
+	members := make(map[int][]int)
+	var roots []int
+	for i := range records {
+		root := find(i)
+		if _, ok := members[root]; !ok {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	var groups []DuplicateGroup
+	for _, root := range roots {
+		group := members[root]
+		if len(group) < 2 {
+			continue
+		}
+		slices.SortStableFunc(group, func(a, b int) int {
+			return compareKeep(records[a], records[b])
+		})
+		var match []string
+		for _, i := range group {
+			for _, m := range matched[i] {
+				if !slices.Contains(match, m) {
+					match = append(match, m)
+				}
+			}
+		}
+		slices.Sort(match)
+		groups = append(groups, DuplicateGroup{Records: group, Match: match})
+	}
+	return groups
+}
+
+// compareKeep orders the record to keep first: the more complete, then the
+// one not tombstoned, then the more recently parsed.
+func compareKeep(a, b *parser.PaperMetadata) int {
+	if a.Completeness != b.Completeness {
+		return b.Completeness - a.Completeness
+	}
+	if (a.Removed == nil) != (b.Removed == nil) {
+		if a.Removed == nil {
+			return -1
+		}
+		return 1
+	}
+	return strings.Compare(b.ParsedAt, a.ParsedAt)
+}
+
+// unmergedFields identify a record or describe its parse; MergeDuplicate
+// never copies them.
+var unmergedFields = []string{
+	"id", "url", "canonical_url", "final_url", "redirect_chain",
+	"warnings", "completeness", "parsed_at", "retracted_or_removed",
+}
+
+// MergeDuplicate fills the fields keep lacks from dup, a duplicate of it,
+// and drops keep's "missing" warnings for the fields it gains. The fields
+// keep has are left alone.
+func MergeDuplicate(keep, dup *parser.PaperMetadata) error {
+	target, err := fieldMap(keep)
+	if err != nil {
+		return err
+	}
+	source, err := fieldMap(dup)
+	if err != nil {
+		return err
+	}
+
+	changed := false
+	for name, value := range source {
+		if slices.Contains(unmergedFields, name) || !emptyField(target[name]) || emptyField(value) {
+			continue
+		}
+		target[name] = value
+		changed = true
+	}
+	if !changed {
+		return nil
+	}
+
+	data, err := json.Marshal(target)
+	if err != nil {
+		return fmt.Errorf("failed to merge %s into %s: %w", dup.ID, keep.ID, err)
+	}
+	merged := parser.PaperMetadata{}
+	if err := json.Unmarshal(data, &merged); err != nil {
+		return fmt.Errorf("failed to merge %s into %s: %w", dup.ID, keep.ID, err)
+	}
+	*keep = merged
+
+	keep.Warnings = slices.DeleteFunc(keep.Warnings, func(warning string) bool {
+		field, ok := strings.CutPrefix(warning, "missing ")
+		return ok && parser.IsField(field) && len(keep.Missing([]string{field})) == 0
+	})
+	if len(keep.Warnings) == 0 {
+		keep.Warnings = nil
+	}
+	keep.Completeness = parser.Completeness(keep)
+	return nil
+}
+
+// fieldMap returns a record's JSON fields by name.
+func fieldMap(m *parser.PaperMetadata) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s: %w", m.ID, err)
+	}
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("failed to decode %s: %w", m.ID, err)
+	}
+	return fields, nil
+}
+
+// emptyField reports whether a JSON value is absent or empty.
+func emptyField(value json.RawMessage) bool {
+	switch string(bytes.TrimSpace(value)) {
+	case "", "null", `""`, "0", "[]", "{}", "false":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,256 @@
+package index
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"slices"
+	"sort"
+	"strconv"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Distribution summarizes the citation counts of a set of articles.
+type Distribution struct {
+	Total  int     `json:"total"`
+	Mean   float64 `json:"mean"`
+	Median float64 `json:"median"`
+	Max    int     `json:"max"`
+	// Uncited is the number of articles cited nowhere
+	Uncited int `json:"uncited"`
+}
+
+// YearImpact is the citation record of the articles published in one year.
+// Internal counts are citations by corpus articles, from cited_by; Site
+// counts are the citation counts the journal's pages report.
+type YearImpact struct {
+	Year     string       `json:"year"`
+	Articles int          `json:"articles"`
+	Internal Distribution `json:"internal"`
+	Site     Distribution `json:"site"`
+}
+
+// AuthorImpact is an author's h-index within the journal: the largest h such
+// that h of their articles in the corpus were cited at least h times.
+type AuthorImpact struct {
+	Name        string `json:"name"`
+	Affiliation string `json:"affiliation,omitempty"`
+	Papers      int    `json:"papers"`
+	Citations   int    `json:"citations"`
+	HIndex      int    `json:"h_index"`
+	// SiteCitations and SiteHIndex count the citations the pages report
+	SiteCitations int `json:"site_citations"`
+	SiteHIndex    int `json:"site_h_index"`
+}
+
+// SelfCitations measures how inward-looking the journal's citations are.
+type SelfCitations struct {
+	// References is the number of references in the corpus, and Journal
+	// those resolved to a corpus article: the journal citing itself
+	References  int     `json:"references"`
+	Journal     int     `json:"journal"`
+	JournalRate float64 `json:"journal_rate"`
+	// Author counts the journal's citations whose citing and cited
+	// articles share an author, as grouped by BuildAuthors
+	Author     int     `json:"author"`
+	AuthorRate float64 `json:"author_rate"`
+}
+
+// Impact holds simple bibliometrics of the corpus. Citations within it come
+// from the link command's links, so it should run first.
+type Impact struct {
+	Records       int             `json:"records"`
+	Years         []*YearImpact   `json:"years"`
+	Authors       []*AuthorImpact `json:"authors"`
+	SelfCitations SelfCitations   `json:"self_citations"`
+}
+
+// BuildImpact computes the bibliometrics of records. Years are ascending,
+// with undated records last as "unknown", and authors are ordered by
+// h-index, then citations, then papers.
+func BuildImpact(records []*parser.PaperMetadata) *Impact {
+	impact := &Impact{Records: len(records), Years: []*YearImpact{}, Authors: []*AuthorImpact{}}
+
+	cited := make(map[string]int, len(records))
+	for _, m := range records {
+		cited[m.ID] = len(m.CitedBy)
+	}
+
+	byYear := make(map[string][]*parser.PaperMetadata)
+	for _, m := range records {
+		year := m.Year
+		if year == "" {
+			year = unknownYear
+		}
+		byYear[year] = append(byYear[year], m)
+	}
+	for year, articles := range byYear {
+		internal := make([]int, len(articles))
+		site := make([]int, len(articles))
+		for i, m := range articles {
+			internal[i], site[i] = cited[m.ID], m.Citations
+		}
+		impact.Years = append(impact.Years, &YearImpact{
+			Year:     year,
+			Articles: len(articles),
+			Internal: distribution(internal),
+			Site:     distribution(site),
+		})
+	}
+	sort.Slice(impact.Years, func(i, j int) bool {
+		a, b := impact.Years[i].Year, impact.Years[j].Year
+		if (a == unknownYear) != (b == unknownYear) {
+			return b == unknownYear
+		}
+		return a < b
+	})
+
+	siteCitations := make(map[string]int, len(records))
+	for _, m := range records {
+		siteCitations[m.ID] = m.Citations
+	}
+	// authorsOf maps each article to the people who wrote it, for telling
+	// self-citations
+	authorsOf := make(map[string][]int)
+	for n, author := range BuildAuthors(records).Authors {
+		entry := &AuthorImpact{Name: author.Name, Papers: author.Papers}
+		if len(author.Affiliations) > 0 {
+			entry.Affiliation = author.Affiliations[0]
+		}
+		internal := make([]int, len(author.Articles))
+		site := make([]int, len(author.Articles))
+		for i, id := range author.Articles {
+			internal[i], site[i] = cited[id], siteCitations[id]
+			entry.Citations += internal[i]
+			entry.SiteCitations += site[i]
+			authorsOf[id] = append(authorsOf[id], n)
+		}
+		entry.HIndex, entry.SiteHIndex = hIndex(internal), hIndex(site)
+		impact.Authors = append(impact.Authors, entry)
+	}
+	sort.SliceStable(impact.Authors, func(i, j int) bool {
+		a, b := impact.Authors[i], impact.Authors[j]
+		if a.HIndex != b.HIndex {
+			return a.HIndex > b.HIndex
+		}
+		if a.Citations != b.Citations {
+			return a.Citations > b.Citations
+		}
+		return a.Papers > b.Papers
+	})
+
+	self := &impact.SelfCitations
+	for _, m := range records {
+		for _, ref := range m.References {
+			self.References++
+			if ref.ArticleID == "" {
+				continue
+			}
+			self.Journal++
+			if slices.ContainsFunc(authorsOf[m.ID], func(n int) bool { return slices.Contains(authorsOf[ref.ArticleID], n) }) {
+				self.Author++
+			}
+		}
+	}
+	self.JournalRate = rate(self.Journal, self.References)
+	self.AuthorRate = rate(self.Author, self.Journal)
+
+	return impact
+}
+
+func distribution(counts []int) Distribution {
+	var d Distribution
+	if len(counts) == 0 {
+		return d
+	}
+	sorted := slices.Sorted(slices.Values(counts))
+	for _, n := range sorted {
+		d.Total += n
+		if n == 0 {
+			d.Uncited++
+		}
+	}
+	d.Max = sorted[len(sorted)-1]
+	d.Mean = math.Round(float64(d.Total)*100/float64(len(sorted))) / 100
+	if mid := len(sorted) / 2; len(sorted)%2 == 1 {
+		d.Median = float64(sorted[mid])
+	} else {
+		d.Median = float64(sorted[mid-1]+sorted[mid]) / 2
+	}
+	return d
+}
+
+func hIndex(counts []int) int {
+	sorted := slices.Sorted(slices.Values(counts))
+	slices.Reverse(sorted)
+	h := 0
+	for i, n := range sorted {
+		if n < i+1 {
+			break
+		}
+		h = i + 1
+	}
+	return h
+}
+
+// rate is part as a fraction of whole, to three places.
+func rate(part, whole int) float64 {
+	if whole == 0 {
+		return 0
+	}
+	return math.Round(float64(part)*1000/float64(whole)) / 1000
+}
+
+// WriteYearsCSV writes the per-year citation distributions, one row per
+// year.
+func (i *Impact) WriteYearsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{
+		"year", "articles",
+		"citations", "mean", "median", "max", "uncited",
+		"site_citations", "site_mean", "site_median", "site_max", "site_uncited",
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, y := range i.Years {
+		row := []string{y.Year, strconv.Itoa(y.Articles)}
+		for _, d := range []Distribution{y.Internal, y.Site} {
+			row = append(row,
+				strconv.Itoa(d.Total),
+				strconv.FormatFloat(d.Mean, 'f', -1, 64),
+				strconv.FormatFloat(d.Median, 'f', -1, 64),
+				strconv.Itoa(d.Max),
+				strconv.Itoa(d.Uncited),
+			)
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row for %s: %w", y.Year, err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// WriteAuthorsCSV writes the authors' h-indexes, one row per author.
+func (i *Impact) WriteAuthorsCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"name", "affiliation", "papers", "citations", "h_index", "site_citations", "site_h_index"}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+	for _, a := range i.Authors {
+		row := []string{
+			a.Name, a.Affiliation, strconv.Itoa(a.Papers),
+			strconv.Itoa(a.Citations), strconv.Itoa(a.HIndex),
+			strconv.Itoa(a.SiteCitations), strconv.Itoa(a.SiteHIndex),
+		}
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row for %s: %w", a.Name, err)
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
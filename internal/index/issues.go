@@ -0,0 +1,134 @@
+package index
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// IssueEntry summarizes one issue of a journal from its crawled articles.
+type IssueEntry struct {
+	Journal string `json:"journal"`
+	Volume  string `json:"volume"`
+	Issue   string `json:"issue"`
+	// Year is the year most of the issue's articles give
+	Year     string `json:"year,omitempty"`
+	Articles int    `json:"article_count"`
+	// FirstPage and LastPage span the pages of the crawled articles, which
+	// is the whole issue only when all of them were crawled
+	FirstPage string `json:"first_page,omitempty"`
+	LastPage  string `json:"last_page,omitempty"`
+	// ArticleIDs are in page order, articles without pages last
+	ArticleIDs []string `json:"articles"`
+}
+
+// IssueIndex lists the issues of the journals in the corpus.
+type IssueIndex struct {
+	Records int `json:"records"`
+	// Unplaced counts records without a volume or issue
+	Unplaced int           `json:"unplaced"`
+	Issues   []*IssueEntry `json:"issues"`
+}
+
+// BuildIssues groups records by journal, volume and issue. Issues are
+// ordered by journal, year, volume and issue number.
+func BuildIssues(records []*parser.PaperMetadata) *IssueIndex {
+	idx := &IssueIndex{Records: len(records)}
+
+	type issueKey struct{ journal, volume, issue string }
+	members := make(map[issueKey][]*parser.PaperMetadata)
+	var keys []issueKey
+	for _, m := range records {
+		volume, issue := strings.TrimSpace(m.Volume), strings.TrimSpace(m.Issue)
+		if volume == "" || issue == "" {
+			idx.Unplaced++
+			continue
+		}
+		journal := m.JournalCN
+		if journal == "" {
+			journal = m.JournalEN
+		}
+		key := issueKey{journal, volume, issue}
+		if _, ok := members[key]; !ok {
+			keys = append(keys, key)
+		}
+		members[key] = append(members[key], m)
+	}
+
+	for _, key := range keys {
+		articles := members[key]
+		slices.SortStableFunc(articles, func(a, b *parser.PaperMetadata) int {
+			return comparePages(a.FirstPage, b.FirstPage)
+		})
+
+		entry := &IssueEntry{
+			Journal:  key.journal,
+			Volume:   key.volume,
+			Issue:    key.issue,
+			Year:     commonYear(articles),
+			Articles: len(articles),
+		}
+		for _, m := range articles {
+			entry.ArticleIDs = append(entry.ArticleIDs, m.ID)
+			if m.FirstPage != "" && entry.FirstPage == "" {
+				entry.FirstPage = m.FirstPage
+			}
+			last := cmp.Or(m.LastPage, m.FirstPage)
+			if last != "" && (entry.LastPage == "" || parser.PageNumber(last) > parser.PageNumber(entry.LastPage)) {
+				entry.LastPage = last
+			}
+		}
+		idx.Issues = append(idx.Issues, entry)
+	}
+
+	slices.SortFunc(idx.Issues, func(a, b *IssueEntry) int {
+		return cmp.Or(
+			cmp.Compare(a.Journal, b.Journal),
+			cmp.Compare(a.Year, b.Year),
+			compareNumbers(a.Volume, b.Volume),
+			compareNumbers(a.Issue, b.Issue),
+		)
+	})
+	return idx
+}
+
+// commonYear returns the year most articles give, the earliest on a tie.
+func commonYear(articles []*parser.PaperMetadata) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, m := range articles {
+		if m.Year == "" {
+			continue
+		}
+		counts[m.Year]++
+		if n := counts[m.Year]; best == "" || n > counts[best] || (n == counts[best] && m.Year < best) {
+			best = m.Year
+		}
+	}
+	return best
+}
+
+// comparePages orders pages by number, ignoring prefixes, with missing pages
+// last.
+func comparePages(a, b string) int {
+	switch {
+	case a == "" || b == "":
+		return cmp.Compare(b, a)
+	default:
+		return cmp.Compare(parser.PageNumber(a), parser.PageNumber(b))
+	}
+}
+
+// compareNumbers orders volume or issue numbers numerically, falling back to
+// text for values that aren't numbers, such as "S1".
+func compareNumbers(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	if errA != nil || errB != nil {
+		return cmp.Compare(a, b)
+	}
+	return cmp.Compare(x, y)
+}
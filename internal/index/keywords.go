@@ -0,0 +1,88 @@
+package index
+
+import (
+	"sort"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// KeywordEntry lists the articles tagged with one keyword.
+type KeywordEntry struct {
+	Keyword  string         `json:"keyword"`
+	Language string         `json:"language"`
+	Count    int            `json:"count"`
+	ByYear   map[string]int `json:"by_year"`
+	Articles []string       `json:"articles"`
+}
+
+// KeywordIndex is an inverted keyword → article ID index.
+type KeywordIndex struct {
+	Records  int             `json:"records"`
+	Keywords []*KeywordEntry `json:"keywords"`
+}
+
+// BuildKeywords indexes the Chinese and English keywords of all records.
+// English keywords are case-folded so "Hot rolling" and "hot rolling" merge.
+// Entries are ordered by descending article count, then keyword.
+func BuildKeywords(records []*parser.PaperMetadata) *KeywordIndex {
+	entries := make(map[string]*KeywordEntry)
+
+	add := func(keyword, language string, m *parser.PaperMetadata) {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" {
+			return
+		}
+		if language == "en" {
+			keyword = strings.ToLower(keyword)
+		}
+
+		key := language + "\x00" + keyword
+		entry, ok := entries[key]
+		if !ok {
+			entry = &KeywordEntry{Keyword: keyword, Language: language, ByYear: make(map[string]int)}
+			entries[key] = entry
+		}
+
+		// A keyword listed twice on one article still counts once
+		if n := len(entry.Articles); n > 0 && entry.Articles[n-1] == m.ID {
+			return
+		}
+
+		entry.Articles = append(entry.Articles, m.ID)
+		entry.Count++
+		year := m.Year
+		if year == "" {
+			year = "unknown"
+		}
+		entry.ByYear[year]++
+	}
+
+	for _, m := range records {
+		for _, keyword := range m.KeywordsCN {
+			add(keyword, "zh", m)
+		}
+		for _, keyword := range m.KeywordsEN {
+			add(keyword, "en", m)
+		}
+	}
+
+	idx := &KeywordIndex{Records: len(records)}
+	for _, entry := range entries {
+		sort.Strings(entry.Articles)
+		idx.Keywords = append(idx.Keywords, entry)
+	}
+
+	sort.Slice(idx.Keywords, func(i, j int) bool {
+		a, b := idx.Keywords[i], idx.Keywords[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Language != b.Language {
+			return a.Language < b.Language
+		}
+		return a.Keyword < b.Keyword
+	})
+
+	return idx
+}
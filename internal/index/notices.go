@@ -0,0 +1,59 @@
+package index
+
+import (
+	"slices"
+
+	"gtft-crawler/internal/parser"
+)
+
+// NoticeStats summarizes a LinkNotices pass.
+type NoticeStats struct {
+	Retractions int `json:"retractions"`
+	Corrections int `json:"corrections"`
+	// Linked counts notices whose article is in the corpus
+	Linked int `json:"linked"`
+}
+
+// LinkNotices links retraction and correction notices to the articles they
+// concern. A notice without a CorrectsID from the page gets one resolved
+// from the DOI or title it names, as references are; each article's
+// Notices is rebuilt from the notices pointing at it.
+func LinkNotices(records []*parser.PaperMetadata) NoticeStats {
+	idx := newCitationIndex(records)
+	stats := NoticeStats{}
+
+	notices := make(map[string][]string)
+	counted := make(map[string]bool)
+	for _, m := range records {
+		if !m.IsRetraction && !m.IsCorrection {
+			continue
+		}
+		if m.CorrectsID == "" {
+			id, _, _ := idx.resolve(parser.Reference{DOI: m.CorrectsDOI, Title: m.CorrectsTitle})
+			if id != m.ID {
+				m.CorrectsID = id
+			}
+		}
+
+		if counted[m.ID] {
+			continue
+		}
+		counted[m.ID] = true
+		if m.IsRetraction {
+			stats.Retractions++
+		} else {
+			stats.Corrections++
+		}
+		if m.CorrectsID != "" {
+			stats.Linked++
+			if !slices.Contains(notices[m.CorrectsID], m.ID) {
+				notices[m.CorrectsID] = append(notices[m.CorrectsID], m.ID)
+			}
+		}
+	}
+
+	for _, m := range records {
+		m.Notices = slices.Sorted(slices.Values(notices[m.ID]))
+	}
+	return stats
+}
@@ -0,0 +1,77 @@
+package index
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	_ "modernc.org/sqlite"
+)
+
+const keywordSchema = `
+CREATE TABLE keywords (
+	keyword  TEXT NOT NULL,
+	language TEXT NOT NULL,
+	count    INTEGER NOT NULL,
+	PRIMARY KEY (keyword, language)
+);
+CREATE TABLE keyword_articles (
+	keyword    TEXT NOT NULL,
+	language   TEXT NOT NULL,
+	article_id TEXT NOT NULL
+);
+CREATE TABLE keyword_years (
+	keyword  TEXT NOT NULL,
+	language TEXT NOT NULL,
+	year     TEXT NOT NULL,
+	count    INTEGER NOT NULL
+);
+CREATE INDEX keyword_articles_article ON keyword_articles (article_id);
+CREATE INDEX keyword_years_year ON keyword_years (year);
+`
+
+// WriteSQLite writes the index to a new SQLite database at path,
+// replacing any existing file.
+func (idx *KeywordIndex) WriteSQLite(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to replace existing database: %w", err)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.Close()
+
+	if _, err := db.Exec(keywordSchema); err != nil {
+		return fmt.Errorf("failed to create schema: %w", err)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, entry := range idx.Keywords {
+		if _, err := tx.Exec(`INSERT INTO keywords VALUES (?, ?, ?)`, entry.Keyword, entry.Language, entry.Count); err != nil {
+			return fmt.Errorf("failed to insert keyword %q: %w", entry.Keyword, err)
+		}
+		for _, id := range entry.Articles {
+			if _, err := tx.Exec(`INSERT INTO keyword_articles VALUES (?, ?, ?)`, entry.Keyword, entry.Language, id); err != nil {
+				return fmt.Errorf("failed to insert article for %q: %w", entry.Keyword, err)
+			}
+		}
+		for year, count := range entry.ByYear {
+			if _, err := tx.Exec(`INSERT INTO keyword_years VALUES (?, ?, ?, ?)`, entry.Keyword, entry.Language, year, count); err != nil {
+				return fmt.Errorf("failed to insert year count for %q: %w", entry.Keyword, err)
+			}
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit index: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,252 @@
+package index
+
+import (
+	"encoding/csv"
+	"fmt"
+	"html"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// KeywordTrend is one keyword's article count in each publication year.
+type KeywordTrend struct {
+	Keyword  string `json:"keyword"`
+	Language string `json:"language"`
+	Count    int    `json:"count"`
+	// Counts and Shares are per year, in the order of KeywordTrends.Years;
+	// a share is the percentage of that year's articles using the keyword
+	Counts []int     `json:"counts"`
+	Shares []float64 `json:"shares"`
+	// Slope is the least-squares change of the share in percentage points
+	// per year: positive for rising topics, negative for fading ones
+	Slope float64 `json:"slope"`
+}
+
+// KeywordTrends tabulates how often the most used keywords appear, year by
+// year. Shares make years with more articles comparable to leaner ones.
+type KeywordTrends struct {
+	// Years runs from the first to the last publication year of the
+	// records, without gaps
+	Years []string `json:"years"`
+	// Articles are the records of each year
+	Articles []int `json:"articles"`
+	// Undated records have no year and are left out of the counts
+	Undated  int             `json:"undated"`
+	Keywords []*KeywordTrend `json:"keywords"`
+}
+
+// BuildKeywordTrends tabulates the top keywords of records by article count,
+// counting English keywords case-folded as BuildKeywords does. language
+// ("zh" or "en") limits them to one language, and an empty one takes both.
+// top = 0 keeps every keyword.
+func BuildKeywordTrends(records []*parser.PaperMetadata, language string, top int) *KeywordTrends {
+	trends := &KeywordTrends{Years: []string{}, Articles: []int{}, Keywords: []*KeywordTrend{}}
+
+	first, last := 0, 0
+	for _, m := range records {
+		year, err := strconv.Atoi(m.Year)
+		if err != nil {
+			trends.Undated++
+			continue
+		}
+		if first == 0 || year < first {
+			first = year
+		}
+		last = max(last, year)
+	}
+	if first == 0 {
+		return trends
+	}
+
+	column := make(map[string]int)
+	for year := first; year <= last; year++ {
+		column[strconv.Itoa(year)] = len(trends.Years)
+		trends.Years = append(trends.Years, strconv.Itoa(year))
+		trends.Articles = append(trends.Articles, 0)
+	}
+	for _, m := range records {
+		if i, ok := column[m.Year]; ok {
+			trends.Articles[i]++
+		}
+	}
+
+	for _, entry := range BuildKeywords(records).Keywords {
+		if language != "" && entry.Language != language {
+			continue
+		}
+		if top > 0 && len(trends.Keywords) == top {
+			break
+		}
+
+		trend := &KeywordTrend{
+			Keyword:  entry.Keyword,
+			Language: entry.Language,
+			Counts:   make([]int, len(trends.Years)),
+			Shares:   make([]float64, len(trends.Years)),
+		}
+		for year, n := range entry.ByYear {
+			if i, ok := column[year]; ok {
+				trend.Counts[i] = n
+				trend.Count += n
+			}
+		}
+		if trend.Count == 0 {
+			continue
+		}
+		for i, n := range trend.Counts {
+			if trends.Articles[i] > 0 {
+				trend.Shares[i] = round1(float64(n) * 100 / float64(trends.Articles[i]))
+			}
+		}
+		trend.Slope = trends.slope(trend.Shares)
+		trends.Keywords = append(trends.Keywords, trend)
+	}
+	return trends
+}
+
+// slope fits a line through the shares of the years with articles.
+func (t *KeywordTrends) slope(shares []float64) float64 {
+	var n, sumX, sumY, sumXY, sumXX float64
+	for i, share := range shares {
+		if t.Articles[i] == 0 {
+			continue
+		}
+		x := float64(i)
+		n++
+		sumX += x
+		sumY += share
+		sumXY += x * share
+		sumXX += x * x
+	}
+	if n < 2 {
+		return 0
+	}
+	slope := math.Round((n*sumXY-sumX*sumY)/(n*sumXX-sumX*sumX)*100) / 100
+	if slope == 0 {
+		return 0 // not -0
+	}
+	return slope
+}
+
+func round1(x float64) float64 {
+	return math.Round(x*10) / 10
+}
+
+// WriteCSV writes the table with one row per keyword and one column per
+// year, holding counts, or shares when shares is set, followed by the
+// slope. The first row after the header gives each year's article count.
+func (t *KeywordTrends) WriteCSV(w io.Writer, shares bool) error {
+	writer := csv.NewWriter(w)
+
+	header := append([]string{"keyword", "language", "count"}, t.Years...)
+	if err := writer.Write(append(header, "slope")); err != nil {
+		return fmt.Errorf("failed to write CSV header: %w", err)
+	}
+
+	articles := []string{"(articles)", "", strconv.Itoa(sum(t.Articles))}
+	for _, n := range t.Articles {
+		articles = append(articles, strconv.Itoa(n))
+	}
+	if err := writer.Write(append(articles, "")); err != nil {
+		return fmt.Errorf("failed to write CSV row: %w", err)
+	}
+
+	for _, k := range t.Keywords {
+		row := []string{k.Keyword, k.Language, strconv.Itoa(k.Count)}
+		for i, n := range k.Counts {
+			if shares {
+				row = append(row, strconv.FormatFloat(k.Shares[i], 'f', 1, 64))
+			} else {
+				row = append(row, strconv.Itoa(n))
+			}
+		}
+		row = append(row, strconv.FormatFloat(k.Slope, 'f', 2, 64))
+		if err := writer.Write(row); err != nil {
+			return fmt.Errorf("failed to write CSV row for %s: %w", k.Keyword, err)
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func sum(values []int) int {
+	total := 0
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
+
+// chartColors tell up to ten lines apart.
+var chartColors = []string{
+	"#1f77b4", "#ff7f0e", "#2ca02c", "#d62728", "#9467bd",
+	"#8c564b", "#e377c2", "#7f7f7f", "#bcbd22", "#17becf",
+}
+
+// WriteSVG draws the shares of the first keywords, at most ten, as a line
+// chart with one line per keyword and a legend.
+func (t *KeywordTrends) WriteSVG(w io.Writer) error {
+	const (
+		width, height = 900, 480
+		left, right   = 50, 220
+		top, bottom   = 20, 40
+		plotW, plotH  = width - left - right, height - top - bottom
+	)
+	keywords := t.Keywords[:min(len(t.Keywords), len(chartColors))]
+
+	peak := 1.0
+	for _, k := range keywords {
+		for _, share := range k.Shares {
+			peak = max(peak, share)
+		}
+	}
+	peak = math.Ceil(peak/5) * 5
+	x := func(i int) float64 {
+		if len(t.Years) < 2 {
+			return left + plotW/2
+		}
+		return left + float64(i)*plotW/float64(len(t.Years)-1)
+	}
+	y := func(share float64) float64 {
+		return top + plotH - share*plotH/peak
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="sans-serif" font-size="12">`+"\n", width, height)
+	fmt.Fprintf(&b, `<rect width="%d" height="%d" fill="#fff"/>`+"\n", width, height)
+
+	// Axes, with gridlines every fifth of the peak share and a year label
+	// every few years so labels don't overlap
+	for i := 0; i <= 5; i++ {
+		share := peak * float64(i) / 5
+		fmt.Fprintf(&b, `<line x1="%d" y1="%.1f" x2="%d" y2="%.1f" stroke="#ddd"/>`+"\n", left, y(share), left+plotW, y(share))
+		fmt.Fprintf(&b, `<text x="%d" y="%.1f" text-anchor="end">%g%%</text>`+"\n", left-6, y(share)+4, share)
+	}
+	step := max(1, (len(t.Years)+11)/12)
+	for i, year := range t.Years {
+		if i%step == 0 || i == len(t.Years)-1 {
+			fmt.Fprintf(&b, `<text x="%.1f" y="%d" text-anchor="middle">%s</text>`+"\n", x(i), top+plotH+18, year)
+		}
+	}
+
+	for n, k := range keywords {
+		color := chartColors[n]
+		var points []string
+		for i, share := range k.Shares {
+			points = append(points, fmt.Sprintf("%.1f,%.1f", x(i), y(share)))
+		}
+		fmt.Fprintf(&b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="2"/>`+"\n", strings.Join(points, " "), color)
+		legendY := top + 10 + n*20
+		fmt.Fprintf(&b, `<line x1="%d" y1="%d" x2="%d" y2="%d" stroke="%s" stroke-width="2"/>`+"\n", width-right+20, legendY, width-right+40, legendY, color)
+		fmt.Fprintf(&b, `<text x="%d" y="%d">%s</text>`+"\n", width-right+46, legendY+4, html.EscapeString(k.Keyword))
+	}
+	b.WriteString("</svg>\n")
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,161 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// FileName is the checksum manifest written at the root of an output
+// directory, in the format read by `sha256sum -c`.
+const FileName = "SHA256SUMS"
+
+// Sums checksums every file under dir, keyed by its slash-separated path
+// relative to dir. Hidden files, temporary files and the manifest and its
+// signatures are left out.
+func Sums(dir string) (map[string]string, error) {
+	sums := make(map[string]string)
+
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		name := d.Name()
+		if strings.HasPrefix(name, ".") && path != dir {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() || strings.HasSuffix(name, ".tmp") || strings.HasPrefix(name, FileName) {
+			return nil
+		}
+
+		sum, err := hashFile(path)
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		sums[filepath.ToSlash(rel)] = sum
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to checksum output: %w", err)
+	}
+	return sums, nil
+}
+
+// Write checksums every file under dir, as Sums does, into dir/SHA256SUMS
+// and returns its path and the number of files listed.
+func Write(dir string) (string, int, error) {
+	sums, err := Sums(dir)
+	if err != nil {
+		return "", 0, err
+	}
+
+	// Sort by path so manifests of the same dataset are byte-identical
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(sums)) {
+		lines = append(lines, sums[name]+"  "+name)
+	}
+
+	// Signatures of a previous manifest no longer match
+	for _, ext := range []string{".asc", ".minisig"} {
+		os.Remove(filepath.Join(dir, FileName+ext))
+	}
+
+	path := filepath.Join(dir, FileName)
+	content := strings.Join(lines, "\n")
+	if len(lines) > 0 {
+		content += "\n"
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		return "", 0, fmt.Errorf("failed to write manifest: %w", err)
+	}
+
+	return path, len(lines), nil
+}
+
+// Read returns the checksums a manifest lists, keyed by file path.
+func Read(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	sums := make(map[string]string)
+	for i, line := range strings.Split(strings.TrimRight(string(data), "\n"), "\n") {
+		if line == "" {
+			continue
+		}
+		sum, name, ok := strings.Cut(line, "  ")
+		if !ok || len(sum) != sha256.Size*2 {
+			return nil, fmt.Errorf("invalid manifest %s: line %d isn't \"checksum  path\"", path, i+1)
+		}
+		sums[name] = sum
+	}
+	return sums, nil
+}
+
+func hashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// Sign creates a detached signature for the manifest at path with the
+// minisign or gpg command-line tool, returning the signature's path. key is
+// the minisign secret key file, or the gpg key ID/email (empty for gpg's
+// default key). The tools may prompt for a passphrase on the terminal.
+func Sign(path, method, key string) (string, error) {
+	var cmd *exec.Cmd
+	var sigPath string
+
+	switch method {
+	case "minisign":
+		sigPath = path + ".minisig"
+		args := []string{"-S", "-m", path, "-x", sigPath, "-t", "gtft-crawler dataset manifest"}
+		if key != "" {
+			args = append(args, "-s", key)
+		}
+		cmd = exec.Command("minisign", args...)
+	case "gpg":
+		sigPath = path + ".asc"
+		args := []string{"--yes", "--armor", "--detach-sign", "--output", sigPath}
+		if key != "" {
+			args = append(args, "--local-user", key)
+		}
+		cmd = exec.Command("gpg", append(args, path)...)
+	default:
+		return "", fmt.Errorf("unknown signing method %q (use minisign or gpg)", method)
+	}
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stderr
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s signing failed: %w", method, err)
+	}
+
+	return sigPath, nil
+}
@@ -0,0 +1,74 @@
+// Package metrics exposes the crawl's running counters to monitoring
+// systems. Values are read from the components that already count them when
+// metrics are gathered, so instrumenting a counter costs nothing per page.
+package metrics
+
+import (
+	"sync"
+)
+
+// Kind is the type of a metric.
+type Kind string
+
+const (
+	// Counter only increases during a run
+	Counter Kind = "counter"
+	// Gauge can go up and down
+	Gauge Kind = "gauge"
+)
+
+// Sample is the value of one metric when it was gathered.
+type Sample struct {
+	Name  string
+	Help  string
+	Kind  Kind
+	Value float64
+}
+
+type metric struct {
+	name  string
+	help  string
+	kind  Kind
+	value func() float64
+}
+
+// Registry holds the metrics of a crawl. It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// Counter registers a counter read from value. Names are lower_snake_case
+// without a prefix or unit suffix, e.g. "records_saved".
+func (r *Registry) Counter(name, help string, value func() float64) {
+	r.add(metric{name: name, help: help, kind: Counter, value: value})
+}
+
+// Gauge registers a gauge read from value.
+func (r *Registry) Gauge(name, help string, value func() float64) {
+	r.add(metric{name: name, help: help, kind: Gauge, value: value})
+}
+
+func (r *Registry) add(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.metrics = append(r.metrics, m)
+}
+
+// Gather reads every metric, in registration order.
+func (r *Registry) Gather() []Sample {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	samples := make([]Sample, len(metrics))
+	for i, m := range metrics {
+		samples[i] = Sample{Name: m.name, Help: m.help, Kind: m.kind, Value: m.value()}
+	}
+	return samples
+}
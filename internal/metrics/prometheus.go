@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// WritePrometheus writes samples in the Prometheus text exposition format.
+// Names get namespace and an underscore prepended, and counters the _total
+// suffix, e.g. gtft_crawler_records_saved_total.
+func WritePrometheus(w io.Writer, namespace string, samples []Sample) error {
+	bw := bufio.NewWriter(w)
+	for _, sample := range samples {
+		name := sample.Name
+		if namespace != "" {
+			name = namespace + "_" + name
+		}
+		if sample.Kind == Counter {
+			name += "_total"
+		}
+
+		fmt.Fprintf(bw, "# HELP %s %s\n", name, escapeHelp(sample.Help))
+		fmt.Fprintf(bw, "# TYPE %s %s\n", name, sample.Kind)
+		fmt.Fprintf(bw, "%s %s\n", name, strconv.FormatFloat(sample.Value, 'g', -1, 64))
+	}
+	return bw.Flush()
+}
+
+func escapeHelp(help string) string {
+	return strings.NewReplacer(`\`, `\\`, "\n", `\n`).Replace(help)
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pushgateway pushes a registry's metrics to a Prometheus Pushgateway, for
+// runs that end before they could be scraped.
+type Pushgateway struct {
+	registry  *Registry
+	url       string
+	namespace string
+	client    *http.Client
+}
+
+// NewPushgateway prepares to push the metrics in registry to the Pushgateway
+// at baseURL (e.g. http://localhost:9091), grouped under job and the
+// grouping labels (typically instance).
+func NewPushgateway(registry *Registry, baseURL, namespace, job string, labels map[string]string) (*Pushgateway, error) {
+	if job == "" {
+		return nil, fmt.Errorf("pushgateway job name must not be empty")
+	}
+
+	path := "/metrics/job" + groupingSegment(job)
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if name == "" || name == "job" {
+			return nil, fmt.Errorf("invalid pushgateway grouping label %q", name)
+		}
+		path += "/" + name + groupingSegment(labels[name])
+	}
+
+	return &Pushgateway{
+		registry:  registry,
+		url:       strings.TrimRight(baseURL, "/") + path,
+		namespace: namespace,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}, nil
+}
+
+// groupingSegment encodes a grouping label value as a URL path segment.
+// Values that are empty or contain a slash use the base64 form the
+// Pushgateway accepts for them.
+func groupingSegment(value string) string {
+	if value == "" {
+		return "@base64/="
+	}
+	if strings.Contains(value, "/") {
+		return "@base64/" + base64.RawURLEncoding.EncodeToString([]byte(value))
+	}
+	return "/" + url.PathEscape(value)
+}
+
+// Push replaces the metrics of the group with the current values.
+func (p *Pushgateway) Push() error {
+	var body bytes.Buffer
+	if err := WritePrometheus(&body, p.namespace, p.registry.Gather()); err != nil {
+		return fmt.Errorf("failed to encode metrics: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPut, p.url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create pushgateway request: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(message)))
+	}
+	return nil
+}
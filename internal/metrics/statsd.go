@@ -0,0 +1,136 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxPacketSize keeps StatsD datagrams under a typical Ethernet MTU.
+const maxPacketSize = 1432
+
+// StatsD sends a registry's metrics to a StatsD or DogStatsD agent over UDP
+// at a fixed interval. Counters are sent as the increase since the previous
+// flush, gauges as their current value.
+type StatsD struct {
+	registry *Registry
+	conn     net.Conn
+	prefix   string
+	// tags is the DogStatsD tag suffix, e.g. "|#env:prod,job:gtft"
+	tags    string
+	verbose bool
+
+	mu   sync.Mutex
+	sent map[string]float64
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewStatsD prepares to send the metrics in registry to the agent at addr
+// (host:port). Metric names get prefix prepended; tags ("key:value" pairs)
+// are attached to every metric in the DogStatsD format.
+func NewStatsD(registry *Registry, addr, prefix string, tags []string, verbose bool) (*StatsD, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to statsd at %s: %w", addr, err)
+	}
+
+	s := &StatsD{
+		registry: registry,
+		conn:     conn,
+		prefix:   prefix,
+		verbose:  verbose,
+		sent:     make(map[string]float64),
+	}
+	if len(tags) > 0 {
+		s.tags = "|#" + strings.Join(tags, ",")
+	}
+	return s, nil
+}
+
+// Start flushes metrics every interval until Close.
+func (s *StatsD) Start(interval time.Duration) {
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				if err := s.Flush(); err != nil && s.verbose {
+					fmt.Printf("[StatsD] %v\n", err)
+				}
+			case <-s.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Flush sends the current metric values.
+func (s *StatsD) Flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var lines []string
+	for _, sample := range s.registry.Gather() {
+		value, kind := sample.Value, "g"
+		if sample.Kind == Counter {
+			value -= s.sent[sample.Name]
+			if value == 0 {
+				continue
+			}
+			s.sent[sample.Name] = sample.Value
+			kind = "c"
+		}
+		lines = append(lines, s.prefix+sample.Name+":"+strconv.FormatFloat(value, 'f', -1, 64)+"|"+kind+s.tags)
+	}
+
+	// Pack as many lines per datagram as fit
+	var packet strings.Builder
+	for _, line := range lines {
+		if packet.Len() > 0 && packet.Len()+1+len(line) > maxPacketSize {
+			if err := s.write(packet.String()); err != nil {
+				return err
+			}
+			packet.Reset()
+		}
+		if packet.Len() > 0 {
+			packet.WriteByte('\n')
+		}
+		packet.WriteString(line)
+	}
+	if packet.Len() > 0 {
+		return s.write(packet.String())
+	}
+	return nil
+}
+
+func (s *StatsD) write(packet string) error {
+	if _, err := s.conn.Write([]byte(packet)); err != nil {
+		return fmt.Errorf("failed to send metrics: %w", err)
+	}
+	return nil
+}
+
+// Close stops the periodic flush, sends the final values and closes the
+// connection.
+func (s *StatsD) Close() error {
+	if s.stop != nil {
+		close(s.stop)
+		<-s.done
+	}
+
+	err := s.Flush()
+	if closeErr := s.conn.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close statsd connection: %w", closeErr)
+	}
+	return err
+}
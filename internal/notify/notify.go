@@ -0,0 +1,128 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Notifier announces newly discovered articles.
+type Notifier interface {
+	Notify(articles []*parser.PaperMetadata) error
+}
+
+// FromConfig builds the notifiers for the configured alert targets.
+func FromConfig(webhookURL, slackURL string) []Notifier {
+	var notifiers []Notifier
+	if webhookURL != "" {
+		notifiers = append(notifiers, NewWebhook(webhookURL))
+	}
+	if slackURL != "" {
+		notifiers = append(notifiers, NewSlack(slackURL))
+	}
+	return notifiers
+}
+
+type articleSummary struct {
+	ID         string `json:"id"`
+	Title      string `json:"title"`
+	TitleEN    string `json:"title_en,omitempty"`
+	URL        string `json:"url"`
+	DOI        string `json:"doi,omitempty"`
+	OnlineDate string `json:"online_date,omitempty"`
+}
+
+func summarize(articles []*parser.PaperMetadata) []articleSummary {
+	summaries := make([]articleSummary, 0, len(articles))
+	for _, m := range articles {
+		summaries = append(summaries, articleSummary{
+			ID:         m.ID,
+			Title:      m.TitleCN,
+			TitleEN:    m.TitleEN,
+			URL:        m.URL,
+			DOI:        m.DOI,
+			OnlineDate: m.OnlineDate,
+		})
+	}
+	return summaries
+}
+
+// Webhook POSTs a JSON document listing the new articles.
+type Webhook struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhook(url string) *Webhook {
+	return &Webhook{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+func (w *Webhook) Notify(articles []*parser.PaperMetadata) error {
+	payload := struct {
+		Event    string           `json:"event"`
+		Count    int              `json:"count"`
+		Articles []articleSummary `json:"articles"`
+	}{
+		Event:    "new_articles",
+		Count:    len(articles),
+		Articles: summarize(articles),
+	}
+
+	return postJSON(w.client, w.url, payload)
+}
+
+// Slack posts a message to a Slack incoming webhook.
+type Slack struct {
+	url    string
+	client *http.Client
+}
+
+func NewSlack(url string) *Slack {
+	return &Slack{url: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// slackMaxArticles keeps messages under Slack's block size limits
+const slackMaxArticles = 20
+
+func (s *Slack) Notify(articles []*parser.PaperMetadata) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "*%d new article(s) in 钢铁钒钛*\n", len(articles))
+
+	for i, m := range articles {
+		if i == slackMaxArticles {
+			fmt.Fprintf(&b, "…and %d more\n", len(articles)-slackMaxArticles)
+			break
+		}
+		title := m.TitleCN
+		if title == "" {
+			title = m.ID
+		}
+		fmt.Fprintf(&b, "• <%s|%s>\n", m.URL, title)
+	}
+
+	return postJSON(s.client, s.url, map[string]string{"text": b.String()})
+}
+
+func postJSON(client *http.Client, url string, payload any) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("alert request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("alert rejected: HTTP %d", resp.StatusCode)
+	}
+
+	return nil
+}
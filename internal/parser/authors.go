@@ -0,0 +1,67 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+)
+
+// authorSeparatorPattern splits an author list on commas, semicolons and
+// the Chinese enumeration comma 、, in either width, and on a final "and"
+// or "&".
+var authorSeparatorPattern = regexp.MustCompile(`\s*(?:[,;，；、]+|\s+and\s+|\s*&\s*)\s*`)
+
+// authorMarkerPattern matches affiliation and correspondence markers
+// trailing a name, as in "张伟1,2*".
+var authorMarkerPattern = regexp.MustCompile(`[\s\d*†‡#,，]+$`)
+
+// affiliationPattern matches text that names an institution or address
+// rather than a person.
+var affiliationPattern = regexp.MustCompile(`(?i)大学|学院|研究院|研究所|研究中心|实验室|公司|集团|钢铁厂|编辑部|university|institute|college|laborator|company|co\.,? ?ltd|corporation|\b\d{6}\b`)
+
+// maxAuthors is the largest author list taken as plausible; more is almost
+// always a page element mistaken for the author list.
+const maxAuthors = 30
+
+// splitAuthors splits an author list into names.
+func splitAuthors(list string) []string {
+	var names []string
+	for _, name := range authorSeparatorPattern.Split(strings.TrimSpace(list), -1) {
+		if name = cleanAuthorName(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// addAuthors appends names to the record's authors in order, skipping
+// repeats of a name already listed.
+func (m *PaperMetadata) addAuthors(names ...string) {
+	for _, name := range names {
+		if name == "" || m.hasAuthor(name) {
+			continue
+		}
+		m.Authors = append(m.Authors, Author{Name: name, Order: len(m.Authors) + 1})
+	}
+}
+
+// hasAuthor reports whether name is already among the authors, ignoring
+// case and spacing.
+func (m *PaperMetadata) hasAuthor(name string) bool {
+	key := authorKey(name)
+	for _, author := range m.Authors {
+		if authorKey(author.Name) == key {
+			return true
+		}
+	}
+	return false
+}
+
+func authorKey(name string) string {
+	return strings.ToLower(strings.Join(strings.Fields(name), ""))
+}
+
+// looksLikeAffiliation reports whether text names an institution rather
+// than a person.
+func looksLikeAffiliation(text string) bool {
+	return affiliationPattern.MatchString(text)
+}
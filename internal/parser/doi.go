@@ -0,0 +1,83 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// doiPrefixes are the resolver and scheme prefixes DOIs are published with.
+var doiPrefixes = []string{
+	"https://doi.org/", "http://doi.org/", "https://dx.doi.org/", "http://dx.doi.org/", "doi:",
+}
+
+// NormalizeDOI returns a DOI trimmed, lowercased and without a resolver or
+// "doi:" prefix, so the same DOI written differently compares equal.
+func NormalizeDOI(doi string) string {
+	doi = strings.ToLower(strings.TrimSpace(doi))
+	for _, prefix := range doiPrefixes {
+		if strings.HasPrefix(doi, prefix) {
+			doi = strings.TrimSpace(doi[len(prefix):])
+			break
+		}
+	}
+	return doi
+}
+
+var (
+	// doiPattern matches a bare DOI, in an element marked as holding one
+	doiPattern = regexp.MustCompile(`(10\.\d{4,9}/[^\s"<>，。；）、]+)`)
+	// labelledDOIPattern matches a DOI labelled "doi:" or linked through a
+	// resolver, in any page text
+	labelledDOIPattern = regexp.MustCompile(`(?i)(?:\bdoi\s*[:：]?\s*|doi\.org/)(10\.\d{4,9}/[^\s"<>，。；）、]+)`)
+	// referenceContainers hold reference lists, whose DOIs are the cited
+	// works' rather than the article's
+	referenceContainers = ".references, .reference-list, .article-references, #references, [class*='reference']"
+)
+
+// doiFromTextWarning flags a DOI found in the page text rather than marked
+// up as the article's.
+const doiFromTextWarning = "doi taken from page text"
+
+// extractDOI finds the DOI in the visible article header when the meta tags
+// have none, as on many older articles: first in an element marked as the
+// DOI or a resolver link, then in text labelled "DOI" anywhere outside the
+// reference list.
+func (p *Parser) extractDOI(doc *goquery.Document, metadata *PaperMetadata) error {
+	if metadata.DOI != "" {
+		return nil
+	}
+
+	doc.Find("[class*='doi'], [id*='doi'], a[href*='doi.org/10.']").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if s.Closest(referenceContainers).Length() > 0 {
+			return true
+		}
+		text := s.Text()
+		if href, ok := s.Attr("href"); ok && strings.Contains(href, "doi.org/") {
+			text = href
+		}
+		metadata.DOI = findDOI(doiPattern, text)
+		return metadata.DOI == ""
+	})
+	if metadata.DOI == "" {
+		body := doc.Find("body").Clone()
+		body.Find(referenceContainers).Remove()
+		metadata.DOI = findDOI(labelledDOIPattern, body.Text())
+	}
+	if metadata.DOI != "" {
+		metadata.Warn(doiFromTextWarning)
+	}
+
+	return nil
+}
+
+// findDOI returns the first DOI pattern matches in text, without the
+// punctuation that follows it in a sentence.
+func findDOI(pattern *regexp.Regexp, text string) string {
+	matches := pattern.FindStringSubmatch(text)
+	if len(matches) < 2 {
+		return ""
+	}
+	return strings.TrimRight(matches[1], ".,;:)]}")
+}
@@ -0,0 +1,104 @@
+package parser
+
+import (
+	"fmt"
+	neturl "net/url"
+	"strings"
+)
+
+// EnglishPageFunc fetches the page at url, returning its HTML and the URL
+// it came from after any redirects.
+type EnglishPageFunc func(url string) (html []byte, finalURL string, err error)
+
+// SetEnglishPages makes the parser fetch the English version of each
+// Chinese article page with fetch and merge its English title, abstract,
+// keywords and author names into the record, before the record is checked.
+// A failure leaves the record as the Chinese page had it, with a warning.
+func (p *Parser) SetEnglishPages(fetch EnglishPageFunc) {
+	p.englishPage = fetch
+}
+
+// EnglishURL returns the URL of the English version of an rhhz article
+// page: its /cn/ segment replaced by /en/, or /en/ put in front of a bare
+// /article/ path. ok is false for English pages and pages that aren't
+// articles.
+func EnglishURL(url string) (english string, ok bool) {
+	u, err := neturl.Parse(url)
+	if err != nil || !strings.Contains(u.Path, "/article/") {
+		return "", false
+	}
+	segments := strings.Split(u.Path, "/")
+	for i, segment := range segments {
+		switch strings.ToLower(segment) {
+		case "en":
+			return "", false
+		case "cn", "zh":
+			segments[i] = "en"
+			u.Path = strings.Join(segments, "/")
+			return u.String(), true
+		}
+	}
+	u.Path = strings.Replace(u.Path, "/article/", "/en/article/", 1)
+	return u.String(), true
+}
+
+// mergeEnglish fetches and parses the English version of the Chinese page
+// metadata was parsed from, and takes its English fields over.
+func (p *Parser) mergeEnglish(metadata *PaperMetadata) error {
+	url, ok := EnglishURL(metadata.URL)
+	if !ok {
+		return nil
+	}
+	html, finalURL, err := p.englishPage(url)
+	if err != nil {
+		return err
+	}
+
+	// The English page is parsed like any other, but without merging or
+	// strict checks of its own
+	companion := *p
+	companion.englishPage = nil
+	companion.critical = nil
+	companion.verbose = false
+	en, err := companion.Parse(html, finalURL)
+	if err != nil {
+		return err
+	}
+	if !en.english() {
+		return fmt.Errorf("%s is not an English page", finalURL)
+	}
+	metadata.MergeEnglish(en)
+	return nil
+}
+
+// MergeEnglish takes the English title, abstract, keywords and author
+// names of en, the record of an article's English page, over into m, the
+// record of its Chinese page. Fields en lacks are left alone. Author names
+// are matched by position, so they are only merged when both pages list as
+// many authors.
+func (m *PaperMetadata) MergeEnglish(en *PaperMetadata) {
+	if en.TitleEN != "" {
+		m.TitleEN = en.TitleEN
+	}
+	if en.AbstractEN != "" {
+		m.AbstractEN = en.AbstractEN
+	}
+	if len(en.KeywordsEN) > 0 {
+		m.KeywordsEN = en.KeywordsEN
+	}
+	if en.JournalEN != "" && m.JournalEN == "" {
+		m.JournalEN = en.JournalEN
+	}
+
+	switch {
+	case len(en.Authors) == 0:
+	case len(en.Authors) != len(m.Authors):
+		m.Warn("English page lists %d authors against %d, English names not merged", len(en.Authors), len(m.Authors))
+	default:
+		for i, author := range en.Authors {
+			if latinScript(author.Name) {
+				m.Authors[i].NameEN = author.Name
+			}
+		}
+	}
+}
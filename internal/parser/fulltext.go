@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// fullTextSelectors find the HTML full text some article pages carry below
+// the abstract.
+var fullTextSelectors = []string{
+	"#FullText", ".article-fulltext", ".fulltext", ".full-text", "#htmlContent", ".html-content",
+}
+
+// extractFullTextLength measures the page's HTML full text, leaving out its
+// reference list. The text itself isn't kept.
+func (p *Parser) extractFullTextLength(doc *goquery.Document, metadata *PaperMetadata) error {
+	for _, selector := range fullTextSelectors {
+		body := doc.Find(selector).First()
+		if body.Length() == 0 {
+			continue
+		}
+		body = body.Clone()
+		body.Find(referenceContainers).Remove()
+		metadata.FullTextChars, metadata.FullTextWords = textLength(body.Text())
+		if metadata.FullTextChars > 0 {
+			break
+		}
+	}
+	return nil
+}
+
+// textLength counts the characters of text other than spaces, and its
+// words: runs of letters and digits, with each Han character a word of its
+// own as in Chinese word counts.
+func textLength(text string) (chars, words int) {
+	inWord := false
+	for _, r := range text {
+		if unicode.IsSpace(r) {
+			inWord = false
+			continue
+		}
+		chars++
+		switch {
+		case unicode.Is(unicode.Han, r):
+			words++
+			inWord = false
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			if !inWord {
+				words++
+			}
+			inWord = true
+		default:
+			inWord = false
+		}
+	}
+	return chars, words
+}
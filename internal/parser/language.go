@@ -0,0 +1,120 @@
+package parser
+
+import (
+	neturl "net/url"
+	"strings"
+	"unicode"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+const (
+	LanguageZH = "zh"
+	LanguageEN = "en"
+)
+
+// detectLanguage decides whether a page is the English or the Chinese
+// version of an article. A /en/ or /cn/ path segment decides, as rhhz sites
+// serve each language under its own prefix; otherwise the <html lang>
+// attribute does. Pages saying neither are taken as Chinese.
+func detectLanguage(doc *goquery.Document, url string) string {
+	if u, err := neturl.Parse(url); err == nil {
+		for _, segment := range strings.Split(strings.ToLower(u.Path), "/") {
+			switch segment {
+			case "en":
+				return LanguageEN
+			case "cn", "zh":
+				return LanguageZH
+			}
+		}
+	}
+
+	lang, _ := doc.Find("html").First().Attr("lang")
+	lang = strings.ToLower(strings.TrimSpace(lang))
+	if lang == "en" || strings.HasPrefix(lang, "en-") {
+		return LanguageEN
+	}
+	return LanguageZH
+}
+
+// english reports whether the record comes from an English page, whose
+// unmarked meta tags (citation_title, dc.description, ...) hold the English
+// title, abstract and keywords.
+func (m *PaperMetadata) english() bool {
+	return m.Language == LanguageEN
+}
+
+// hasHan reports whether s contains any Chinese characters.
+func hasHan(s string) bool {
+	for _, r := range s {
+		if unicode.Is(unicode.Han, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// latinScript reports whether s is written in Latin script: it has no
+// Chinese characters and at least four letters, nearly all of them Latin.
+// Formula symbols such as Greek letters don't change the verdict.
+func latinScript(s string) bool {
+	latin, letters := 0, 0
+	for _, r := range s {
+		switch {
+		case unicode.Is(unicode.Han, r):
+			return false
+		case unicode.Is(unicode.Latin, r):
+			latin++
+			letters++
+		case unicode.IsLetter(r):
+			letters++
+		}
+	}
+	return latin >= 4 && latin*10 >= letters*9
+}
+
+// setTitle stores a title by its script, so the Chinese and English titles
+// a page declares in the same tags both end up in the right field. Titles
+// of neither script go to the page's language.
+func (m *PaperMetadata) setTitle(title string) {
+	switch {
+	case hasHan(title):
+		m.TitleCN = title
+	case latinScript(title) || m.english():
+		m.TitleEN = title
+	default:
+		m.TitleCN = title
+	}
+}
+
+// setAbstract and setKeywords store a value from an unmarked meta tag in the
+// field for the page's language.
+func (m *PaperMetadata) setAbstract(abstract string) {
+	if m.english() {
+		m.AbstractEN = abstract
+	} else {
+		m.AbstractCN = abstract
+	}
+}
+
+func (m *PaperMetadata) setKeywords(keywords []string) {
+	if m.english() {
+		m.KeywordsEN = keywords
+	} else {
+		m.KeywordsCN = keywords
+	}
+}
+
+// setJournal stores a journal name from a meta tag by script, like setTitle.
+func (m *PaperMetadata) setJournal(journal string) {
+	journal = strings.TrimSpace(journal)
+	switch {
+	case journal == "":
+	case hasHan(journal):
+		m.JournalCN = journal
+	case latinScript(journal) || m.english():
+		m.JournalEN = journal
+	default:
+		m.JournalCN = journal
+	}
+}
@@ -0,0 +1,108 @@
+package parser
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	// ccURLPattern matches a Creative Commons license or public domain
+	// dedication URL, capturing its kind ("by-nc", "zero") and version
+	ccURLPattern = regexp.MustCompile(`(?i)https?://(?:www\.)?creativecommons\.org/(?:licenses|publicdomain)/([a-z-]+)/(\d\.\d)(?:/[a-z]{2,3}\b)?/?(?:(?:deed|legalcode)(?:\.[a-zA-Z_-]+)?)?`)
+	// ccNamePattern matches a license named in English, such as
+	// "CC BY-NC-ND 4.0"
+	ccNamePattern = regexp.MustCompile(`(?i)\bCC[ -]BY((?:[ -](?:NC|ND|SA))*)(?:\s*(\d\.\d))?`)
+	// versionPattern finds a license version in a Chinese statement
+	versionPattern = regexp.MustCompile(`\d\.\d`)
+)
+
+// chineseLicenseTerms are the Chinese names of the Creative Commons license
+// elements, in the order SPDX identifiers list them.
+var chineseLicenseTerms = []struct{ term, element string }{
+	{"非商业性使用", "NC"},
+	{"禁止演绎", "ND"},
+	{"相同方式共享", "SA"},
+}
+
+// setLicense sets License to the SPDX identifier of the license found in
+// text, and LicenseURL to its URL when text gives one. It reports whether a
+// license was found.
+func (m *PaperMetadata) setLicense(text string) bool {
+	if id, url := licenseFromURL(text); id != "" {
+		m.License, m.LicenseURL = id, url
+		return true
+	}
+	if id := licenseFromName(text); id != "" {
+		m.License = id
+		return true
+	}
+	return false
+}
+
+// licenseFromURL returns the SPDX identifier and URL of the first Creative
+// Commons URL in text, e.g. "CC-BY-NC-ND-4.0" for
+// https://creativecommons.org/licenses/by-nc-nd/4.0/.
+func licenseFromURL(text string) (id, url string) {
+	matches := ccURLPattern.FindStringSubmatch(text)
+	if matches == nil {
+		return "", ""
+	}
+	kind, version := strings.ToUpper(matches[1]), matches[2]
+	if kind == "ZERO" {
+		return "CC0-" + version, matches[0]
+	}
+	return "CC-" + kind + "-" + version, matches[0]
+}
+
+// licenseFromName returns the SPDX identifier of a license named in text,
+// in English ("CC BY-NC 4.0") or Chinese ("知识共享署名-非商业性使用 4.0 国际
+// 许可协议"). Without a version the identifier ends at its elements.
+func licenseFromName(text string) string {
+	if matches := ccNamePattern.FindStringSubmatch(text); matches != nil {
+		id := "CC-BY" + strings.ToUpper(strings.ReplaceAll(matches[1], " ", "-"))
+		if matches[2] != "" {
+			id += "-" + matches[2]
+		}
+		return id
+	}
+
+	// "署名" alone just means a byline, so the statement must name Creative
+	// Commons (知识共享) too
+	start := strings.Index(text, "知识共享")
+	if start < 0 {
+		return ""
+	}
+	statement := text[start:]
+	if end := strings.IndexAny(statement, "。\n"); end >= 0 {
+		statement = statement[:end]
+	}
+	if !strings.Contains(statement, "署名") {
+		return ""
+	}
+	id := "CC-BY"
+	for _, t := range chineseLicenseTerms {
+		if strings.Contains(statement, t.term) {
+			id += "-" + t.element
+		}
+	}
+	if version := versionPattern.FindString(statement); version != "" {
+		id += "-" + version
+	}
+	return id
+}
+
+// extractLicenseLink takes the license from a license link, such as
+// <a rel="license" href="https://creativecommons.org/licenses/by/4.0/">,
+// when the page text didn't state it.
+func (p *Parser) extractLicenseLink(doc *goquery.Document, metadata *PaperMetadata) error {
+	if metadata.License != "" {
+		return nil
+	}
+	doc.Find("a[rel='license'], link[rel='license'], a[href*='creativecommons.org']").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		href, _ := s.Attr("href")
+		return !metadata.setLicense(href)
+	})
+	return nil
+}
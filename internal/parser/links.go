@@ -0,0 +1,36 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// ExtractLinks returns the absolute URLs of all links on the page, resolved
+// against base, in document order without duplicates.
+func (p *Parser) ExtractLinks(html []byte, base string) ([]string, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(html))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse HTML: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var links []string
+	doc.Find("a[href]").Each(func(i int, s *goquery.Selection) {
+		href := strings.TrimSpace(s.AttrOr("href", ""))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(href, "javascript:") || strings.HasPrefix(href, "mailto:") {
+			return
+		}
+
+		link, err := resolveURL(base, href)
+		if err != nil || seen[link] {
+			return
+		}
+		seen[link] = true
+		links = append(links, link)
+	})
+
+	return links, nil
+}
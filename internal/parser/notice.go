@@ -0,0 +1,80 @@
+package parser
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	// retractionTitle and correctionTitle recognize notices by their titles,
+	// e.g. "关于《…》一文的撤稿声明" or "Correction to: …"
+	retractionTitle = regexp.MustCompile(`(?i)撤稿|撤销.{0,6}论文|\bretraction\b|\bretracted\b|\bwithdrawn\b`)
+	correctionTitle = regexp.MustCompile(`(?i)更正|勘误|\bcorrection\b|\bcorrigendum\b|\berratum\b`)
+	// quotedTitle matches a title between Chinese title marks
+	quotedTitle = regexp.MustCompile(`《([^《》]+)》`)
+	// noticeContainers hold a notice's text: the abstract block or the HTML
+	// full text, but not the sidebars listing other articles
+	noticeContainers = "[class*='abstract'], [id*='abstract'], " + strings.Join(fullTextSelectors, ", ")
+)
+
+// extractNotice recognizes retraction and correction notices by their
+// titles and records the article each concerns: its ID when the notice
+// links it, and otherwise its DOI or quoted title, for the link command to
+// resolve against the corpus.
+func (p *Parser) extractNotice(doc *goquery.Document, metadata *PaperMetadata) error {
+	title := strings.TrimSpace(metadata.TitleCN + " " + metadata.TitleEN)
+	switch {
+	case retractionTitle.MatchString(title):
+		metadata.IsRetraction = true
+	case correctionTitle.MatchString(title):
+		metadata.IsCorrection = true
+	default:
+		return nil
+	}
+
+	own := map[string]bool{IDFromURL(metadata.URL): true, canonicalID(metadata.CanonicalURL): true}
+	body := doc.Find(noticeContainers).Clone()
+	body.Find(referenceContainers).Remove()
+
+	body.Find("a[href]").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		link, err := resolveURL(metadata.URL, strings.TrimSpace(s.AttrOr("href", "")))
+		if err != nil {
+			return true
+		}
+		if id := canonicalID(link); id != "" && !own[id] {
+			metadata.CorrectsID = id
+		}
+		return metadata.CorrectsID == ""
+	})
+
+	text := title + "\n" + body.Text()
+	ownDOI := NormalizeDOI(metadata.DOI)
+	// A DOI found in the page text may be the one the notice names
+	guessed := slices.Contains(metadata.Warnings, doiFromTextWarning)
+	for _, match := range doiPattern.FindAllStringSubmatch(text, -1) {
+		doi := NormalizeDOI(strings.TrimRight(match[1], ".,;:)]}"))
+		if doi == ownDOI && guessed {
+			metadata.DOI = ""
+			metadata.Warnings = slices.DeleteFunc(metadata.Warnings, func(w string) bool { return w == doiFromTextWarning })
+		}
+		if doi != ownDOI || guessed {
+			metadata.CorrectsDOI = doi
+			break
+		}
+	}
+	for _, match := range quotedTitle.FindAllStringSubmatch(text, -1) {
+		quoted := strings.TrimSpace(match[1])
+		if quoted != metadata.JournalCN && quoted != p.site.JournalCN {
+			metadata.CorrectsTitle = quoted
+			break
+		}
+	}
+
+	if metadata.CorrectsID == "" && metadata.CorrectsDOI == "" && metadata.CorrectsTitle == "" {
+		metadata.Warn("notice doesn't identify the article it concerns")
+	}
+	return nil
+}
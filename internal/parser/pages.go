@@ -0,0 +1,100 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pagePattern matches a page number, optionally with a short prefix as in
+// supplements ("S12") or e-locators ("e1023").
+var pagePattern = regexp.MustCompile(`^[A-Za-z]{0,2}\d+$`)
+
+// pageRangePattern matches "first-last", with any dash or tilde, full-width
+// ones included.
+var pageRangePattern = regexp.MustCompile(`^([A-Za-z]{0,2}\d+)\s*[-–—－~～]+\s*([A-Za-z]{0,2}\d+)$`)
+
+// setPageRange sets FirstPage and LastPage from a "first-last" range or a
+// single page. Empty pages clear both.
+func (m *PaperMetadata) setPageRange(pages string) {
+	pages = strings.TrimSpace(pages)
+	if pages == "" {
+		m.FirstPage, m.LastPage = "", ""
+		return
+	}
+	if matches := pageRangePattern.FindStringSubmatch(pages); matches != nil {
+		m.FirstPage, m.LastPage = matches[1], matches[2]
+		return
+	}
+	if pagePattern.MatchString(pages) {
+		m.FirstPage, m.LastPage = pages, ""
+		return
+	}
+	m.Warn("invalid pages %q", pages)
+}
+
+// finishPages validates FirstPage and LastPage and derives Pages and
+// PageCount from them: "first-last", or just "first" for a single page or a
+// missing last page. Values that aren't page numbers, and a last page before
+// the first, are dropped with a warning.
+func finishPages(m *PaperMetadata) {
+	m.FirstPage = strings.TrimSpace(m.FirstPage)
+	m.LastPage = strings.TrimSpace(m.LastPage)
+	if m.FirstPage != "" && !pagePattern.MatchString(m.FirstPage) {
+		m.Warn("invalid first page %q", m.FirstPage)
+		m.FirstPage = ""
+	}
+	if m.LastPage != "" && !pagePattern.MatchString(m.LastPage) {
+		m.Warn("invalid last page %q", m.LastPage)
+		m.LastPage = ""
+	}
+
+	if m.FirstPage != "" && m.LastPage != "" && PageNumber(m.LastPage) < PageNumber(m.FirstPage) {
+		m.Warn("last page %s before first page %s", m.LastPage, m.FirstPage)
+		m.LastPage = ""
+	}
+
+	switch {
+	case m.FirstPage == "":
+		if m.LastPage != "" {
+			m.Warn("last page %s without a first page", m.LastPage)
+		}
+		m.Pages = ""
+	case m.LastPage == "" || m.LastPage == m.FirstPage:
+		m.Pages = m.FirstPage
+	default:
+		m.Pages = m.FirstPage + "-" + m.LastPage
+	}
+	m.PageCount = pageCount(m.FirstPage, m.LastPage)
+}
+
+// pageCount returns how many pages first to last spans, 1 for a single
+// page, or 0 when that isn't known: without a first page, across a change of
+// prefix, or for an e-locator, which numbers the article rather than its
+// pages.
+func pageCount(first, last string) int {
+	if first == "" || strings.HasPrefix(strings.ToLower(first), "e") {
+		return 0
+	}
+	if last == "" {
+		return 1
+	}
+	if pagePrefix(first) != pagePrefix(last) {
+		return 0
+	}
+	return PageNumber(last) - PageNumber(first) + 1
+}
+
+// pagePrefix returns the letters before a page's number, as in "S12".
+func pagePrefix(page string) string {
+	return strings.ToUpper(page[:len(page)-len(strings.TrimLeft(page, pageLetters))])
+}
+
+// pageLetters are the letters a page's prefix may use.
+const pageLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// PageNumber returns the numeric part of a page, ignoring its prefix.
+func PageNumber(page string) int {
+	n, _ := strconv.Atoi(strings.TrimLeft(page, pageLetters))
+	return n
+}
@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"bytes"
 	"fmt"
+	"io"
+	neturl "net/url"
 	"regexp"
 	"slices"
 	"strconv"
@@ -10,18 +13,155 @@ import (
 	"github.com/PuerkitoBio/goquery"
 )
 
+// RulesVersion identifies the extraction rules implemented by this parser.
+// Bump it whenever a change alters the metadata produced for the same page.
+const RulesVersion = "18"
+
 type Parser struct {
 	verbose bool
+	site    Site
+	plugins []Plugin
+	// skip holds the names of disabled extractors
+	skip map[string]bool
+	// critical are the fields strict mode requires (nil when off)
+	critical []string
+	// rules are selector rules from a rules file, applied after the site's
+	// selectors
+	rules Rules
+	// englishPage fetches English pages to merge (nil when off)
+	englishPage EnglishPageFunc
+}
+
+// extractor fills fields either from the whole document (extract) or from
+// the text of one element at a time (scan). Scanners share a single walk
+// over the page's div, span and p elements, made where the first enabled
+// one sits in the table, rather than walking the page once each.
+type extractor struct {
+	name    string
+	extract func(*Parser, *goquery.Document, *PaperMetadata) error
+	scan    func(*Parser, string, *PaperMetadata)
+}
+
+// extractors run in this order; later ones may rely on fields set by
+// earlier ones (e.g. extractTitle only fills in what the meta tags lack).
+var extractors = []extractor{
+	{name: "canonical_url", extract: (*Parser).extractCanonicalURL},
+	{name: "meta_tags", extract: (*Parser).extractMetaTags},
+	{name: "title", extract: (*Parser).extractTitle},
+	{name: "authors", extract: (*Parser).extractAuthors},
+	{name: "journal", extract: (*Parser).extractJournalInfo},
+	{name: "doi", extract: (*Parser).extractDOI},
+	{name: "publication_details", scan: (*Parser).scanPublicationDetails},
+	{name: "abstract", extract: (*Parser).extractAbstract},
+	{name: "keywords", extract: (*Parser).extractKeywords},
+	{name: "metrics", scan: (*Parser).scanMetrics},
+	{name: "dates", scan: (*Parser).scanDates},
+	{name: "additional_info", scan: (*Parser).scanAdditionalInfo},
+	{name: "license_link", extract: (*Parser).extractLicenseLink},
+	{name: "graphical_abstract", extract: (*Parser).extractGraphicalAbstract},
+	{name: "figures", extract: (*Parser).extractFigures},
+	{name: "references", extract: (*Parser).extractReferences},
+	{name: "fulltext_length", extract: (*Parser).extractFullTextLength},
+	{name: "notice", extract: (*Parser).extractNotice},
+}
+
+// ExtractorNames lists the built-in extractors of the rhhz site parser in
+// the order they run.
+func ExtractorNames() []string {
+	names := make([]string, len(extractors))
+	for i, e := range extractors {
+		names[i] = e.name
+	}
+	return names
+}
+
+// Plugin is an external extractor that runs after the built-in extractors
+// and selector overrides, and may overwrite any field.
+type Plugin interface {
+	Name() string
+	Extract(html []byte, url string, metadata *PaperMetadata) error
+}
+
+// Site holds the journal-specific rules for the site being parsed.
+type Site struct {
+	// JournalCN and JournalEN are looked for in the page header to
+	// identify the journal
+	JournalCN string
+	JournalEN string
+
+	// Selectors override extraction of a field, keyed by its JSON name
+	Selectors map[string]string
+}
+
+// DefaultSite is 钢铁钒钛 (gtft.cn).
+var DefaultSite = Site{
+	JournalCN: "钢铁钒钛",
+	JournalEN: "IRON STEEL VANADIUM TITANIUM",
 }
 
 func NewParser(verbose bool) *Parser {
 	return &Parser{
 		verbose: verbose,
+		site:    DefaultSite,
 	}
 }
 
+// SetSite switches the parser to another journal's rules.
+func (p *Parser) SetSite(site Site) error {
+	for field := range site.Selectors {
+		if !IsField(field) {
+			return fmt.Errorf("no selector override for field %q", field)
+		}
+	}
+	p.site = site
+	return nil
+}
+
+// Site returns the journal rules the parser works with, for site parsers.
+func (p *Parser) Site() Site {
+	return p.site
+}
+
+// SkipExtractors disables the named built-in extractors, e.g. "metrics" and
+// "dates" for crawls that don't need views, downloads or dates, saving the
+// work they do. Fields they would fill stay empty.
+func (p *Parser) SkipExtractors(names []string) error {
+	skip := make(map[string]bool, len(names))
+	for _, name := range names {
+		if !slices.Contains(ExtractorNames(), name) {
+			return fmt.Errorf("unknown extractor %q (want one of %s)", name, strings.Join(ExtractorNames(), ", "))
+		}
+		skip[name] = true
+	}
+	p.skip = skip
+	return nil
+}
+
+// AddPlugin appends an extractor plugin. Plugins run in the order added.
+func (p *Parser) AddPlugin(plugin Plugin) {
+	p.plugins = append(p.plugins, plugin)
+}
+
 func (p *Parser) Parse(html []byte, url string) (*PaperMetadata, error) {
-	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(html)))
+	return p.parse(bytes.NewReader(html), func() []byte { return html }, url)
+}
+
+// ParseReader is Parse for a page read from r, such as a response body
+// being streamed, building the document without holding the page in
+// memory first. Only when plugins are added, which take the raw HTML, is a
+// copy kept for them.
+func (p *Parser) ParseReader(r io.Reader, url string) (*PaperMetadata, error) {
+	var raw bytes.Buffer
+	if len(p.plugins) > 0 {
+		r = io.TeeReader(r, &raw)
+	}
+	return p.parse(r, raw.Bytes, url)
+}
+
+// parse parses the page read from r; html returns its raw HTML for the
+// plugins once r has been read.
+func (p *Parser) parse(r io.Reader, html func() []byte, url string) (*PaperMetadata, error) {
+	doc, err := goquery.NewDocumentFromReader(r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse HTML: %w", err)
 	}
@@ -29,32 +169,105 @@ func (p *Parser) Parse(html []byte, url string) (*PaperMetadata, error) {
 	metadata := NewPaperMetadata(url)
 
 	// Extract article ID from URL
-	metadata.ID = extractIDFromURL(url)
+	metadata.ID = IDFromURL(url)
+	metadata.Language = detectLanguage(doc, url)
 
-	// Run all extractors
-	extractors := []func(*goquery.Document, *PaperMetadata) error{
-		p.extractMetaTags,
-		p.extractTitle,
-		p.extractAuthors,
-		p.extractJournalInfo,
-		p.extractPublicationDetails,
-		p.extractAbstract,
-		p.extractKeywords,
-		p.extractMetrics,
-		p.extractDates,
-		p.extractAdditionalInfo,
+	SiteParserFor(url).Extract(p, doc, metadata)
+
+	finishPages(metadata)
+	first, last, pages := metadata.FirstPage, metadata.LastPage, metadata.Pages
+
+	p.applySelectors(doc, metadata)
+	p.applyRules(doc, metadata)
+
+	for _, plugin := range p.plugins {
+		if err := plugin.Extract(html(), url, metadata); err != nil {
+			metadata.Warn("plugin %s: %v", plugin.Name(), err)
+		}
+	}
+
+	// A selector or plugin that set pages wins over the page fields
+	switch {
+	case metadata.Pages != pages:
+		metadata.setPageRange(metadata.Pages)
+		finishPages(metadata)
+	case metadata.FirstPage != first || metadata.LastPage != last:
+		finishPages(metadata)
 	}
 
-	for _, extractor := range extractors {
-		if err := extractor(doc, metadata); err != nil && p.verbose {
-			fmt.Printf("Warning in extractor: %v\n", err)
+	metadata.DOI = NormalizeDOI(metadata.DOI)
+	// A selector or plugin may have set the license to its URL
+	if metadata.LicenseURL == "" {
+		if id, url := licenseFromURL(metadata.License); id != "" {
+			metadata.License, metadata.LicenseURL = id, url
 		}
 	}
 
+	if p.englishPage != nil && !metadata.english() {
+		if err := p.mergeEnglish(metadata); err != nil {
+			metadata.Warn("English page not merged: %v", err)
+		}
+	}
+
+	checkQuality(metadata)
+	metadata.Completeness = Completeness(metadata)
+	if p.verbose {
+		for _, warning := range metadata.Warnings {
+			fmt.Printf("Warning for %s: %s\n", url, warning)
+		}
+	}
+
+	// Base the ID on the canonical URL so /cn/ and bare variants of the same
+	// article share one record
+	if id := canonicalID(metadata.CanonicalURL); id != "" {
+		metadata.ID = id
+	}
+
+	if missing := metadata.Missing(p.critical); len(missing) > 0 {
+		return nil, &IncompleteError{URL: url, Missing: missing, Metadata: metadata}
+	}
+
 	return metadata, nil
 }
 
+// extractCanonicalURL reads <link rel="canonical">, falling back to og:url.
+func (p *Parser) extractCanonicalURL(doc *goquery.Document, metadata *PaperMetadata) error {
+	href, ok := doc.Find("link[rel='canonical']").First().Attr("href")
+	if !ok || strings.TrimSpace(href) == "" {
+		href, ok = doc.Find("meta[property='og:url']").First().Attr("content")
+	}
+	href = strings.TrimSpace(href)
+	if !ok || href == "" {
+		return nil
+	}
+
+	canonical, err := resolveURL(metadata.URL, href)
+	if err != nil {
+		return fmt.Errorf("invalid canonical URL %q: %w", href, err)
+	}
+	metadata.CanonicalURL = canonical
+
+	return nil
+}
+
+// canonicalID returns the article ID for a canonical URL, or "" when it
+// doesn't point at an article page (e.g. a site-wide canonical homepage).
+func canonicalID(canonical string) string {
+	if canonical == "" {
+		return ""
+	}
+	u, err := neturl.Parse(canonical)
+	if err != nil || !strings.Contains(u.Path, "/article/") {
+		return ""
+	}
+	return IDFromURL(strings.TrimSuffix(u.Path, "/"))
+}
+
 func (p *Parser) extractMetaTags(doc *goquery.Document, metadata *PaperMetadata) error {
+	// Dublin Core creators are only used when there are no citation_
+	// authors, which sites fill more reliably
+	var creators []string
+
 	// Extract Dublin Core metadata
 	doc.Find("meta[name^='dc.']").Each(func(i int, s *goquery.Selection) {
 		name, _ := s.Attr("name")
@@ -62,20 +275,20 @@ func (p *Parser) extractMetaTags(doc *goquery.Document, metadata *PaperMetadata)
 
 		switch name {
 		case "dc.title":
-			metadata.TitleCN = content
+			metadata.setTitle(content)
 		case "dc.contributor", "dc.creator":
-			// These are handled in extractAuthors
+			creators = append(creators, splitAuthors(content)...)
 		case "dc.date":
 			metadata.Date = content
 		case "dc.keywords":
-			metadata.KeywordsCN = strings.Split(content, ", ")
+			metadata.setKeywords(strings.Split(content, ", "))
 		case "dc.description":
-			metadata.AbstractCN = content
+			metadata.setAbstract(content)
 		case "dc.source":
 			// Parse journal info from dc.source
 			p.parseJournalSource(content, metadata)
 		case "dc.publisher":
-			metadata.JournalCN = content
+			metadata.setJournal(content)
 		}
 	})
 
@@ -86,18 +299,14 @@ func (p *Parser) extractMetaTags(doc *goquery.Document, metadata *PaperMetadata)
 
 		switch name {
 		case "citation_title":
-			metadata.TitleCN = content
+			metadata.setTitle(content)
 		case "citation_authors":
-			// Parse comma-separated authors
-			authors := strings.Split(content, ", ")
-			for i, author := range authors {
-				metadata.Authors = append(metadata.Authors, Author{
-					Name:  strings.TrimSpace(author),
-					Order: i + 1,
-				})
-			}
+			metadata.addAuthors(splitAuthors(content)...)
+		case "citation_author":
+			// One author per tag, possibly written "Last, First"
+			metadata.addAuthors(cleanAuthorName(content))
 		case "citation_journal_title":
-			metadata.JournalCN = content
+			metadata.setJournal(content)
 		case "citation_journal_abbrev":
 			metadata.JournalAbbr = content
 		case "citation_issn":
@@ -111,26 +320,22 @@ func (p *Parser) extractMetaTags(doc *goquery.Document, metadata *PaperMetadata)
 		case "citation_issue":
 			metadata.Issue = content
 		case "citation_firstpage":
-			if metadata.Pages == "" {
-				metadata.Pages = content
-			} else {
-				metadata.Pages = content + "-" + strings.Split(metadata.Pages, "-")[1]
-			}
+			metadata.FirstPage = content
 		case "citation_lastpage":
-			if metadata.Pages == "" {
-				metadata.Pages = "-" + content
-			} else {
-				metadata.Pages = strings.Split(metadata.Pages, "-")[0] + "-" + content
-			}
+			metadata.LastPage = content
 		case "citation_doi":
 			metadata.DOI = content
 		case "citation_keywords":
-			metadata.KeywordsCN = strings.Split(content, ", ")
+			metadata.setKeywords(strings.Split(content, ", "))
 		case "citation_pdf_url":
 			metadata.PDFURL = content
 		}
 	})
 
+	if len(metadata.Authors) == 0 {
+		metadata.addAuthors(creators...)
+	}
+
 	return nil
 }
 
@@ -138,7 +343,7 @@ func (p *Parser) parseJournalSource(source string, metadata *PaperMetadata) {
 	// Parse format like: "钢铁钒钛, 2003, Vol. 24, Issue 4, Pages: 1-5"
 	parts := strings.Split(source, ", ")
 	if len(parts) >= 1 {
-		metadata.JournalCN = parts[0]
+		metadata.setJournal(parts[0])
 	}
 	if len(parts) >= 2 {
 		metadata.Year = parts[1]
@@ -158,11 +363,33 @@ func (p *Parser) parseJournalSource(source string, metadata *PaperMetadata) {
 	// Parse pages
 	re = regexp.MustCompile(`Pages:\s*(\d+-\d+)`)
 	if matches := re.FindStringSubmatch(source); len(matches) > 1 {
-		metadata.Pages = matches[1]
+		metadata.setPageRange(matches[1])
 	}
 }
 
+// englishTitleSelectors find the English title shown under the Chinese one
+// on Chinese article pages.
+var englishTitleSelectors = []string{
+	".article-title-en", ".title-en", ".en-title", ".article-entitle",
+	"h1[lang='en']", "h2[lang='en']", ".article-title[lang='en']",
+	".article-title + .article-title", "h1 + h2",
+}
+
 func (p *Parser) extractTitle(doc *goquery.Document, metadata *PaperMetadata) error {
+	if metadata.TitleEN == "" {
+		for _, selector := range englishTitleSelectors {
+			title := strings.Join(strings.Fields(doc.Find(selector).First().Text()), " ")
+			if latinScript(title) {
+				metadata.TitleEN = title
+				break
+			}
+		}
+	}
+
+	if (metadata.english() && metadata.TitleEN != "") || (!metadata.english() && metadata.TitleCN != "") {
+		return nil
+	}
+
 	// Try to get title from various selectors
 	selectors := []string{
 		"h1", "h2", ".article-title", ".title", "title",
@@ -170,9 +397,18 @@ func (p *Parser) extractTitle(doc *goquery.Document, metadata *PaperMetadata) er
 	}
 
 	for _, selector := range selectors {
-		title := doc.Find(selector).First().Text()
-		if title != "" && metadata.TitleCN == "" {
-			metadata.TitleCN = strings.TrimSpace(title)
+		title := strings.TrimSpace(doc.Find(selector).First().Text())
+		if title == "" {
+			continue
+		}
+		cn, en := metadata.TitleCN, metadata.TitleEN
+		metadata.setTitle(title)
+		field := "title_cn"
+		if metadata.TitleEN != en {
+			field = "title_en"
+		}
+		if metadata.TitleCN != cn || metadata.TitleEN != en {
+			metadata.Warn("%s taken from fallback selector %q", field, selector)
 			break
 		}
 	}
@@ -196,19 +432,16 @@ func (p *Parser) extractAuthors(doc *goquery.Document, metadata *PaperMetadata)
 		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
 			s.Find("li, span, a").Each(func(j int, authorSel *goquery.Selection) {
 				authorText := strings.TrimSpace(authorSel.Text())
-				if authorText != "" && !strings.Contains(authorText, "@") {
-					// Clean up author name (remove numbers, punctuation)
-					authorText = cleanAuthorName(authorText)
-
-					metadata.Authors = append(metadata.Authors, Author{
-						Name:  authorText,
-						Order: len(metadata.Authors) + 1,
-					})
+				// Author blocks often carry emails and affiliations too
+				if authorText == "" || strings.Contains(authorText, "@") || looksLikeAffiliation(authorText) {
+					return
 				}
+				metadata.addAuthors(splitAuthors(authorText)...)
 			})
 		})
 
 		if len(metadata.Authors) > 0 {
+			metadata.Warn("authors taken from fallback selector %q", selector)
 			break
 		}
 	}
@@ -216,71 +449,130 @@ func (p *Parser) extractAuthors(doc *goquery.Document, metadata *PaperMetadata)
 	return nil
 }
 
+// leadingNumberPattern matches list numbers before a name, like "1, ".
+var leadingNumberPattern = regexp.MustCompile(`^\d+[\.,]?\s*`)
+
 func cleanAuthorName(name string) string {
 	// Remove numbers, punctuation, and extra whitespace
-	name = strings.TrimSpace(name)
+	name = strings.Join(strings.Fields(name), " ")
 
-	// Remove trailing commas, periods, etc.
+	// Remove trailing affiliation markers, commas, periods, etc.
+	name = authorMarkerPattern.ReplaceAllString(name, "")
 	name = strings.TrimRight(name, ",.& ")
 
 	// Remove affiliation numbers like "1,", "2,", etc.
-	re := regexp.MustCompile(`^\d+[\.,]?\s*`)
-	name = re.ReplaceAllString(name, "")
+	name = leadingNumberPattern.ReplaceAllString(name, "")
 
 	return name
 }
 
+// extractJournalInfo fills the journal names the meta tags left empty from
+// the site's own names, when the meta tags or the page header show the page
+// belongs to the site's journal, or failing any journal name on the page at
+// all, since the site's pages are its journal's.
 func (p *Parser) extractJournalInfo(doc *goquery.Document, metadata *PaperMetadata) error {
-	// Try to find journal info in navigation or headers
+	if metadata.JournalCN != "" && metadata.JournalEN != "" {
+		return nil
+	}
+	onPage := metadata.JournalCN != "" || metadata.JournalEN != ""
+	if p.site.JournalCN == "" && p.site.JournalEN == "" {
+		if !onPage {
+			return fmt.Errorf("no journal name in the meta tags and none configured for the site")
+		}
+		return nil
+	}
+
+	ours := p.site.namesJournal(metadata.JournalCN) || p.site.namesJournal(metadata.JournalEN)
 	selectors := []string{
 		".journal-name", ".journal-title", ".publication-title",
 		"nav a", ".breadcrumb a",
 	}
-
 	for _, selector := range selectors {
-		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
-			text := strings.TrimSpace(s.Text())
-			if strings.Contains(text, "钢铁钒钛") || strings.Contains(text, "IRON STEEL VANADIUM TITANIUM") {
-				metadata.JournalCN = "钢铁钒钛"
-				metadata.JournalEN = "IRON STEEL VANADIUM TITANIUM"
-			}
-		})
-
-		if metadata.JournalCN != "" {
+		if ours {
 			break
 		}
+		doc.Find(selector).EachWithBreak(func(i int, s *goquery.Selection) bool {
+			ours = p.site.namesJournal(s.Text())
+			return !ours
+		})
+	}
+
+	// A journal named on the page that isn't the site's is left alone
+	if onPage && !ours {
+		return nil
+	}
+	if metadata.JournalCN == "" {
+		metadata.JournalCN = p.site.JournalCN
+	}
+	if metadata.JournalEN == "" {
+		metadata.JournalEN = p.site.JournalEN
+	}
+	if !onPage && !ours {
+		metadata.Warn("journal taken from the site profile")
 	}
 
 	return nil
 }
 
-func (p *Parser) extractPublicationDetails(doc *goquery.Document, metadata *PaperMetadata) error {
-	// Look for publication details in the page
-	doc.Find("div, span, p").Each(func(i int, s *goquery.Selection) {
-		text := s.Text()
+// namesJournal reports whether text mentions the site's journal by either
+// of its names.
+func (s Site) namesJournal(text string) bool {
+	text = strings.ToUpper(text)
+	for _, name := range []string{s.JournalCN, s.JournalEN} {
+		if name != "" && strings.Contains(text, strings.ToUpper(name)) {
+			return true
+		}
+	}
+	return false
+}
 
-		// Look for volume/issue pattern
-		re := regexp.MustCompile(`(\d+)\((\d+)\):\s*(\d+-\d+)`)
-		if matches := re.FindStringSubmatch(text); len(matches) > 3 {
-			if metadata.Volume == "" {
-				metadata.Volume = matches[1]
-			}
-			if metadata.Issue == "" {
-				metadata.Issue = matches[2]
-			}
-			if metadata.Pages == "" {
-				metadata.Pages = matches[3]
-			}
+// Patterns the text scanners look for, compiled once rather than per element.
+var (
+	volumeIssuePattern = regexp.MustCompile(`(\d+)\((\d+)\):\s*(\d+-\d+)`)
+	yearPattern        = regexp.MustCompile(`\b(19|20)\d{2}\b`)
+	countPattern       = regexp.MustCompile(`\d+`)
+	datePattern        = regexp.MustCompile(`\d{4}-\d{2}-\d{2}`)
+	clcPattern         = regexp.MustCompile(`[A-Z]+\d+(\.\d+)?`)
+)
+
+// scanText runs every enabled scanner over the text of each div, span and
+// p element, in document order.
+func (p *Parser) scanText(doc *goquery.Document, metadata *PaperMetadata) {
+	var scanners []func(*Parser, string, *PaperMetadata)
+	for _, e := range extractors {
+		if e.scan != nil && !p.skip[e.name] {
+			scanners = append(scanners, e.scan)
 		}
+	}
 
-		// Look for year
-		re = regexp.MustCompile(`\b(19|20)\d{2}\b`)
-		if matches := re.FindStringSubmatch(text); len(matches) > 0 && metadata.Year == "" {
-			metadata.Year = matches[0]
+	doc.Find("div, span, p").Each(func(i int, s *goquery.Selection) {
+		text := s.Text()
+		for _, scan := range scanners {
+			scan(p, text, metadata)
 		}
 	})
+}
 
-	return nil
+// scanPublicationDetails looks for "volume(issue): pages" and a year.
+func (p *Parser) scanPublicationDetails(text string, metadata *PaperMetadata) {
+	if matches := volumeIssuePattern.FindStringSubmatch(text); len(matches) > 3 {
+		if metadata.Volume == "" {
+			metadata.Volume = matches[1]
+		}
+		if metadata.Issue == "" {
+			metadata.Issue = matches[2]
+		}
+		if metadata.FirstPage == "" {
+			metadata.setPageRange(matches[3])
+		}
+	}
+
+	if metadata.Year == "" {
+		if year := yearPattern.FindString(text); year != "" {
+			metadata.Year = year
+			metadata.Warn("year guessed from page text")
+		}
+	}
 }
 
 func (p *Parser) extractAbstract(doc *goquery.Document, metadata *PaperMetadata) error {
@@ -295,7 +587,8 @@ func (p *Parser) extractAbstract(doc *goquery.Document, metadata *PaperMetadata)
 		doc.Find(selector).Each(func(i int, s *goquery.Selection) {
 			text := strings.TrimSpace(s.Text())
 
-			if strings.Contains(text, "摘要") || strings.Contains(selector, "abstract") {
+			// Unlabelled abstract blocks are in the page's language
+			if strings.Contains(text, "摘要") || (strings.Contains(selector, "abstract") && !metadata.english()) {
 				// Clean the abstract text
 				text = strings.TrimPrefix(text, "摘要:")
 				text = strings.TrimPrefix(text, "摘要：")
@@ -371,111 +664,141 @@ func (p *Parser) extractKeywords(doc *goquery.Document, metadata *PaperMetadata)
 	return nil
 }
 
-func (p *Parser) extractMetrics(doc *goquery.Document, metadata *PaperMetadata) error {
-	// Look for metrics like views, downloads, citations
-	doc.Find("div, span, p").Each(func(i int, s *goquery.Selection) {
-		text := s.Text()
-
-		// Look for views count
-		if strings.Contains(text, "文章访问数") || strings.Contains(text, "访问数") {
-			re := regexp.MustCompile(`(\d+)`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 1 {
-				if views, err := strconv.Atoi(matches[1]); err == nil {
-					metadata.Views = views
-				}
-			}
+// scanMetrics looks for view, download and citation counts.
+func (p *Parser) scanMetrics(text string, metadata *PaperMetadata) {
+	if strings.Contains(text, "文章访问数") || strings.Contains(text, "访问数") {
+		if views, ok := firstCount(text); ok {
+			metadata.Views = views
 		}
+	}
 
-		// Look for download count
-		if strings.Contains(text, "PDF下载量") || strings.Contains(text, "下载") {
-			re := regexp.MustCompile(`(\d+)`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 1 {
-				if downloads, err := strconv.Atoi(matches[1]); err == nil {
-					metadata.Downloads = downloads
-				}
-			}
+	if strings.Contains(text, "PDF下载量") || strings.Contains(text, "下载") {
+		if downloads, ok := firstCount(text); ok {
+			metadata.Downloads = downloads
 		}
+	}
 
-		// Look for citation count
-		if strings.Contains(text, "被引次数") || strings.Contains(text, "引用") {
-			re := regexp.MustCompile(`(\d+)`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 1 {
-				if citations, err := strconv.Atoi(matches[1]); err == nil {
-					metadata.Citations = citations
-				}
-			}
+	if strings.Contains(text, "被引次数") || strings.Contains(text, "引用") {
+		if citations, ok := firstCount(text); ok {
+			metadata.Citations = citations
 		}
-	})
+	}
+}
 
-	return nil
+// firstCount returns the first number in text.
+func firstCount(text string) (int, bool) {
+	n, err := strconv.Atoi(countPattern.FindString(text))
+	return n, err == nil
 }
 
-func (p *Parser) extractDates(doc *goquery.Document, metadata *PaperMetadata) error {
-	// Look for date information
-	doc.Find("div, span, p").Each(func(i int, s *goquery.Selection) {
-		text := s.Text()
+// scanDates looks for the submission, online and publication dates.
+func (p *Parser) scanDates(text string, metadata *PaperMetadata) {
+	if strings.Contains(text, "收稿日期") && metadata.SubmitDate == "" {
+		metadata.SubmitDate = datePattern.FindString(text)
+	}
 
-		// Look for submission date
-		if strings.Contains(text, "收稿日期") {
-			re := regexp.MustCompile(`(\d{4}-\d{2}-\d{2})`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 1 && metadata.SubmitDate == "" {
-				metadata.SubmitDate = matches[1]
-			}
-		}
+	if strings.Contains(text, "网络出版日期") && metadata.OnlineDate == "" {
+		metadata.OnlineDate = datePattern.FindString(text)
+	}
 
-		// Look for online date
-		if strings.Contains(text, "网络出版日期") {
-			re := regexp.MustCompile(`(\d{4}-\d{2}-\d{2})`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 1 && metadata.OnlineDate == "" {
-				metadata.OnlineDate = matches[1]
-			}
+	if (strings.Contains(text, "刊出日期") || strings.Contains(text, "出版日期")) && metadata.Date == "" {
+		metadata.Date = datePattern.FindString(text)
+	}
+}
+
+// scanAdditionalInfo looks for the fund project, CLC code and license.
+func (p *Parser) scanAdditionalInfo(text string, metadata *PaperMetadata) {
+	if strings.Contains(text, "基金项目") && metadata.FundProject == "" {
+		text := strings.TrimPrefix(text, "基金项目:")
+		text = strings.TrimPrefix(text, "基金项目：")
+		metadata.FundProject = strings.TrimSpace(text)
+	}
+
+	if strings.Contains(text, "中图分类号") && metadata.CLCCode == "" {
+		metadata.CLCCode = clcPattern.FindString(text)
+	}
+
+	if metadata.License == "" {
+		metadata.setLicense(text)
+	}
+}
+
+func (p *Parser) extractGraphicalAbstract(doc *goquery.Document, metadata *PaperMetadata) error {
+	// Lead image shown with the abstract, most specific selectors first
+	selectors := []string{
+		".graphical-abstract img", ".graphic-abstract img", "#graphicalAbstract img",
+		".article-abstract img", "div[class*='abstract'] img",
+	}
+
+	for _, selector := range selectors {
+		src, ok := doc.Find(selector).First().Attr("src")
+		if !ok || strings.TrimSpace(src) == "" {
+			continue
 		}
 
-		// Look for publication date
-		if strings.Contains(text, "刊出日期") || strings.Contains(text, "出版日期") {
-			re := regexp.MustCompile(`(\d{4}-\d{2}-\d{2})`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 1 && metadata.Date == "" {
-				metadata.Date = matches[1]
-			}
+		imageURL, err := resolveURL(metadata.URL, strings.TrimSpace(src))
+		if err != nil {
+			return fmt.Errorf("invalid graphical abstract URL %q: %w", src, err)
 		}
-	})
+		metadata.GraphicalAbstractURL = imageURL
+		break
+	}
 
 	return nil
 }
 
-func (p *Parser) extractAdditionalInfo(doc *goquery.Document, metadata *PaperMetadata) error {
-	// Look for additional information
-	doc.Find("div, span, p").Each(func(i int, s *goquery.Selection) {
-		text := s.Text()
+var figureLabelPattern = regexp.MustCompile(`^\s*((?:图|Fig\.?|Figure)\s*\d+[a-z]?)`)
+
+func (p *Parser) extractFigures(doc *goquery.Document, metadata *PaperMetadata) error {
+	seen := make(map[string]bool)
 
-		// Look for fund project
-		if strings.Contains(text, "基金项目") && metadata.FundProject == "" {
-			text = strings.TrimPrefix(text, "基金项目:")
-			text = strings.TrimPrefix(text, "基金项目：")
-			metadata.FundProject = strings.TrimSpace(text)
+	doc.Find("figure, div.figure, div.fig, div[class*='figure-box']").Each(func(i int, s *goquery.Selection) {
+		img := s.Find("img").First()
+		src, ok := img.Attr("data-src")
+		if !ok || strings.TrimSpace(src) == "" {
+			src, ok = img.Attr("src")
+		}
+		src = strings.TrimSpace(src)
+		if !ok || src == "" {
+			return
 		}
 
-		// Look for CLC code
-		if strings.Contains(text, "中图分类号") && metadata.CLCCode == "" {
-			re := regexp.MustCompile(`[A-Z]+\d+(\.\d+)?`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 0 {
-				metadata.CLCCode = matches[0]
-			}
+		imageURL, err := resolveURL(metadata.URL, src)
+		if err != nil || seen[imageURL] {
+			return
 		}
+		seen[imageURL] = true
 
-		// Look for license
-		if strings.Contains(text, "creativecommons.org") && metadata.License == "" {
-			re := regexp.MustCompile(`https?://[^\s]+`)
-			if matches := re.FindStringSubmatch(text); len(matches) > 0 {
-				metadata.License = matches[0]
-			}
+		caption := strings.Join(strings.Fields(s.Find("figcaption, .figure-caption, .fig-caption, .caption").First().Text()), " ")
+		figure := Figure{
+			Caption:  caption,
+			ImageURL: imageURL,
 		}
+		if matches := figureLabelPattern.FindStringSubmatch(caption); len(matches) > 1 {
+			figure.Label = matches[1]
+		}
+
+		metadata.Figures = append(metadata.Figures, figure)
 	})
 
 	return nil
 }
 
-func extractIDFromURL(url string) string {
+// resolveURL resolves a possibly relative reference against the page URL.
+func resolveURL(base, ref string) (string, error) {
+	baseURL, err := neturl.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	refURL, err := neturl.Parse(ref)
+	if err != nil {
+		return "", err
+	}
+	return baseURL.ResolveReference(refURL).String(), nil
+}
+
+// IDFromURL returns the record ID for an article URL: its last path segment.
+func IDFromURL(url string) string {
 	// Extract UUID from URL
 	parts := strings.Split(url, "/")
 	if len(parts) > 0 {
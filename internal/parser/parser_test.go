@@ -0,0 +1,841 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var update = flag.Bool("update", false, "Rewrite the fixtures' JSON from the current parser")
+
+// TestFixtures parses every page captured by `fixture add` and compares the
+// record with the JSON captured alongside it. After an intended change to
+// the parser's output, run with -update and review the diff.
+func TestFixtures(t *testing.T) {
+	pages, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*.html"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) == 0 {
+		t.Fatal("no fixtures in testdata/fixtures")
+	}
+
+	for _, page := range pages {
+		name := strings.TrimSuffix(filepath.Base(page), ".html")
+		t.Run(name, func(t *testing.T) {
+			jsonPath := strings.TrimSuffix(page, ".html") + ".json"
+			want, err := os.ReadFile(jsonPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var captured PaperMetadata
+			if err := json.Unmarshal(want, &captured); err != nil {
+				t.Fatalf("invalid fixture JSON: %v", err)
+			}
+
+			html, err := os.ReadFile(page)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := encodeFixture(t, parseFixture(t, html, captured.URL))
+
+			if *update {
+				if err := os.WriteFile(jsonPath, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("record differs from %s:\ngot:\n%s\nwant:\n%s", jsonPath, got, want)
+			}
+
+			// A page streamed in small reads parses the same
+			streamed, err := NewParser(false).ParseReader(iotest.OneByteReader(bytes.NewReader(html)), captured.URL)
+			if err != nil {
+				t.Fatalf("ParseReader: %v", err)
+			}
+			streamed.ParsedAt = ""
+			if got := encodeFixture(t, streamed); !bytes.Equal(got, want) {
+				t.Errorf("ParseReader record differs from %s:\ngot:\n%s", jsonPath, got)
+			}
+		})
+	}
+}
+
+// parseFixture parses a captured page the way `fixture add` does.
+func parseFixture(t *testing.T, html []byte, url string) *PaperMetadata {
+	t.Helper()
+
+	metadata, err := NewParser(false).Parse(html, url)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	metadata.ParsedAt = ""
+	return metadata
+}
+
+// encodeFixture encodes a record as `fixture add` writes it.
+func encodeFixture(t *testing.T, metadata *PaperMetadata) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(metadata); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// FuzzParse checks that Parse never panics, whatever the input. It is
+// seeded with the fixture pages, whole and cut off partway as by a dropped
+// connection; run it with go test -fuzz FuzzParse ./internal/parser.
+func FuzzParse(f *testing.F) {
+	pages, err := filepath.Glob(filepath.Join("testdata", "fixtures", "*.html"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, page := range pages {
+		html, err := os.ReadFile(page)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(html)
+		f.Add(html[:len(html)/2])
+		f.Add(html[:len(html)/5])
+	}
+
+	f.Fuzz(func(t *testing.T, html []byte) {
+		metadata, err := NewParser(false).Parse(html, "https://www.gtft.cn/cn/article/id/fuzz")
+		if err != nil {
+			return
+		}
+		metadata.Validate()
+	})
+}
+
+func TestPageRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		pages       string
+		first, last string
+		want        string
+		count       int
+		warnings    int
+	}{
+		{name: "range", pages: "1-5", first: "1", last: "5", want: "1-5", count: 5},
+		{name: "spaced range", pages: " 43 - 49 ", first: "43", last: "49", want: "43-49", count: 7},
+		{name: "single page", pages: "12", first: "12", want: "12", count: 1},
+		{name: "same first and last", pages: "7-7", first: "7", last: "7", want: "7", count: 1},
+		{name: "article number", pages: "e1023", first: "e1023", want: "e1023"},
+		{name: "supplement range", pages: "S12-S18", first: "S12", last: "S18", want: "S12-S18", count: 7},
+		{name: "prefix change", pages: "S12-18", first: "S12", last: "18", want: "S12-18"},
+		{name: "en dash", pages: "101–108", first: "101", last: "108", want: "101-108", count: 8},
+		{name: "em dash", pages: "101—108", first: "101", last: "108", want: "101-108", count: 8},
+		{name: "full-width hyphen", pages: "101－108", first: "101", last: "108", want: "101-108", count: 8},
+		{name: "full-width tilde", pages: "101～108", first: "101", last: "108", want: "101-108", count: 8},
+		{name: "last before first", pages: "9-3", first: "9", want: "9", count: 1, warnings: 1},
+		{name: "not a page", pages: "pp. 1 to 5", want: "", warnings: 1},
+		{name: "empty", pages: "", want: ""},
+		{name: "blank", pages: "  ", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &PaperMetadata{}
+			m.setPageRange(tt.pages)
+			finishPages(m)
+
+			if m.FirstPage != tt.first || m.LastPage != tt.last || m.Pages != tt.want {
+				t.Errorf("setPageRange(%q) = first %q, last %q, pages %q; want %q, %q, %q",
+					tt.pages, m.FirstPage, m.LastPage, m.Pages, tt.first, tt.last, tt.want)
+			}
+			if m.PageCount != tt.count {
+				t.Errorf("setPageRange(%q) page count = %d, want %d", tt.pages, m.PageCount, tt.count)
+			}
+			if len(m.Warnings) != tt.warnings {
+				t.Errorf("setPageRange(%q) warnings = %q, want %d", tt.pages, m.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+func TestFinishPages(t *testing.T) {
+	tests := []struct {
+		name        string
+		first, last string
+		wantFirst   string
+		wantLast    string
+		want        string
+		warnings    int
+	}{
+		{name: "both", first: "1", last: "5", wantFirst: "1", wantLast: "5", want: "1-5"},
+		{name: "first only", first: "12", wantFirst: "12", want: "12"},
+		{name: "last only", last: "5", wantLast: "5", want: "", warnings: 1},
+		{name: "invalid first", first: "abc", last: "5", wantLast: "5", want: "", warnings: 2},
+		{name: "invalid last", first: "1", last: "5a5", wantFirst: "1", want: "1", warnings: 1},
+		{name: "prefixed pages compare by number", first: "S9", last: "S10", wantFirst: "S9", wantLast: "S10", want: "S9-S10"},
+		{name: "empty", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &PaperMetadata{FirstPage: tt.first, LastPage: tt.last}
+			finishPages(m)
+
+			if m.FirstPage != tt.wantFirst || m.LastPage != tt.wantLast || m.Pages != tt.want {
+				t.Errorf("finishPages(%q, %q) = %q, %q, pages %q; want %q, %q, %q",
+					tt.first, tt.last, m.FirstPage, m.LastPage, m.Pages, tt.wantFirst, tt.wantLast, tt.want)
+			}
+			if len(m.Warnings) != tt.warnings {
+				t.Errorf("finishPages(%q, %q) warnings = %q, want %d", tt.first, tt.last, m.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+// scannedFields are the fields the text scanners fill, or may fill when the
+// meta tags lack them.
+type scannedFields struct {
+	Volume      string `json:"volume"`
+	Issue       string `json:"issue"`
+	Pages       string `json:"pages"`
+	Year        string `json:"year"`
+	Date        string `json:"date"`
+	OnlineDate  string `json:"online_date,omitempty"`
+	SubmitDate  string `json:"submit_date,omitempty"`
+	Views       int    `json:"views"`
+	Downloads   int    `json:"downloads"`
+	Citations   int    `json:"citations"`
+	FundProject string `json:"fund_project,omitempty"`
+	CLCCode     string `json:"clc_code,omitempty"`
+	License     string `json:"license,omitempty"`
+	LicenseURL  string `json:"license_url,omitempty"`
+}
+
+// TestScanTextGolden checks the single shared walk of scanText against
+// testdata/scan, which holds the scanned fields of each fixture page as the
+// parser produced them when every scanner still walked the page on its own
+// (the license since normalized to an SPDX identifier).
+func TestScanTextGolden(t *testing.T) {
+	goldens, err := filepath.Glob(filepath.Join("testdata", "scan", "*.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(goldens) == 0 {
+		t.Fatal("no golden files in testdata/scan")
+	}
+
+	for _, golden := range goldens {
+		name := strings.TrimSuffix(filepath.Base(golden), ".json")
+		t.Run(name, func(t *testing.T) {
+			var want scannedFields
+			data, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, &want); err != nil {
+				t.Fatalf("invalid golden file: %v", err)
+			}
+
+			fixture := filepath.Join("testdata", "fixtures", name)
+			var captured PaperMetadata
+			data, err = os.ReadFile(fixture + ".json")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := json.Unmarshal(data, &captured); err != nil {
+				t.Fatalf("invalid fixture JSON: %v", err)
+			}
+			html, err := os.ReadFile(fixture + ".html")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			m := parseFixture(t, html, captured.URL)
+			got := scannedFields{
+				Volume: m.Volume, Issue: m.Issue, Pages: m.Pages, Year: m.Year,
+				Date: m.Date, OnlineDate: m.OnlineDate, SubmitDate: m.SubmitDate,
+				Views: m.Views, Downloads: m.Downloads, Citations: m.Citations,
+				FundProject: m.FundProject, CLCCode: m.CLCCode, License: m.License,
+				LicenseURL: m.LicenseURL,
+			}
+			if got != want {
+				t.Errorf("scanned fields differ from %s:\ngot  %+v\nwant %+v", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestJournalNames(t *testing.T) {
+	other := Site{JournalCN: "示例学报", JournalEN: "Journal of Examples"}
+	tests := []struct {
+		name     string
+		site     Site
+		html     string
+		cn, en   string
+		warnings []string
+	}{
+		{
+			name: "meta tag completed from the site",
+			site: DefaultSite,
+			html: `<meta name="citation_journal_title" content="钢铁钒钛">`,
+			cn:   "钢铁钒钛", en: "IRON STEEL VANADIUM TITANIUM",
+		},
+		{
+			name: "English meta tag on a Chinese page",
+			site: DefaultSite,
+			html: `<meta name="citation_journal_title" content="Iron Steel Vanadium Titanium">`,
+			cn:   "钢铁钒钛", en: "Iron Steel Vanadium Titanium",
+		},
+		{
+			name: "publisher",
+			site: other,
+			html: `<meta name="dc.publisher" content="示例学报">`,
+			cn:   "示例学报", en: "Journal of Examples",
+		},
+		{
+			name: "header",
+			site: other,
+			html: `<ol class="breadcrumb"><li><a href="/">示例学报</a></li></ol>`,
+			cn:   "示例学报", en: "Journal of Examples",
+		},
+		{
+			name: "another journal is left alone",
+			site: other,
+			html: `<meta name="citation_journal_title" content="钢铁钒钛">`,
+			cn:   "钢铁钒钛",
+		},
+		{
+			name:     "nothing on the page",
+			site:     other,
+			html:     `<p>no journal here</p>`,
+			cn:       "示例学报",
+			en:       "Journal of Examples",
+			warnings: []string{"journal taken from the site profile"},
+		},
+		{
+			name:     "nothing anywhere",
+			site:     Site{},
+			html:     `<p>no journal here</p>`,
+			warnings: []string{"no journal name in the meta tags and none configured for the site"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := NewParser(false)
+			if err := p.SetSite(tt.site); err != nil {
+				t.Fatal(err)
+			}
+			html := "<html><head>" + tt.html + "</head><body></body></html>"
+			m, err := p.Parse([]byte(html), "https://www.example.cn/cn/article/id/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if m.JournalCN != tt.cn || m.JournalEN != tt.en {
+				t.Errorf("journal = %q, %q; want %q, %q", m.JournalCN, m.JournalEN, tt.cn, tt.en)
+			}
+			for _, warning := range tt.warnings {
+				if !slices.Contains(m.Warnings, warning) {
+					t.Errorf("warnings %q lack %q", m.Warnings, warning)
+				}
+			}
+		})
+	}
+}
+
+func TestSplitAuthors(t *testing.T) {
+	tests := []struct {
+		list string
+		want []string
+	}{
+		{"宋立秋, 张伟, 李明", []string{"宋立秋", "张伟", "李明"}},
+		{"宋立秋,张伟", []string{"宋立秋", "张伟"}},
+		{"宋立秋；张伟; 李明", []string{"宋立秋", "张伟", "李明"}},
+		{"宋立秋、张伟、李明", []string{"宋立秋", "张伟", "李明"}},
+		{"宋立秋，张伟", []string{"宋立秋", "张伟"}},
+		{"WANG Hui, CHEN Lin and LI Na", []string{"WANG Hui", "CHEN Lin", "LI Na"}},
+		{"WANG Hui & CHEN Lin", []string{"WANG Hui", "CHEN Lin"}},
+		{"Alexander Anderson", []string{"Alexander Anderson"}},
+		{"张伟1,2*, 李明2", []string{"张伟", "李明"}},
+		{"  ", nil},
+	}
+
+	for _, tt := range tests {
+		if got := splitAuthors(tt.list); !slices.Equal(got, tt.want) {
+			t.Errorf("splitAuthors(%q) = %q, want %q", tt.list, got, tt.want)
+		}
+	}
+}
+
+func TestAuthors(t *testing.T) {
+	tests := []struct {
+		name     string
+		html     string
+		want     []string
+		warnings []string
+	}{
+		{
+			name: "duplicates across tags",
+			html: `<meta name="citation_authors" content="宋立秋、张伟"><meta name="citation_author" content="张伟">`,
+			want: []string{"宋立秋", "张伟"},
+		},
+		{
+			name: "Dublin Core fallback",
+			html: `<meta name="dc.creator" content="宋立秋"><meta name="dc.creator" content="张伟">`,
+			want: []string{"宋立秋", "张伟"},
+		},
+		{
+			name: "body skips affiliations",
+			html: `</head><body><ul class="article-author"><li>宋立秋1</li><li>张伟2</li><li>攀枝花钢铁研究院，四川 攀枝花 617000</li></ul>`,
+			want: []string{"宋立秋", "张伟"},
+		},
+		{
+			name:     "affiliation in the meta tags",
+			html:     `<meta name="citation_authors" content="宋立秋; 攀钢集团研究院">`,
+			want:     []string{"宋立秋", "攀钢集团研究院"},
+			warnings: []string{`ambiguous author name "攀钢集团研究院": looks like an affiliation`},
+		},
+		{
+			name:     "too many authors",
+			html:     `<meta name="citation_authors" content="` + authorList(31) + `">`,
+			want:     strings.Split(authorList(31), ", "),
+			warnings: []string{"implausible author list: 31 authors"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			html := "<html><head>" + tt.html + "</body></html>"
+			m, err := NewParser(false).Parse([]byte(html), "https://www.gtft.cn/cn/article/id/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got []string
+			for i, author := range m.Authors {
+				got = append(got, author.Name)
+				if author.Order != i+1 {
+					t.Errorf("author %q order = %d, want %d", author.Name, author.Order, i+1)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("authors = %q, want %q", got, tt.want)
+			}
+			for _, warning := range tt.warnings {
+				if !slices.Contains(m.Warnings, warning) {
+					t.Errorf("warnings %q lack %q", m.Warnings, warning)
+				}
+			}
+		})
+	}
+}
+
+// authorList returns n distinct author names joined by commas.
+func authorList(n int) string {
+	names := make([]string, n)
+	for i := range names {
+		names[i] = "Author " + string(rune('A'+i%26)) + strings.Repeat("x", i/26)
+	}
+	return strings.Join(names, ", ")
+}
+
+func TestNormalizeDOI(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"10.7513/j.issn.1004-7638.2003.04.001", "10.7513/j.issn.1004-7638.2003.04.001"},
+		{" https://doi.org/10.7513/J.ISSN.1004-7638.2003.04.001 ", "10.7513/j.issn.1004-7638.2003.04.001"},
+		{"http://dx.doi.org/10.1000/ABC", "10.1000/abc"},
+		{"DOI:10.1000/abc", "10.1000/abc"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := NormalizeDOI(tt.in); got != tt.want {
+			t.Errorf("NormalizeDOI(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestDOIFromBody(t *testing.T) {
+	references := `<div class="references"><ol><li>Wang. Titanium[J]. doi: 10.1000/cited.1</li></ol></div>`
+	tests := []struct {
+		name string
+		html string
+		want string
+	}{
+		{
+			name: "meta tag wins",
+			html: `<head><meta name="citation_doi" content="https://doi.org/10.7513/META"></head><body><div class="article-doi">doi: 10.7513/body</div></body>`,
+			want: "10.7513/meta",
+		},
+		{
+			name: "marked element",
+			html: `<body><div class="article-doi">10.7513/j.issn.1004-7638.2003.04.001</div></body>`,
+			want: "10.7513/j.issn.1004-7638.2003.04.001",
+		},
+		{
+			name: "resolver link",
+			html: `<body><p>全文: <a href="https://doi.org/10.7513/Link.2">link</a></p></body>`,
+			want: "10.7513/link.2",
+		},
+		{
+			name: "labelled header text",
+			html: `<body><div class="header"><span>钢铁钒钛, 2003, 24(4): 1-5. DOI：10.7513/j.issn.1004-7638.2003.04.001.</span></div>` + references + `</body>`,
+			want: "10.7513/j.issn.1004-7638.2003.04.001",
+		},
+		{
+			name: "reference DOIs are not the article's",
+			html: `<body><div class="content"><p>正文</p>` + references + `</div></body>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewParser(false).Parse([]byte("<html>"+tt.html+"</html>"), "https://www.gtft.cn/cn/article/id/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.DOI != tt.want {
+				t.Errorf("doi = %q, want %q", m.DOI, tt.want)
+			}
+		})
+	}
+}
+
+func TestFullTextLength(t *testing.T) {
+	html := `<html><body><div class="abstract">摘要不计</div>
+<div class="article-fulltext"><h2>1 引言</h2><p>钒钛磁铁矿 is abundant.</p>
+<div class="references"><ol><li>Wang. Titanium[J]. 2001.</li></ol></div></div></body></html>`
+	m, err := NewParser(false).Parse([]byte(html), "https://www.gtft.cn/cn/article/id/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// "1引言" and "钒钛磁铁矿isabundant." are 19 characters; 1, 引, 言, the
+	// five Han characters, is and abundant are 10 words
+	if m.FullTextChars != 19 || m.FullTextWords != 10 {
+		t.Errorf("full text length = %d chars, %d words; want 19, 10", m.FullTextChars, m.FullTextWords)
+	}
+
+	m, err = NewParser(false).Parse([]byte(`<html><body><p>no full text</p></body></html>`), "https://www.gtft.cn/cn/article/id/b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.FullTextChars != 0 || m.FullTextWords != 0 {
+		t.Errorf("full text length without full text = %d, %d; want 0, 0", m.FullTextChars, m.FullTextWords)
+	}
+}
+
+func TestLicense(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		id, url string
+	}{
+		{
+			name: "URL",
+			text: "本文采用知识共享许可协议 https://creativecommons.org/licenses/by/4.0/ 发布",
+			id:   "CC-BY-4.0", url: "https://creativecommons.org/licenses/by/4.0/",
+		},
+		{
+			name: "URL with elements",
+			text: "Licensed under http://creativecommons.org/licenses/by-nc-nd/3.0/.",
+			id:   "CC-BY-NC-ND-3.0", url: "http://creativecommons.org/licenses/by-nc-nd/3.0/",
+		},
+		{
+			name: "ported deed",
+			text: "见 https://creativecommons.org/licenses/by/3.0/cn/deed.zh。",
+			id:   "CC-BY-3.0", url: "https://creativecommons.org/licenses/by/3.0/cn/deed.zh",
+		},
+		{
+			name: "deed",
+			text: "https://creativecommons.org/licenses/by-nc/4.0/deed.zh-hans",
+			id:   "CC-BY-NC-4.0", url: "https://creativecommons.org/licenses/by-nc/4.0/deed.zh-hans",
+		},
+		{
+			name: "public domain",
+			text: "https://creativecommons.org/publicdomain/zero/1.0/",
+			id:   "CC0-1.0", url: "https://creativecommons.org/publicdomain/zero/1.0/",
+		},
+		{name: "English name", text: "This article is published under CC BY-NC 4.0.", id: "CC-BY-NC-4.0"},
+		{name: "Chinese statement", text: "本文遵循知识共享署名-非商业性使用-禁止演绎 4.0 国际许可协议。", id: "CC-BY-NC-ND-4.0"},
+		{name: "Chinese statement without version", text: "采用知识共享署名-相同方式共享许可协议", id: "CC-BY-SA"},
+		{name: "byline is not a license", text: "署名：张伟；知识共享平台"},
+		{name: "none", text: "版权所有 © 钢铁钒钛"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &PaperMetadata{}
+			found := m.setLicense(tt.text)
+			if m.License != tt.id || m.LicenseURL != tt.url || found != (tt.id != "") {
+				t.Errorf("setLicense(%q) = %q, %q, %v; want %q, %q", tt.text, m.License, m.LicenseURL, found, tt.id, tt.url)
+			}
+		})
+	}
+}
+
+func TestNotice(t *testing.T) {
+	tests := []struct {
+		name       string
+		html       string
+		retraction bool
+		correction bool
+		id         string
+		doi        string
+		title      string
+	}{
+		{
+			name: "article",
+			html: `<head><meta name="citation_title" content="超细晶粒钢力学性能研究"></head>`,
+		},
+		{
+			name:       "retraction linking the article",
+			html:       `<head><meta name="citation_title" content="关于《超细晶粒钢力学性能研究》一文的撤稿声明"><meta name="citation_doi" content="10.7513/notice"></head><body><div class="article-abstract">经核查，<a href="/cn/article/id/orig">该文</a>存在数据问题，现予撤稿。DOI: 10.7513/notice</div><div class="related"><a href="/cn/article/id/other">其他</a></div></body>`,
+			retraction: true,
+			id:         "orig",
+			title:      "超细晶粒钢力学性能研究",
+		},
+		{
+			name:       "correction naming the DOI",
+			html:       `<head><meta name="citation_title" content="更正"><meta name="citation_journal_title" content="钢铁钒钛"></head><body><div class="abstract">本刊《钢铁钒钛》2003年第4期一文（DOI: 10.7513/J.ISSN.1004-7638.2003.04.001）作者单位有误，特此更正。</div></body>`,
+			correction: true,
+			doi:        "10.7513/j.issn.1004-7638.2003.04.001",
+		},
+		{
+			name:       "English retraction",
+			html:       `<head><meta name="citation_title" content="Retraction notice"></head>`,
+			retraction: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewParser(false).Parse([]byte("<html>"+tt.html+"</html>"), "https://www.gtft.cn/cn/article/id/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if m.IsRetraction != tt.retraction || m.IsCorrection != tt.correction {
+				t.Errorf("retraction/correction = %v/%v, want %v/%v", m.IsRetraction, m.IsCorrection, tt.retraction, tt.correction)
+			}
+			if m.CorrectsID != tt.id || m.CorrectsDOI != tt.doi || m.CorrectsTitle != tt.title {
+				t.Errorf("corrects id/doi/title = %q/%q/%q, want %q/%q/%q", m.CorrectsID, m.CorrectsDOI, m.CorrectsTitle, tt.id, tt.doi, tt.title)
+			}
+			if m.CorrectsDOI != "" && m.DOI == m.CorrectsDOI {
+				t.Errorf("doi = %q, the DOI of the article the notice concerns", m.DOI)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		want []Reference
+	}{
+		{
+			name: "reference table",
+			html: `<div class="reference-tab"><table>
+<tr><td>[1]</td><td>王伟, 李明. 钒钛磁铁矿高炉冶炼[J]. 钢铁钒钛, 2001, 22(3): 1-5. <a href="https://doi.org/10.7513/J.ISSN.1004-7638.2001.03.001">DOI</a> <a href="https://scholar.google.com/?q=x">[Google Scholar]</a></td></tr>
+<tr><td>[2]</td><td>Smith J, Lee K. Titanium slag smelting[M]. Berlin: Springer, 1998.</td></tr>
+</table></div>`,
+			want: []Reference{
+				{Number: 1, Text: "王伟, 李明. 钒钛磁铁矿高炉冶炼[J]. 钢铁钒钛, 2001, 22(3): 1-5. DOI", Title: "钒钛磁铁矿高炉冶炼", Year: "2001", DOI: "10.7513/j.issn.1004-7638.2001.03.001"},
+				{Number: 2, Text: "Smith J, Lee K. Titanium slag smelting[M]. Berlin: Springer, 1998.", Title: "Titanium slag smelting", Year: "1998"},
+			},
+		},
+		{
+			name: "numbered paragraphs under a heading",
+			html: `<h3>参考文献</h3><p>1. Wang. <a href="/cn/article/id/b">Vanadium recovery from slag</a>. Hydrometallurgy, 2015. doi: 10.1016/j.hydromet.2015.01.002</p><p>正文之外</p>`,
+			want: []Reference{
+				{Number: 1, Text: "Wang. Vanadium recovery from slag. Hydrometallurgy, 2015. doi: 10.1016/j.hydromet.2015.01.002", Title: "Vanadium recovery from slag", Year: "2015", DOI: "10.1016/j.hydromet.2015.01.002", URL: "https://www.gtft.cn/cn/article/id/b"},
+			},
+		},
+		{
+			name: "no reference list",
+			html: `<p>正文</p>`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := NewParser(false).Parse([]byte("<html><body>"+tt.html+"</body></html>"), "https://www.gtft.cn/cn/article/id/a")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !slices.Equal(m.References, tt.want) {
+				t.Errorf("references = %+v, want %+v", m.References, tt.want)
+			}
+		})
+	}
+}
+
+// titleParser is a site parser that only reads the <h1>.
+type titleParser struct{ name string }
+
+func (t titleParser) Name() string { return t.name }
+
+func (t titleParser) Extract(p *Parser, doc *goquery.Document, metadata *PaperMetadata) {
+	metadata.TitleCN = strings.TrimSpace(doc.Find("h1").First().Text())
+}
+
+func TestSiteParserFor(t *testing.T) {
+	RegisterSiteParser("journal.example", titleParser{"host"})
+	RegisterSiteParser("cn.journal.example", titleParser{"subdomain"})
+	if err := RegisterSiteParserPattern(`^https://journal\.example/special/`, titleParser{"pattern"}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{"https://www.gtft.cn/cn/article/id/a", "rhhz"},
+		{"https://other.example/article/id/a", "rhhz"},
+		{"https://journal.example/article/id/a", "host"},
+		{"https://www.journal.example/article/id/a", "host"},
+		{"https://cn.journal.example/article/id/a", "subdomain"},
+		{"https://journal.example/special/a", "pattern"},
+		{"https://notjournal.example/article/id/a", "rhhz"},
+	}
+	for _, tt := range tests {
+		if got := SiteParserFor(tt.url).Name(); got != tt.want {
+			t.Errorf("SiteParserFor(%q) = %s, want %s", tt.url, got, tt.want)
+		}
+	}
+
+	m, err := NewParser(false).Parse([]byte(`<html><head><meta name="citation_title" content="meta"></head><body><h1>heading</h1></body></html>`), "https://journal.example/article/id/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.TitleCN != "heading" {
+		t.Errorf("title_cn = %q, want the site parser's %q", m.TitleCN, "heading")
+	}
+}
+
+func TestRules(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.yaml")
+	err := os.WriteFile(path, []byte(`
+title_cn:
+  - selector: .missing
+  - selector: "#title"
+keywords_en:
+  - selector: .kw-en
+    replace:
+      - pattern: "^Key ?words:"
+        with: ""
+    split: "[;；]"
+doi:
+  - selector: a.doi
+    attr: href
+    match: "doi\\.org/(.+)$"
+year:
+  - selector: .nothing
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rules, err := LoadRules(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewParser(false)
+	if err := p.SetRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	m, err := p.Parse([]byte(`<html><head><meta name="citation_title" content="旧标题"><meta name="citation_year" content="2019"></head><body>
+<h1 id="title">  新的
+  标题 </h1>
+<p class="kw-en">Key words: vanadium; titanium ；steel</p>
+<a class="doi" href="https://doi.org/10.7513/j.issn.1004-7638.2019.02.007">DOI</a>
+</body></html>`), "https://www.gtft.cn/cn/article/id/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if m.TitleCN != "新的 标题" {
+		t.Errorf("title_cn = %q, want %q", m.TitleCN, "新的 标题")
+	}
+	if want := []string{"vanadium", "titanium", "steel"}; !slices.Equal(m.KeywordsEN, want) {
+		t.Errorf("keywords_en = %q, want %q", m.KeywordsEN, want)
+	}
+	if m.DOI != "10.7513/j.issn.1004-7638.2019.02.007" {
+		t.Errorf("doi = %q", m.DOI)
+	}
+	if m.Year != "2019" {
+		t.Errorf("year = %q, want the built-in extraction's 2019 when no rule matches", m.Year)
+	}
+
+	for _, bad := range []string{"publisher:\n  - selector: .x\n", "year:\n  - selector: \"[\"\n", "year:\n  - selector: .x\n    match: \"(\"\n", "year:\n  - selector: .x\n    atr: y\n"} {
+		if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := LoadRules(path); err == nil {
+			t.Errorf("LoadRules accepted %q", bad)
+		}
+	}
+}
+
+func TestEnglishURL(t *testing.T) {
+	tests := []struct {
+		url, want string
+	}{
+		{"https://www.gtft.cn/cn/article/id/a", "https://www.gtft.cn/en/article/id/a"},
+		{"https://www.gtft.cn/article/doi/10.7513/x", "https://www.gtft.cn/en/article/doi/10.7513/x"},
+		{"https://www.gtft.cn/en/article/id/a", ""},
+		{"https://www.gtft.cn/cn/issue/list", ""},
+	}
+	for _, tt := range tests {
+		if got, _ := EnglishURL(tt.url); got != tt.want {
+			t.Errorf("EnglishURL(%q) = %q, want %q", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestMergeEnglish(t *testing.T) {
+	chinese := `<html><head><meta name="citation_title" content="超细晶粒钢力学性能研究"><meta name="citation_author" content="宋立秋"><meta name="citation_author" content="张伟"><meta name="dc.description" content="研究了超细晶粒钢。"></head></html>`
+	english := `<html><head><meta name="citation_title" content="Mechanical Properties of Ultra-fine Grain Steel"><meta name="citation_author" content="SONG Liqiu"><meta name="citation_author" content="ZHANG Wei"><meta name="dc.description" content="Ultra-fine grain steel was studied."><meta name="dc.keywords" content="steel, grain"></head></html>`
+
+	var fetched string
+	p := NewParser(false)
+	p.SetEnglishPages(func(url string) ([]byte, string, error) {
+		fetched = url
+		return []byte(english), url, nil
+	})
+	m, err := p.Parse([]byte(chinese), "https://www.gtft.cn/cn/article/id/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if fetched != "https://www.gtft.cn/en/article/id/a" {
+		t.Errorf("fetched %q", fetched)
+	}
+	if m.TitleCN != "超细晶粒钢力学性能研究" || m.TitleEN != "Mechanical Properties of Ultra-fine Grain Steel" {
+		t.Errorf("titles = %q, %q", m.TitleCN, m.TitleEN)
+	}
+	if m.AbstractCN != "研究了超细晶粒钢。" || m.AbstractEN != "Ultra-fine grain steel was studied." {
+		t.Errorf("abstracts = %q, %q", m.AbstractCN, m.AbstractEN)
+	}
+	if !slices.Equal(m.KeywordsEN, []string{"steel", "grain"}) {
+		t.Errorf("keywords_en = %q", m.KeywordsEN)
+	}
+	if len(m.Authors) != 2 || m.Authors[0].Name != "宋立秋" || m.Authors[0].NameEN != "SONG Liqiu" || m.Authors[1].NameEN != "ZHANG Wei" {
+		t.Errorf("authors = %+v", m.Authors)
+	}
+	for _, warning := range m.Warnings {
+		if warning == "missing title_en" || warning == "missing abstract_en" {
+			t.Errorf("warning %q after merging", warning)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package parser
+
+import (
+	"regexp"
+	"unicode/utf8"
+)
+
+// ambiguousAuthorPattern matches author names that still contain a list
+// separator, a digit or an email address, i.e. names that were probably not
+// split or cleaned correctly.
+var ambiguousAuthorPattern = regexp.MustCompile(`[;；,，、\d@]`)
+
+// maxAuthorNameLength is the longest plausible author name in characters;
+// longer values are usually affiliations or whole author lists.
+const maxAuthorNameLength = 40
+
+// completenessWeights are the fields that make up the completeness score,
+// weighted by how much a record is worth without them. They sum to 100.
+var completenessWeights = []struct {
+	weight  int
+	present func(*PaperMetadata) bool
+}{
+	{15, func(m *PaperMetadata) bool { return m.TitleCN != "" }},
+	{10, func(m *PaperMetadata) bool { return m.TitleEN != "" }},
+	{15, func(m *PaperMetadata) bool { return m.AbstractCN != "" }},
+	{10, func(m *PaperMetadata) bool { return m.AbstractEN != "" }},
+	{10, func(m *PaperMetadata) bool { return len(m.KeywordsCN) > 0 }},
+	{5, func(m *PaperMetadata) bool { return len(m.KeywordsEN) > 0 }},
+	{15, func(m *PaperMetadata) bool { return m.DOI != "" }},
+	{10, func(m *PaperMetadata) bool { return m.Pages != "" }},
+	{5, func(m *PaperMetadata) bool { return m.Date != "" }},
+	{5, func(m *PaperMetadata) bool { return m.SubmitDate != "" || m.OnlineDate != "" }},
+}
+
+// Completeness scores a record from 0 to 100 by the weighted presence of
+// its titles, abstracts, keywords, DOI, pages and dates.
+func Completeness(metadata *PaperMetadata) int {
+	score := 0
+	for _, w := range completenessWeights {
+		if w.present(metadata) {
+			score += w.weight
+		}
+	}
+	return score
+}
+
+// checkQuality adds warnings for fields a complete record should have, for
+// author names that look wrong and for implausibly long author lists.
+func checkQuality(metadata *PaperMetadata) {
+	type check struct {
+		field string
+		empty bool
+	}
+	missing := []check{
+		{"title_en", metadata.TitleEN == ""},
+		{"abstract_cn", metadata.AbstractCN == ""},
+		{"abstract_en", metadata.AbstractEN == ""},
+		{"keywords_cn", len(metadata.KeywordsCN) == 0},
+		{"doi", metadata.DOI == ""},
+		{"year", metadata.Year == ""},
+		{"pages", metadata.Pages == ""},
+	}
+	// English pages have no Chinese abstract or keywords to miss
+	if metadata.english() {
+		missing = []check{
+			{"title_en", metadata.TitleEN == ""},
+			{"abstract_en", metadata.AbstractEN == ""},
+			{"keywords_en", len(metadata.KeywordsEN) == 0},
+			{"doi", metadata.DOI == ""},
+			{"year", metadata.Year == ""},
+			{"pages", metadata.Pages == ""},
+		}
+	}
+	for _, m := range missing {
+		if m.empty {
+			metadata.Warn("missing %s", m.field)
+		}
+	}
+
+	if len(metadata.Authors) > maxAuthors {
+		metadata.Warn("implausible author list: %d authors", len(metadata.Authors))
+	}
+	seen := make(map[string]bool, len(metadata.Authors))
+	for _, author := range metadata.Authors {
+		key := authorKey(author.Name)
+		switch {
+		case ambiguousAuthorPattern.MatchString(author.Name):
+			metadata.Warn("ambiguous author name %q", author.Name)
+		case utf8.RuneCountInString(author.Name) > maxAuthorNameLength:
+			metadata.Warn("ambiguous author name %q: too long", author.Name)
+		case looksLikeAffiliation(author.Name):
+			metadata.Warn("ambiguous author name %q: looks like an affiliation", author.Name)
+		case seen[key]:
+			metadata.Warn("duplicate author %q", author.Name)
+		}
+		seen[key] = true
+	}
+}
@@ -0,0 +1,167 @@
+package parser
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+var (
+	// referenceEntrySelectors find one element per reference list entry,
+	// most specific first; the first that matches any is used
+	referenceEntrySelectors = []string{
+		".reference-list li", ".references li", "#references li", ".article-references li",
+		".reference-tab tr", ".references tr", "#references tr",
+		"[class*='reference'] li", "[class*='reference'] tr",
+	}
+	// referenceHeading matches the heading over a reference list that isn't
+	// marked up as one
+	referenceHeading = regexp.MustCompile(`(?i)^(?:参考文献|references|bibliography)\s*[:：]?$`)
+	// referenceNumber matches the printed number leading an entry: "[1]",
+	// "［1］", "1." or "1、"
+	referenceNumber = regexp.MustCompile(`^\s*(?:[\[［](\d{1,4})[\]］]|(\d{1,4})[.．、])\s*`)
+	// referenceType matches the GB/T 7714 document type code closing a
+	// title, e.g. "[J]", "[M]" or "[EB/OL]"
+	referenceType = regexp.MustCompile(`\s*[\[［][A-Z]{1,2}(?:/OL)?[\]］]`)
+	// referenceLinkLabels matches the lookup links rhhz pages append to
+	// each entry
+	referenceLinkLabels = regexp.MustCompile(`(?i)(?:\s*[\[［]?\s*(?:google scholar|crossref|pubmed|百度学术|谷歌学术)\s*[\]］]?)+\s*$`)
+)
+
+// extractReferences parses the page's reference list (参考文献) into
+// references: each entry's printed number, its text, and the title, year,
+// DOI and link of the cited work where they can be told. Lists filled by
+// selector rules are left alone.
+func (p *Parser) extractReferences(doc *goquery.Document, metadata *PaperMetadata) error {
+	if len(metadata.References) > 0 {
+		return nil
+	}
+
+	entries := referenceEntries(doc)
+	entries.Each(func(i int, s *goquery.Selection) {
+		// Entries nested in another one are part of it
+		if s.ParentsFiltered("li, tr").Intersection(entries).Length() > 0 {
+			return
+		}
+		if ref, ok := parseReference(s, metadata.URL, len(metadata.References)+1); ok {
+			metadata.References = append(metadata.References, ref)
+		}
+	})
+	return nil
+}
+
+// referenceEntries returns the elements of the page's reference list
+// entries: those of a marked-up list, or else the list items or paragraphs
+// following a 参考文献 or References heading.
+func referenceEntries(doc *goquery.Document) *goquery.Selection {
+	for _, selector := range referenceEntrySelectors {
+		if entries := doc.Find(selector); entries.Length() > 0 {
+			return entries
+		}
+	}
+
+	var entries *goquery.Selection
+	doc.Find("h1, h2, h3, h4, h5, h6, p, div, span, strong, b").EachWithBreak(func(i int, s *goquery.Selection) bool {
+		if s.Children().Length() > 1 || !referenceHeading.MatchString(strings.TrimSpace(s.Text())) {
+			return true
+		}
+		// The entries are in the list after the heading, or are the
+		// numbered paragraphs following it
+		heading := s
+		if s.Is("span, strong, b") {
+			heading = s.Parent()
+		}
+		if list := heading.NextAllFiltered("ol, ul, table, div").First(); list.Length() > 0 {
+			if items := list.Find("li, tr"); items.Length() > 0 {
+				entries = items
+				return false
+			}
+		}
+		entries = heading.NextAll().FilterFunction(func(i int, s *goquery.Selection) bool {
+			return referenceNumber.MatchString(s.Text())
+		})
+		return entries.Length() == 0
+	})
+	if entries == nil {
+		return doc.FindNodes()
+	}
+	return entries
+}
+
+// parseReference parses one reference list entry, numbered position unless
+// it prints its own number. ok is false for entries without text.
+func parseReference(s *goquery.Selection, pageURL string, position int) (ref Reference, ok bool) {
+	text := strings.Join(strings.Fields(s.Text()), " ")
+	text = referenceLinkLabels.ReplaceAllString(text, "")
+	ref.Number = position
+	if matches := referenceNumber.FindStringSubmatch(text); matches != nil {
+		number := matches[1] + matches[2]
+		ref.Number, _ = strconv.Atoi(number)
+		text = text[len(matches[0]):]
+	}
+	ref.Text = strings.TrimSpace(text)
+	if ref.Text == "" {
+		return ref, false
+	}
+
+	s.Find("a[href]").Each(func(i int, a *goquery.Selection) {
+		href := strings.TrimSpace(a.AttrOr("href", ""))
+		if href == "" || strings.HasPrefix(href, "#") || strings.HasPrefix(strings.ToLower(href), "javascript:") {
+			return
+		}
+		link, err := resolveURL(pageURL, href)
+		if err != nil {
+			return
+		}
+		switch {
+		case strings.Contains(link, "doi.org/10."):
+			if ref.DOI == "" {
+				ref.DOI = findDOI(doiPattern, link)
+			}
+		case referenceLinkLabels.MatchString(a.Text()):
+			// Lookup links are searches, not the cited work
+		case ref.URL == "":
+			ref.URL = link
+			// A link on a run of words is the cited work's title
+			if title := strings.Join(strings.Fields(a.Text()), " "); len([]rune(title)) >= 6 && !strings.Contains(link, title) {
+				ref.Title = title
+			}
+		}
+	})
+	if ref.DOI == "" {
+		ref.DOI = findDOI(labelledDOIPattern, ref.Text)
+	}
+	if ref.DOI == "" {
+		ref.DOI = findDOI(doiPattern, ref.Text)
+	}
+	ref.DOI = NormalizeDOI(ref.DOI)
+
+	// Entries in GB/T 7714 style read "Authors. Title[J]. Journal, Year,
+	// Volume(Issue): Pages."; the title is the sentence before the type code
+	rest := ref.Text
+	if loc := referenceType.FindStringIndex(ref.Text); loc != nil {
+		before := ref.Text[:loc[0]]
+		if ref.Title == "" {
+			if i := strings.LastIndexFunc(before, isFullStop); i >= 0 {
+				_, size := utf8.DecodeRuneInString(before[i:])
+				before = before[i+size:]
+			}
+			ref.Title = strings.TrimSpace(before)
+		}
+		rest = ref.Text[loc[1]:]
+	}
+	if ref.DOI != "" {
+		rest = strings.ReplaceAll(strings.ToLower(rest), ref.DOI, "")
+	}
+	if year := yearPattern.FindString(rest); year != "" {
+		ref.Year = year
+	}
+	return ref, true
+}
+
+func isFullStop(r rune) bool {
+	return r == '.' || r == '．'
+}
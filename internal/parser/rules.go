@@ -0,0 +1,171 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"gopkg.in/yaml.v3"
+)
+
+// Rules are selector rules loaded from a file, keyed by the JSON name of
+// the field they fill. A field's rules are tried in order and the first
+// yielding a value wins; when none does, the field keeps what the built-in
+// extractors found.
+type Rules map[string][]*Rule
+
+// Rule extracts a field from the elements matching Selector: their text,
+// or the value of Attr, post-processed in the order of the fields below.
+type Rule struct {
+	Selector string `json:"selector" yaml:"selector"`
+	Attr     string `json:"attr,omitempty" yaml:"attr,omitempty"`
+	// Replace rewrites each value, in order
+	Replace []Replacement `json:"replace,omitempty" yaml:"replace,omitempty"`
+	// Match keeps the first submatch of this regular expression, or the
+	// whole match when it has none, dropping values it doesn't match
+	Match string `json:"match,omitempty" yaml:"match,omitempty"`
+	// Split splits each value on this regular expression, for list fields
+	Split string `json:"split,omitempty" yaml:"split,omitempty"`
+
+	match *regexp.Regexp
+	split *regexp.Regexp
+}
+
+// Replacement replaces the matches of the regular expression Pattern with
+// With, which may refer to submatches as $1.
+type Replacement struct {
+	Pattern string `json:"pattern" yaml:"pattern"`
+	With    string `json:"with" yaml:"with"`
+
+	pattern *regexp.Regexp
+}
+
+var spaceRun = regexp.MustCompile(`\s+`)
+
+// LoadRules reads selector rules from a YAML file, or a JSON one when its
+// name ends in .json.
+func LoadRules(path string) (Rules, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var rules Rules
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(&rules)
+	} else {
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(&rules)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid rules file %s: %w", path, err)
+	}
+	if err := rules.compile(); err != nil {
+		return nil, fmt.Errorf("rules file %s: %w", path, err)
+	}
+	return rules, nil
+}
+
+// compile checks the rules and compiles their regular expressions.
+func (rules Rules) compile() error {
+	for field, list := range rules {
+		if !IsField(field) {
+			return fmt.Errorf("no rules for field %q", field)
+		}
+		for i, rule := range list {
+			if rule == nil || strings.TrimSpace(rule.Selector) == "" {
+				return fmt.Errorf("%s rule %d: selector is required", field, i+1)
+			}
+			if _, err := cascadia.Compile(rule.Selector); err != nil {
+				return fmt.Errorf("%s rule %d: invalid selector: %w", field, i+1, err)
+			}
+			var err error
+			if rule.Match != "" {
+				if rule.match, err = regexp.Compile(rule.Match); err != nil {
+					return fmt.Errorf("%s rule %d: invalid match: %w", field, i+1, err)
+				}
+			}
+			if rule.Split != "" {
+				if rule.split, err = regexp.Compile(rule.Split); err != nil {
+					return fmt.Errorf("%s rule %d: invalid split: %w", field, i+1, err)
+				}
+			}
+			for j := range rule.Replace {
+				if rule.Replace[j].pattern, err = regexp.Compile(rule.Replace[j].Pattern); err != nil {
+					return fmt.Errorf("%s rule %d: invalid replace pattern: %w", field, i+1, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// values returns what the rule extracts from doc, with whitespace runs
+// collapsed and empty values dropped.
+func (r *Rule) values(doc *goquery.Document) []string {
+	var values []string
+	doc.Find(r.Selector).Each(func(i int, s *goquery.Selection) {
+		value := s.Text()
+		if r.Attr != "" {
+			value, _ = s.Attr(r.Attr)
+		}
+		value = spaceRun.ReplaceAllString(value, " ")
+		for _, replace := range r.Replace {
+			value = replace.pattern.ReplaceAllString(value, replace.With)
+		}
+		if r.match != nil {
+			matches := r.match.FindStringSubmatch(value)
+			switch {
+			case matches == nil:
+				return
+			case len(matches) > 1:
+				value = matches[1]
+			default:
+				value = matches[0]
+			}
+		}
+
+		parts := []string{value}
+		if r.split != nil {
+			parts = r.split.Split(value, -1)
+		}
+		for _, part := range parts {
+			if part = strings.TrimSpace(part); part != "" {
+				values = append(values, part)
+			}
+		}
+	})
+	return values
+}
+
+// SetRules makes the parser apply rules, which win over the site's
+// selectors for the fields they both cover.
+func (p *Parser) SetRules(rules Rules) error {
+	if err := rules.compile(); err != nil {
+		return err
+	}
+	p.rules = rules
+	return nil
+}
+
+// applyRules runs the rules, leaving fields none of whose rules yield a
+// value as they are.
+func (p *Parser) applyRules(doc *goquery.Document, metadata *PaperMetadata) {
+	for field, list := range p.rules {
+		for _, rule := range list {
+			if values := rule.values(doc); len(values) > 0 {
+				SetField(metadata, field, values)
+				break
+			}
+		}
+	}
+}
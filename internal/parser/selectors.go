@@ -0,0 +1,99 @@
+package parser
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// selectorFields are the fields a site profile can override with a CSS
+// selector. String fields take the text of the first match; list fields take
+// one entry per match, splitting a single match on list separators.
+var selectorFields = map[string]func(*PaperMetadata) any{
+	"title_cn":     func(m *PaperMetadata) any { return &m.TitleCN },
+	"title_en":     func(m *PaperMetadata) any { return &m.TitleEN },
+	"abstract_cn":  func(m *PaperMetadata) any { return &m.AbstractCN },
+	"abstract_en":  func(m *PaperMetadata) any { return &m.AbstractEN },
+	"keywords_cn":  func(m *PaperMetadata) any { return &m.KeywordsCN },
+	"keywords_en":  func(m *PaperMetadata) any { return &m.KeywordsEN },
+	"authors":      func(m *PaperMetadata) any { return &m.Authors },
+	"journal_cn":   func(m *PaperMetadata) any { return &m.JournalCN },
+	"journal_en":   func(m *PaperMetadata) any { return &m.JournalEN },
+	"volume":       func(m *PaperMetadata) any { return &m.Volume },
+	"issue":        func(m *PaperMetadata) any { return &m.Issue },
+	"pages":        func(m *PaperMetadata) any { return &m.Pages },
+	"first_page":   func(m *PaperMetadata) any { return &m.FirstPage },
+	"last_page":    func(m *PaperMetadata) any { return &m.LastPage },
+	"year":         func(m *PaperMetadata) any { return &m.Year },
+	"doi":          func(m *PaperMetadata) any { return &m.DOI },
+	"fund_project": func(m *PaperMetadata) any { return &m.FundProject },
+	"clc_code":     func(m *PaperMetadata) any { return &m.CLCCode },
+}
+
+var listSeparatorPattern = regexp.MustCompile(`\s*[;；,，、]\s*`)
+
+// applySelectors runs the site's selector overrides. A selector that matches
+// nothing leaves the field as the default extractors found it.
+func (p *Parser) applySelectors(doc *goquery.Document, metadata *PaperMetadata) {
+	for field, selector := range p.site.Selectors {
+		matches := doc.Find(selector)
+		if matches.Length() == 0 {
+			continue
+		}
+
+		var values []string
+		matches.Each(func(i int, s *goquery.Selection) {
+			if text := strings.TrimSpace(s.Text()); text != "" {
+				values = append(values, text)
+			}
+		})
+		if len(values) == 0 {
+			continue
+		}
+		SetField(metadata, field, values)
+	}
+}
+
+// IsField reports whether field is a JSON field name that SetField accepts.
+func IsField(field string) bool {
+	_, ok := selectorFields[field]
+	return ok
+}
+
+// SetField overwrites a metadata field, named by its JSON name, with values.
+// String fields take the first value; list fields take one entry per value,
+// splitting a single value on list separators. No values clears the field.
+func SetField(metadata *PaperMetadata, field string, values []string) error {
+	target, ok := selectorFields[field]
+	if !ok {
+		return fmt.Errorf("unknown field %q", field)
+	}
+
+	switch target := target(metadata).(type) {
+	case *string:
+		*target = ""
+		if len(values) > 0 {
+			*target = strings.TrimSpace(values[0])
+		}
+	case *[]string:
+		*target = splitList(values)
+	case *[]Author:
+		metadata.Authors = nil
+		for _, value := range values {
+			metadata.addAuthors(splitAuthors(value)...)
+		}
+	}
+	return nil
+}
+
+func splitList(values []string) []string {
+	if len(values) == 0 {
+		return nil
+	}
+	if len(values) == 1 {
+		return listSeparatorPattern.Split(strings.TrimSpace(values[0]), -1)
+	}
+	return values
+}
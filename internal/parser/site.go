@@ -0,0 +1,116 @@
+package parser
+
+import (
+	"fmt"
+	neturl "net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// SiteParser extracts an article's metadata from the pages of one family of
+// journal sites. Parse runs the site parser registered for the page's URL,
+// then applies the selector overrides, plugins and checks common to all
+// sites, so a site parser only fills the fields its pages hold. Problems
+// that don't stop extraction belong in metadata.Warn.
+type SiteParser interface {
+	Name() string
+	Extract(p *Parser, doc *goquery.Document, metadata *PaperMetadata)
+}
+
+// Rhhz parses the Beijing Magtech (rhhz) platform's article pages, which
+// gtft.cn and many other Chinese journals share. It runs the built-in
+// extractors, and is used for URLs no other site parser is registered for.
+var Rhhz SiteParser = rhhzParser{}
+
+type siteRule struct {
+	host    string
+	pattern *regexp.Regexp
+	parser  SiteParser
+}
+
+var (
+	siteMu    sync.RWMutex
+	siteRules []siteRule
+)
+
+func init() {
+	RegisterSiteParser("gtft.cn", Rhhz)
+}
+
+// RegisterSiteParser makes sp parse the pages of host and its subdomains,
+// taking over from any parser registered for a shorter host suffix.
+func RegisterSiteParser(host string, sp SiteParser) {
+	siteMu.Lock()
+	defer siteMu.Unlock()
+	siteRules = append(siteRules, siteRule{host: strings.ToLower(host), parser: sp})
+}
+
+// RegisterSiteParserPattern makes sp parse the pages whose URL matches the
+// regular expression pattern. Patterns are tried in the order registered,
+// before any host.
+func RegisterSiteParserPattern(pattern string, sp SiteParser) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid site parser pattern %q: %w", pattern, err)
+	}
+	siteMu.Lock()
+	defer siteMu.Unlock()
+	siteRules = append(siteRules, siteRule{pattern: re, parser: sp})
+	return nil
+}
+
+// SiteParserFor returns the site parser for url: the first one whose
+// pattern matches it, else the one registered for the longest suffix of
+// its host, else Rhhz.
+func SiteParserFor(url string) SiteParser {
+	siteMu.RLock()
+	defer siteMu.RUnlock()
+
+	for _, rule := range siteRules {
+		if rule.pattern != nil && rule.pattern.MatchString(url) {
+			return rule.parser
+		}
+	}
+
+	var host string
+	if u, err := neturl.Parse(url); err == nil {
+		host = strings.ToLower(u.Hostname())
+	}
+	best, found := Rhhz, ""
+	for _, rule := range siteRules {
+		if rule.pattern != nil || len(rule.host) <= len(found) {
+			continue
+		}
+		if host == rule.host || strings.HasSuffix(host, "."+rule.host) {
+			best, found = rule.parser, rule.host
+		}
+	}
+	return best
+}
+
+type rhhzParser struct{}
+
+func (rhhzParser) Name() string { return "rhhz" }
+
+// Extract runs the extractors the parser hasn't had skipped.
+func (rhhzParser) Extract(p *Parser, doc *goquery.Document, metadata *PaperMetadata) {
+	scanned := false
+	for _, e := range extractors {
+		if p.skip[e.name] {
+			continue
+		}
+		if e.scan != nil {
+			if !scanned {
+				p.scanText(doc, metadata)
+				scanned = true
+			}
+			continue
+		}
+		if err := e.extract(p, doc, metadata); err != nil {
+			metadata.Warn("%v", err)
+		}
+	}
+}
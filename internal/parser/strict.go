@@ -0,0 +1,85 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// DefaultCriticalFields are the fields strict mode requires unless told
+// otherwise.
+var DefaultCriticalFields = []string{"title", "authors", "journal", "doi", "year"}
+
+// eitherLanguage are critical field names satisfied by either language's
+// field, since English pages may only carry the English one.
+var eitherLanguage = map[string][2]string{
+	"title":    {"title_cn", "title_en"},
+	"abstract": {"abstract_cn", "abstract_en"},
+	"keywords": {"keywords_cn", "keywords_en"},
+	"journal":  {"journal_cn", "journal_en"},
+}
+
+// IncompleteError is returned by Parse in strict mode for a page missing
+// critical fields. Metadata holds the best-effort record, for callers that
+// quarantine incomplete pages rather than drop them.
+type IncompleteError struct {
+	URL      string
+	Missing  []string
+	Metadata *PaperMetadata
+}
+
+func (e *IncompleteError) Error() string {
+	return fmt.Sprintf("incomplete record: missing %s", strings.Join(e.Missing, ", "))
+}
+
+// IsCriticalField reports whether field can be required by strict mode:
+// any field SetField accepts, or title, abstract, keywords or journal in
+// either language.
+func IsCriticalField(field string) bool {
+	_, either := eitherLanguage[field]
+	return either || IsField(field)
+}
+
+// SetStrict makes Parse return an *IncompleteError instead of a record when
+// any of fields is empty. No fields turns strict mode off.
+func (p *Parser) SetStrict(fields []string) error {
+	for _, field := range fields {
+		if !IsCriticalField(field) {
+			return fmt.Errorf("unknown field %q", field)
+		}
+	}
+	p.critical = slices.Clone(fields)
+	return nil
+}
+
+// Missing returns those of fields that are empty in the record, in order.
+func (m *PaperMetadata) Missing(fields []string) []string {
+	var missing []string
+	for _, field := range fields {
+		names := []string{field}
+		if pair, ok := eitherLanguage[field]; ok {
+			names = pair[:]
+		}
+		if !slices.ContainsFunc(names, m.hasField) {
+			missing = append(missing, field)
+		}
+	}
+	return missing
+}
+
+// hasField reports whether the field with this JSON name is set.
+func (m *PaperMetadata) hasField(field string) bool {
+	target, ok := selectorFields[field]
+	if !ok {
+		return false
+	}
+	switch target := target(m).(type) {
+	case *string:
+		return *target != ""
+	case *[]string:
+		return len(*target) > 0
+	case *[]Author:
+		return len(*target) > 0
+	}
+	return false
+}
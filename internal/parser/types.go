@@ -1,20 +1,63 @@
 package parser
 
 import (
+	"fmt"
 	"time"
 )
 
 type Author struct {
-	Name        string `json:"name"`
+	Name string `json:"name"`
+	// NameEN is the name as the article's English page gives it, when
+	// that page was merged
+	NameEN      string `json:"name_en,omitempty"`
 	Affiliation string `json:"affiliation,omitempty"`
 	Order       int    `json:"order,omitempty"`
 }
 
+type Figure struct {
+	Label    string `json:"label,omitempty"`
+	Caption  string `json:"caption,omitempty"`
+	ImageURL string `json:"image_url"`
+	Path     string `json:"path,omitempty"`
+}
+
+// Tombstone marks a record whose page the site no longer serves.
+type Tombstone struct {
+	// StatusCode is the response that found it gone: 404 or 410
+	StatusCode int `json:"status_code"`
+	// RemovedAt is when a crawl first found it gone
+	RemovedAt string `json:"removed_at"`
+}
+
+// Reference is one entry in an article's reference list.
+type Reference struct {
+	// Number is the entry's position in the list, as printed
+	Number int    `json:"number,omitempty"`
+	Text   string `json:"text"`
+	Title  string `json:"title,omitempty"`
+	Year   string `json:"year,omitempty"`
+	DOI    string `json:"doi,omitempty"`
+	URL    string `json:"url,omitempty"`
+	// ArticleID is the cited article's record ID when it is in the corpus
+	ArticleID string `json:"article_id,omitempty"`
+	// Source is where the entry came from when not the article's page,
+	// e.g. "crossref"
+	Source string `json:"source,omitempty"`
+}
+
 type PaperMetadata struct {
 	// Core Identification
-	ID       string `json:"id"`
-	URL      string `json:"url"`
-	Language string `json:"language"`
+	ID           string `json:"id"`
+	URL          string `json:"url"`
+	CanonicalURL string `json:"canonical_url,omitempty"`
+	// FinalURL and RedirectChain are set when the request URL redirected;
+	// the chain lists every URL visited, ending with FinalURL
+	FinalURL      string   `json:"final_url,omitempty"`
+	RedirectChain []string `json:"redirect_chain,omitempty"`
+	Language      string   `json:"language"`
+	// Site is the profile of the journal the record was crawled from, in
+	// runs crawling several journals
+	Site string `json:"site,omitempty"`
 
 	// Titles
 	TitleCN string `json:"title_cn"`
@@ -32,8 +75,14 @@ type PaperMetadata struct {
 	// Publication Details
 	Volume string `json:"volume"`
 	Issue  string `json:"issue"`
-	Pages  string `json:"pages"`
-	Year   string `json:"year"`
+	// Pages is derived from FirstPage and LastPage: "first-last", or the
+	// first page alone
+	Pages     string `json:"pages"`
+	FirstPage string `json:"first_page,omitempty"`
+	LastPage  string `json:"last_page,omitempty"`
+	// PageCount is the number of pages Pages spans, when known
+	PageCount int    `json:"page_count,omitempty"`
+	Year      string `json:"year"`
 
 	// Dates
 	Date       string `json:"date"`
@@ -45,10 +94,24 @@ type PaperMetadata struct {
 	AbstractEN string   `json:"abstract_en,omitempty"`
 	KeywordsCN []string `json:"keywords_cn"`
 	KeywordsEN []string `json:"keywords_en,omitempty"`
+	// FullTextChars and FullTextWords measure the HTML full text, when
+	// the page has one: characters other than spaces, and words, counting
+	// each Chinese character as one
+	FullTextChars int `json:"fulltext_chars,omitempty"`
+	FullTextWords int `json:"fulltext_words,omitempty"`
+	// MachineTranslated names the English fields filled by machine
+	// translation of the Chinese ones, e.g. "abstract_en"
+	MachineTranslated []string `json:"machine_translated,omitempty"`
 
 	// Resources
-	PDFURL  string `json:"pdf_url,omitempty"`
-	PDFSize string `json:"pdf_size,omitempty"`
+	PDFURL                string   `json:"pdf_url,omitempty"`
+	PDFSize               string   `json:"pdf_size,omitempty"`
+	PDFBytes              int64    `json:"pdf_bytes,omitempty"`
+	PDFPath               string   `json:"pdf_path,omitempty"`
+	PDFSHA256             string   `json:"pdf_sha256,omitempty"`
+	GraphicalAbstractURL  string   `json:"graphical_abstract_url,omitempty"`
+	GraphicalAbstractPath string   `json:"graphical_abstract_path,omitempty"`
+	Figures               []Figure `json:"figures,omitempty"`
 
 	// Metrics
 	Views     int `json:"views"`
@@ -59,7 +122,37 @@ type PaperMetadata struct {
 	DOI         string `json:"doi,omitempty"`
 	FundProject string `json:"fund_project,omitempty"`
 	CLCCode     string `json:"clc_code,omitempty"`
-	License     string `json:"license,omitempty"`
+	// License is an SPDX identifier such as "CC-BY-4.0", and LicenseURL
+	// the license's URL when the page links it
+	License    string `json:"license,omitempty"`
+	LicenseURL string `json:"license_url,omitempty"`
+
+	// Citations within the corpus: References lists the works the article
+	// cites, and CitedBy the IDs of corpus articles citing it
+	References []Reference `json:"references,omitempty"`
+	CitedBy    []string    `json:"cited_by,omitempty"`
+
+	// IsRetraction and IsCorrection mark retraction and correction
+	// notices. CorrectsID is the record ID of the article a notice concerns,
+	// from a link in the notice or resolved by the link command from the
+	// DOI or title it names, and Notices lists, on that article, the IDs of
+	// the notices concerning it
+	IsRetraction  bool     `json:"is_retraction,omitempty"`
+	IsCorrection  bool     `json:"is_correction,omitempty"`
+	CorrectsID    string   `json:"corrects_id,omitempty"`
+	CorrectsDOI   string   `json:"corrects_doi,omitempty"`
+	CorrectsTitle string   `json:"corrects_title,omitempty"`
+	Notices       []string `json:"notices,omitempty"`
+
+	// Removed is set once the article's page answers 404 or 410: it was
+	// retracted or taken down, and the record is what was last seen
+	Removed *Tombstone `json:"retracted_or_removed,omitempty"`
+
+	// Warnings lists data-quality problems found while parsing, such as
+	// missing fields or values taken from fallback selectors
+	Warnings []string `json:"warnings,omitempty"`
+	// Completeness is the weighted share (0-100) of the key fields present
+	Completeness int `json:"completeness"`
 
 	// Timestamps
 	ParsedAt string `json:"parsed_at"`
@@ -68,13 +161,20 @@ type PaperMetadata struct {
 func NewPaperMetadata(url string) *PaperMetadata {
 	return &PaperMetadata{
 		URL:      url,
-		Language: "zh",
+		Language: LanguageZH,
 		ParsedAt: time.Now().UTC().Format(time.RFC3339),
 	}
 }
 
+// Warn records a data-quality problem with the record.
+func (p *PaperMetadata) Warn(format string, args ...any) {
+	p.Warnings = append(p.Warnings, fmt.Sprintf(format, args...))
+}
+
 func (p *PaperMetadata) Validate() bool {
-	if p.ID == "" || p.TitleCN == "" || len(p.Authors) == 0 || p.JournalCN == "" {
+	// English pages may only carry the English title and journal name
+	title, journal := p.TitleCN+p.TitleEN, p.JournalCN+p.JournalEN
+	if p.ID == "" || title == "" || len(p.Authors) == 0 || journal == "" {
 		return false
 	}
 	return true
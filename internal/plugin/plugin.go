@@ -0,0 +1,207 @@
+// Package plugin runs site parsers compiled to WebAssembly.
+//
+// A plugin is a WASM module that exports its linear memory as "memory" and a
+// function "extract" taking no arguments and returning an i32 (0 on success).
+// It may import these functions from the "gtft" host module:
+//
+//	html(ptr, cap i32) i32      copies the page HTML into memory if it fits in
+//	                            cap bytes; returns its length either way
+//	url(ptr, cap i32) i32       the same for the page URL
+//	set_field(name_ptr, name_len, value_ptr, value_len i32) i32
+//	                            sets a field by its JSON name; list fields take
+//	                            one entry per line. Returns 0, or 1 for an
+//	                            unknown field. An empty value clears the field
+//	log(ptr, len i32)           writes a message to the crawler's log
+//
+// Modules built for WASI (e.g. with TinyGo or GOOS=wasip1) are supported, but
+// get no filesystem, network, environment or clock access beyond what WASI
+// provides by default.
+package plugin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"gtft-crawler/internal/parser"
+)
+
+const (
+	// memoryLimitPages caps a plugin's memory at 64 MiB
+	memoryLimitPages = 1024
+
+	// extractTimeout bounds a single extract call
+	extractTimeout = 10 * time.Second
+)
+
+// Plugin is a loaded WASM extraction plugin. Each page is processed by a
+// fresh instance of the module, so plugins can't keep state between pages
+// and a Plugin is safe for concurrent use.
+type Plugin struct {
+	name     string
+	runtime  wazero.Runtime
+	compiled wazero.CompiledModule
+	verbose  bool
+}
+
+// call is the state of one extract call, reached by the host functions
+// through the context.
+type call struct {
+	html     []byte
+	url      string
+	metadata *parser.PaperMetadata
+	errs     []error
+}
+
+type callKey struct{}
+
+// Load compiles the WASM module at path.
+func Load(path string, verbose bool) (*Plugin, error) {
+	wasm, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read plugin: %w", err)
+	}
+
+	ctx := context.Background()
+	runtime := wazero.NewRuntimeWithConfig(ctx, wazero.NewRuntimeConfig().
+		WithMemoryLimitPages(memoryLimitPages).
+		WithCloseOnContextDone(true))
+
+	p := &Plugin{
+		name:    strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)),
+		runtime: runtime,
+		verbose: verbose,
+	}
+
+	if _, err := wasi_snapshot_preview1.Instantiate(ctx, runtime); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to set up WASI: %w", err)
+	}
+	if err := p.instantiateHost(ctx); err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to set up host API: %w", err)
+	}
+
+	p.compiled, err = runtime.CompileModule(ctx, wasm)
+	if err != nil {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("failed to compile plugin %s: %w", p.name, err)
+	}
+	if _, ok := p.compiled.ExportedFunctions()["extract"]; !ok {
+		runtime.Close(ctx)
+		return nil, fmt.Errorf("plugin %s does not export an extract function", p.name)
+	}
+
+	return p, nil
+}
+
+// Name returns the plugin's file name without its extension.
+func (p *Plugin) Name() string {
+	return p.name
+}
+
+// Extract runs the plugin on a page, letting it overwrite fields of metadata.
+func (p *Plugin) Extract(html []byte, url string, metadata *parser.PaperMetadata) error {
+	c := &call{html: html, url: url, metadata: metadata}
+
+	ctx, cancel := context.WithTimeout(context.Background(), extractTimeout)
+	defer cancel()
+	ctx = context.WithValue(ctx, callKey{}, c)
+
+	// Anonymous instances let pages be processed concurrently. Reactor
+	// modules are initialised; command modules' _start is not run.
+	config := wazero.NewModuleConfig().
+		WithName("").
+		WithStartFunctions("_initialize").
+		WithStdout(os.Stderr).
+		WithStderr(os.Stderr)
+
+	module, err := p.runtime.InstantiateModule(ctx, p.compiled, config)
+	if err != nil {
+		return fmt.Errorf("failed to instantiate: %w", err)
+	}
+	defer module.Close(ctx)
+
+	results, err := module.ExportedFunction("extract").Call(ctx)
+	if err != nil {
+		return fmt.Errorf("extract failed: %w", err)
+	}
+	if len(results) > 0 && api.DecodeI32(results[0]) != 0 {
+		return fmt.Errorf("extract returned %d", api.DecodeI32(results[0]))
+	}
+
+	return errors.Join(c.errs...)
+}
+
+// Close releases the plugin's runtime.
+func (p *Plugin) Close() error {
+	return p.runtime.Close(context.Background())
+}
+
+func (p *Plugin) instantiateHost(ctx context.Context) error {
+	_, err := p.runtime.NewHostModuleBuilder("gtft").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, capacity uint32) uint32 {
+			return copyOut(m, ptr, capacity, ctx.Value(callKey{}).(*call).html)
+		}).
+		Export("html").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, capacity uint32) uint32 {
+			return copyOut(m, ptr, capacity, []byte(ctx.Value(callKey{}).(*call).url))
+		}).
+		Export("url").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, namePtr, nameLen, valuePtr, valueLen uint32) uint32 {
+			c := ctx.Value(callKey{}).(*call)
+			name, ok1 := m.Memory().Read(namePtr, nameLen)
+			value, ok2 := m.Memory().Read(valuePtr, valueLen)
+			if !ok1 || !ok2 {
+				panic("set_field: out of bounds memory access")
+			}
+
+			field := string(name)
+			if !parser.IsField(field) {
+				c.errs = append(c.errs, fmt.Errorf("unknown field %q", field))
+				return 1
+			}
+
+			var values []string
+			for _, line := range strings.Split(string(value), "\n") {
+				if line = strings.TrimSpace(line); line != "" {
+					values = append(values, line)
+				}
+			}
+			parser.SetField(c.metadata, field, values)
+			return 0
+		}).
+		Export("set_field").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, m api.Module, ptr, length uint32) {
+			if !p.verbose {
+				return
+			}
+			if msg, ok := m.Memory().Read(ptr, length); ok {
+				fmt.Printf("[Plugin %s] %s\n", p.name, msg)
+			}
+		}).
+		Export("log").
+		Instantiate(ctx)
+	return err
+}
+
+// copyOut writes data into guest memory when it fits and returns its length,
+// so a guest can retry with a larger buffer.
+func copyOut(m api.Module, ptr, capacity uint32, data []byte) uint32 {
+	if uint32(len(data)) <= capacity && !m.Memory().Write(ptr, data) {
+		panic("out of bounds memory access")
+	}
+	return uint32(len(data))
+}
@@ -0,0 +1,228 @@
+package profile
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/schedule"
+)
+
+// Profile describes one journal site: where it lives, which URLs are
+// article pages, selector overrides for its markup and how hard to crawl it.
+// Other rhhz-platform journals share gtft.cn's page layout, so a profile is
+// usually just a base URL and journal names.
+type Profile struct {
+	Name    string   `json:"name"`
+	BaseURL string   `json:"base_url"`
+	Hosts   []string `json:"hosts,omitempty"`
+
+	// ArticlePatterns are regular expressions matched against URLs to tell
+	// article pages from issue and listing pages
+	ArticlePatterns []string `json:"article_patterns,omitempty"`
+
+	Journal Journal `json:"journal"`
+
+	// Timezone is the site's IANA time zone, e.g. "Asia/Shanghai", in
+	// which crawl windows are given
+	Timezone string `json:"timezone,omitempty"`
+
+	// Selectors override extraction of a field (by JSON name, e.g.
+	// "title_cn" or "keywords_en") with a CSS selector
+	Selectors map[string]string `json:"selectors,omitempty"`
+
+	Rate RatePolicy `json:"rate"`
+
+	articlePatterns []*regexp.Regexp
+}
+
+// Journal names are matched on the page to fill journal_cn and journal_en.
+type Journal struct {
+	CN string `json:"cn"`
+	EN string `json:"en,omitempty"`
+}
+
+// RatePolicy holds crawl defaults for the site. Zero values leave the
+// crawler's defaults alone, and command-line flags always win.
+type RatePolicy struct {
+	RequestsPerSecond int      `json:"requests_per_second,omitempty"`
+	Workers           int      `json:"workers,omitempty"`
+	Timeout           Duration `json:"timeout,omitempty"`
+	MaxRetries        int      `json:"max_retries,omitempty"`
+	// CrawlWindows are the site-local times of day crawling is allowed,
+	// e.g. "01:00-06:00"
+	CrawlWindows string `json:"crawl_windows,omitempty"`
+}
+
+// Duration is a time.Duration written as a string such as "30s" in JSON.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"30s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DefaultArticlePatterns match rhhz-platform article URLs.
+var DefaultArticlePatterns = []string{`/article/id/`, `/article/doi/`}
+
+var builtin = map[string]Profile{
+	"gtft": {
+		Name:    "gtft",
+		BaseURL: "https://www.gtft.cn",
+		Hosts:   []string{"gtft.cn"},
+		Journal: Journal{
+			CN: "钢铁钒钛",
+			EN: "IRON STEEL VANADIUM TITANIUM",
+		},
+		Timezone: "Asia/Shanghai",
+		Rate:     RatePolicy{RequestsPerSecond: 5, Workers: 20},
+	},
+}
+
+// Builtin lists the names of the profiles compiled into the binary.
+func Builtin() []string {
+	names := make([]string, 0, len(builtin))
+	for name := range builtin {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Load returns the built-in profile called nameOrPath, or reads a JSON
+// profile from that path.
+func Load(nameOrPath string) (*Profile, error) {
+	var p Profile
+	if b, ok := builtin[nameOrPath]; ok {
+		p = b
+	} else {
+		data, err := os.ReadFile(nameOrPath)
+		if err != nil {
+			return nil, fmt.Errorf("unknown profile %q (built-in: %s): %w", nameOrPath, strings.Join(Builtin(), ", "), err)
+		}
+		if err := json.Unmarshal(data, &p); err != nil {
+			return nil, fmt.Errorf("invalid profile %s: %w", nameOrPath, err)
+		}
+	}
+
+	if err := p.init(); err != nil {
+		return nil, fmt.Errorf("profile %s: %w", nameOrPath, err)
+	}
+	return &p, nil
+}
+
+func (p *Profile) init() error {
+	if p.BaseURL == "" {
+		return fmt.Errorf("base_url is required")
+	}
+	base, err := url.Parse(p.BaseURL)
+	if err != nil || base.Host == "" {
+		return fmt.Errorf("invalid base_url %q", p.BaseURL)
+	}
+
+	if len(p.Hosts) == 0 {
+		p.Hosts = []string{strings.TrimPrefix(base.Hostname(), "www.")}
+	}
+	if p.Name == "" {
+		p.Name = p.Hosts[0]
+	}
+	if p.Journal.CN == "" {
+		return fmt.Errorf("journal.cn is required")
+	}
+
+	patterns := p.ArticlePatterns
+	if len(patterns) == 0 {
+		patterns = DefaultArticlePatterns
+	}
+	p.articlePatterns = p.articlePatterns[:0]
+	for _, pattern := range patterns {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid article pattern %q: %w", pattern, err)
+		}
+		p.articlePatterns = append(p.articlePatterns, re)
+	}
+
+	loc := time.Local
+	if p.Timezone != "" {
+		if loc, err = time.LoadLocation(p.Timezone); err != nil {
+			return fmt.Errorf("invalid timezone %q: %w", p.Timezone, err)
+		}
+	}
+	if p.Rate.CrawlWindows != "" {
+		if _, err := schedule.ParseWindows(p.Rate.CrawlWindows, loc); err != nil {
+			return err
+		}
+	}
+
+	// Reject selector overrides for fields the parser doesn't know
+	if err := parser.NewParser(false).SetSite(p.ParserSite()); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// ParserSite returns the parser rules for this site.
+func (p *Profile) ParserSite() parser.Site {
+	return parser.Site{
+		JournalCN: p.Journal.CN,
+		JournalEN: p.Journal.EN,
+		Selectors: p.Selectors,
+	}
+}
+
+// Owns reports whether u is on one of the site's hosts or their subdomains.
+func (p *Profile) Owns(u string) bool {
+	parsed, err := url.Parse(u)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Hostname())
+	for _, owned := range p.Hosts {
+		owned = strings.ToLower(owned)
+		if host == owned || strings.HasSuffix(host, "."+owned) {
+			return true
+		}
+	}
+	return false
+}
+
+// For returns the first of profiles that owns u, or the first profile when
+// none does.
+func For(profiles []*Profile, u string) *Profile {
+	for _, p := range profiles {
+		if p.Owns(u) {
+			return p
+		}
+	}
+	return profiles[0]
+}
+
+// IsArticleURL reports whether u is an article page on this site.
+func (p *Profile) IsArticleURL(u string) bool {
+	for _, re := range p.articlePatterns {
+		if re.MatchString(u) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package publish
+
+import (
+	"bytes"
+	"cmp"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/storage"
+)
+
+// Options describe a site to publish.
+type Options struct {
+	// Title is shown on every page
+	Title string
+	// BaseURL is the address the site is served from; with it, pages get
+	// canonical links and the site a sitemap
+	BaseURL string
+}
+
+// Stats counts the pages of a published site.
+type Stats struct {
+	Articles int
+	Issues   int
+	// Sitemaps counts the sitemap files written, none without a BaseURL
+	Sitemaps int
+}
+
+// year is one year of the site's index, with its issues in order.
+type year struct {
+	Year   string
+	Issues []*issue
+}
+
+// issue is one issue page: an entry of the issue index, or the records
+// without a volume or issue.
+type issue struct {
+	Page     string
+	Journal  string
+	Volume   string
+	Issue    string
+	Year     string
+	Pages    string
+	Articles []*parser.PaperMetadata
+}
+
+// Label names the issue as printed on its journal.
+func (i *issue) Label() string {
+	if i.Volume == "" {
+		return "Articles without volume or issue"
+	}
+	return fmt.Sprintf("Vol. %s, No. %s", i.Volume, i.Issue)
+}
+
+var unsafeSlug = regexp.MustCompile(`[^A-Za-z0-9]+`)
+
+// Write renders records as a static site under dir: index.html, listing
+// the issues by year, one page per issue under issues/ and one per article
+// under articles/, all linked with relative links so the directory can be
+// served from anywhere or opened straight from disk, and with a BaseURL
+// sitemap.xml. Records must hold one per ID.
+func Write(dir string, opts Options, records []*parser.PaperMetadata) (*Stats, error) {
+	backend := storage.NewLocalBackend(dir)
+	title := opts.Title
+	base := opts.BaseURL
+	if base != "" && !strings.HasSuffix(base, "/") {
+		base += "/"
+	}
+	var urls []sitemapURL
+	byID := make(map[string]*parser.PaperMetadata, len(records))
+	for _, m := range records {
+		byID[m.ID] = m
+	}
+
+	idx := index.BuildIssues(records)
+	journals := make(map[string]bool)
+	for _, entry := range idx.Issues {
+		journals[entry.Journal] = true
+	}
+
+	var issues []*issue
+	placed := make(map[string]*issue)
+	for _, entry := range idx.Issues {
+		page := fmt.Sprintf("v%s-n%s", slug(entry.Volume), slug(entry.Issue))
+		if len(journals) > 1 {
+			// Journal names are mostly Chinese, so several journals are
+			// told apart by a hash of the name
+			sum := sha256.Sum256([]byte(entry.Journal))
+			page = hex.EncodeToString(sum[:4]) + "-" + page
+		}
+		i := &issue{Page: page, Journal: entry.Journal, Volume: entry.Volume, Issue: entry.Issue, Year: entry.Year}
+		if entry.FirstPage != "" {
+			i.Pages = entry.FirstPage + "-" + entry.LastPage
+		}
+		for _, id := range entry.ArticleIDs {
+			i.Articles = append(i.Articles, byID[id])
+			placed[id] = i
+		}
+		issues = append(issues, i)
+	}
+	if idx.Unplaced > 0 {
+		unplaced := &issue{Page: "unplaced"}
+		for _, m := range records {
+			if placed[m.ID] == nil {
+				unplaced.Articles = append(unplaced.Articles, m)
+				placed[m.ID] = unplaced
+			}
+		}
+		issues = append(issues, unplaced)
+	}
+
+	// Newest years first, issues without a year last
+	var years []*year
+	byYear := make(map[string]*year)
+	for _, i := range issues {
+		y := byYear[i.Year]
+		if y == nil {
+			y = &year{Year: i.Year}
+			byYear[i.Year] = y
+			years = append(years, y)
+		}
+		y.Issues = append(y.Issues, i)
+	}
+	slices.SortStableFunc(years, func(a, b *year) int {
+		return cmp.Compare(b.Year, a.Year)
+	})
+
+	render := func(name, pageTitle, tmpl string, data map[string]any, lastMod string) error {
+		data["Title"] = title
+		data["PageTitle"] = pageTitle
+		data["Root"] = strings.Repeat("../", strings.Count(name, "/"))
+		if base != "" {
+			loc := base + pathEscape(name)
+			if name == "index.html" {
+				loc = base
+			}
+			data["Canonical"] = loc
+			urls = append(urls, sitemapURL{Loc: loc, LastMod: lastMod})
+		}
+		var buf bytes.Buffer
+		if err := templates.ExecuteTemplate(&buf, tmpl, data); err != nil {
+			return fmt.Errorf("failed to render %s: %w", name, err)
+		}
+		return backend.WriteFile(name, buf.Bytes())
+	}
+
+	if err := backend.WriteFile("style.css", []byte(stylesheet)); err != nil {
+		return nil, err
+	}
+	err := render("index.html", title, "index", map[string]any{
+		"Years":    years,
+		"Articles": len(records),
+		"Journals": len(journals) > 1,
+	}, lastParsed(records))
+	if err != nil {
+		return nil, err
+	}
+	for _, i := range issues {
+		err := render("issues/"+i.Page+".html", i.Label()+" - "+title, "issue", map[string]any{"Issue": i}, lastParsed(i.Articles))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, m := range records {
+		var notices []*parser.PaperMetadata
+		for _, id := range m.Notices {
+			if notice := byID[id]; notice != nil {
+				notices = append(notices, notice)
+			}
+		}
+		var citedBy []*parser.PaperMetadata
+		for _, id := range m.CitedBy {
+			if citing := byID[id]; citing != nil {
+				citedBy = append(citedBy, citing)
+			}
+		}
+		err := render("articles/"+m.ID+".html", articleTitle(m)+" - "+title, "article", map[string]any{
+			"Article":  m,
+			"Issue":    placed[m.ID],
+			"Corrects": byID[m.CorrectsID],
+			"Notices":  notices,
+			"CitedBy":  citedBy,
+		}, m.ParsedAt)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	stats := &Stats{Articles: len(records), Issues: len(issues)}
+	if base != "" {
+		if stats.Sitemaps, err = writeSitemaps(backend, base, urls); err != nil {
+			return nil, err
+		}
+	}
+	return stats, nil
+}
+
+// lastParsed returns when the most recently parsed of records was.
+func lastParsed(records []*parser.PaperMetadata) string {
+	var last string
+	for _, m := range records {
+		last = max(last, m.ParsedAt)
+	}
+	return last
+}
+
+// pathEscape escapes each segment of a slash-separated path.
+func pathEscape(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// slug makes a volume or issue number safe for a file name.
+func slug(s string) string {
+	return cmp.Or(strings.Trim(unsafeSlug.ReplaceAllString(s, "_"), "_"), "_")
+}
+
+// articlePage is the link to an article's page from another page in its
+// directory.
+func articlePage(id string) string {
+	return url.PathEscape(id) + ".html"
+}
+
+// articleTitle returns a record's Chinese title, or its English one.
+func articleTitle(m *parser.PaperMetadata) string {
+	return cmp.Or(m.TitleCN, m.TitleEN, m.ID)
+}
+
+// referenceLink returns where a reference entry links to: the cited
+// article's page when it is in the corpus, otherwise its DOI or URL.
+func referenceLink(ref parser.Reference) string {
+	switch {
+	case ref.ArticleID != "":
+		return articlePage(ref.ArticleID)
+	case ref.DOI != "":
+		return "https://doi.org/" + ref.DOI
+	}
+	return ref.URL
+}
@@ -0,0 +1,62 @@
+package publish
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"gtft-crawler/internal/storage"
+)
+
+// sitemapLimit is how many URLs one sitemap file may list.
+const sitemapLimit = 50000
+
+const sitemapNamespace = "http://www.sitemaps.org/schemas/sitemap/0.9"
+
+type sitemapURL struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+type urlSet struct {
+	XMLName xml.Name     `xml:"urlset"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	URLs    []sitemapURL `xml:"url"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapURL `xml:"sitemap"`
+}
+
+// writeSitemaps lists urls in sitemap.xml, or, when there are more than
+// one file may hold, in sitemap-1.xml, sitemap-2.xml, ... indexed by
+// sitemap.xml. It returns the number of files written.
+func writeSitemaps(backend *storage.LocalBackend, baseURL string, urls []sitemapURL) (int, error) {
+	if len(urls) <= sitemapLimit {
+		return 1, writeXML(backend, "sitemap.xml", urlSet{XMLNS: sitemapNamespace, URLs: urls})
+	}
+
+	index := sitemapIndex{XMLNS: sitemapNamespace}
+	for start := 0; start < len(urls); start += sitemapLimit {
+		part := urls[start:min(start+sitemapLimit, len(urls))]
+		name := fmt.Sprintf("sitemap-%d.xml", len(index.Sitemaps)+1)
+		if err := writeXML(backend, name, urlSet{XMLNS: sitemapNamespace, URLs: part}); err != nil {
+			return 0, err
+		}
+		var lastMod string
+		for _, u := range part {
+			lastMod = max(lastMod, u.LastMod)
+		}
+		index.Sitemaps = append(index.Sitemaps, sitemapURL{Loc: baseURL + name, LastMod: lastMod})
+	}
+	return len(index.Sitemaps) + 1, writeXML(backend, "sitemap.xml", index)
+}
+
+func writeXML(backend *storage.LocalBackend, name string, v any) error {
+	data, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode %s: %w", name, err)
+	}
+	return backend.WriteFile(name, append([]byte(xml.Header), append(data, '\n')...))
+}
@@ -0,0 +1,117 @@
+package publish
+
+import (
+	"html/template"
+	"strings"
+)
+
+var templates = template.Must(template.New("").Funcs(template.FuncMap{
+	"articlePage":   articlePage,
+	"articleTitle":  articleTitle,
+	"referenceLink": referenceLink,
+	"join":          strings.Join,
+}).Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.PageTitle}}</title>
+<link rel="stylesheet" href="{{.Root}}style.css">
+{{with .Canonical}}<link rel="canonical" href="{{.}}">
+{{end}}{{with .Article}}<meta name="citation_title" content="{{articleTitle .}}">
+{{range .Authors}}<meta name="citation_author" content="{{.Name}}">
+{{end}}{{with .JournalCN}}<meta name="citation_journal_title" content="{{.}}">
+{{end}}{{with .ISSN}}<meta name="citation_issn" content="{{.}}">
+{{end}}{{with .Year}}<meta name="citation_year" content="{{.}}">
+{{end}}{{with .Date}}<meta name="citation_date" content="{{.}}">
+{{end}}{{with .Volume}}<meta name="citation_volume" content="{{.}}">
+{{end}}{{with .Issue}}<meta name="citation_issue" content="{{.}}">
+{{end}}{{with .FirstPage}}<meta name="citation_firstpage" content="{{.}}">
+{{end}}{{with .LastPage}}<meta name="citation_lastpage" content="{{.}}">
+{{end}}{{with .DOI}}<meta name="citation_doi" content="{{.}}">
+{{end}}{{with .KeywordsCN}}<meta name="citation_keywords" content="{{join . ", "}}">
+{{end}}{{with .PDFURL}}<meta name="citation_pdf_url" content="{{.}}">
+{{end}}{{end}}</head>
+{{end}}
+
+{{define "index"}}{{template "head" .}}<body>
+<h1>{{.Title}}</h1>
+<p class="meta">{{.Articles}} articles</p>
+{{range .Years}}<h2>{{or .Year "Undated"}}</h2>
+<ul>
+{{range .Issues}}<li><a href="issues/{{.Page}}.html">{{if $.Journals}}{{.Journal}} {{end}}{{.Label}}</a> ({{len .Articles}})</li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+{{end}}
+
+{{define "issue"}}{{template "head" .}}<body>
+<p class="nav"><a href="../index.html">{{.Title}}</a></p>
+{{with .Issue}}<h1>{{.Label}}</h1>
+<p class="meta">{{with .Journal}}{{.}} · {{end}}{{with .Year}}{{.}} · {{end}}{{with .Pages}}pp. {{.}} · {{end}}{{len .Articles}} articles</p>
+<ol class="articles">
+{{range .Articles}}<li><a href="../articles/{{articlePage .ID}}">{{articleTitle .}}</a>{{if .IsRetraction}} <span class="flag">Retraction</span>{{else if .IsCorrection}} <span class="flag">Correction</span>{{end}}
+<div class="meta">{{range $i, $a := .Authors}}{{if $i}}, {{end}}{{$a.Name}}{{end}}{{with .Pages}} · pp. {{.}}{{end}}</div></li>
+{{end}}</ol>
+{{end}}</body>
+</html>
+{{end}}
+
+{{define "article"}}{{template "head" .}}<body>
+<p class="nav"><a href="../index.html">{{.Title}}</a>{{with .Issue}} › <a href="../issues/{{.Page}}.html">{{.Label}}</a>{{end}}</p>
+{{with .Article}}{{if .Removed}}<p class="notice">This article is no longer available on the journal's site (HTTP {{.Removed.StatusCode}} since {{.Removed.RemovedAt}}).</p>
+{{end}}<h1>{{articleTitle .}}</h1>
+{{if and .TitleCN .TitleEN}}<p class="subtitle" lang="en">{{.TitleEN}}</p>
+{{end}}{{end}}{{if .Article.IsRetraction}}<p class="notice">Retraction notice{{with .Corrects}} for <a href="{{articlePage .ID}}">{{articleTitle .}}</a>{{else}}{{with .Article.CorrectsTitle}} for {{.}}{{end}}{{end}}</p>
+{{else if .Article.IsCorrection}}<p class="notice">Correction notice{{with .Corrects}} for <a href="{{articlePage .ID}}">{{articleTitle .}}</a>{{else}}{{with .Article.CorrectsTitle}} for {{.}}{{end}}{{end}}</p>
+{{end}}{{range .Notices}}<p class="notice">{{if .IsRetraction}}Retracted{{else}}Corrected{{end}}: see <a href="{{articlePage .ID}}">{{articleTitle .}}</a></p>
+{{end}}{{with .Article}}{{if .Authors}}<ul class="authors">
+{{range .Authors}}<li>{{.Name}}{{with .NameEN}} <span lang="en">({{.}})</span>{{end}}{{with .Affiliation}} <span class="meta">{{.}}</span>{{end}}</li>
+{{end}}</ul>
+{{end}}<dl>
+{{with .JournalCN}}<dt>Journal</dt><dd>{{.}}{{with $.Article.JournalEN}} ({{.}}){{end}}</dd>
+{{end}}{{with .Volume}}<dt>Volume</dt><dd>{{.}}{{with $.Article.Issue}}, No. {{.}}{{end}}</dd>
+{{end}}{{with .Pages}}<dt>Pages</dt><dd>{{.}}</dd>
+{{end}}{{with .Date}}<dt>Published</dt><dd>{{.}}</dd>
+{{end}}{{with .DOI}}<dt>DOI</dt><dd><a href="https://doi.org/{{.}}">{{.}}</a></dd>
+{{end}}{{with .FundProject}}<dt>Funding</dt><dd>{{.}}</dd>
+{{end}}{{with .CLCCode}}<dt>CLC</dt><dd>{{.}}</dd>
+{{end}}{{with .License}}<dt>License</dt><dd>{{if $.Article.LicenseURL}}<a href="{{$.Article.LicenseURL}}">{{.}}</a>{{else}}{{.}}{{end}}</dd>
+{{end}}<dt>Source</dt><dd><a href="{{.URL}}">{{.URL}}</a></dd>
+{{with .PDFURL}}<dt>PDF</dt><dd><a href="{{.}}">{{.}}</a>{{with $.Article.PDFSize}} ({{.}}){{end}}</dd>
+{{end}}</dl>
+{{with .AbstractCN}}<h2>摘要</h2>
+<p>{{.}}</p>
+{{end}}{{with .KeywordsCN}}<p class="keywords"><strong>关键词：</strong>{{join . "；"}}</p>
+{{end}}{{with .AbstractEN}}<h2>Abstract</h2>
+<p lang="en">{{.}}</p>
+{{end}}{{with .KeywordsEN}}<p class="keywords" lang="en"><strong>Keywords:</strong> {{join . "; "}}</p>
+{{end}}{{with .References}}<h2>References</h2>
+<ol class="references">
+{{range .}}<li>{{with referenceLink .}}<a href="{{.}}">{{end}}{{.Text}}{{if referenceLink .}}</a>{{end}}</li>
+{{end}}</ol>
+{{end}}{{end}}{{with .CitedBy}}<h2>Cited By</h2>
+<ul>
+{{range .}}<li><a href="{{articlePage .ID}}">{{articleTitle .}}</a></li>
+{{end}}</ul>
+{{end}}</body>
+</html>
+{{end}}
+`))
+
+const stylesheet = `body { max-width: 50em; margin: 2em auto; padding: 0 1em; font-family: sans-serif; line-height: 1.6; color: #222; }
+a { color: #1a5599; }
+h1 { font-size: 1.5em; }
+h2 { font-size: 1.2em; margin-top: 1.5em; }
+.nav, .meta { color: #666; font-size: 0.9em; }
+.subtitle { font-size: 1.1em; color: #444; }
+.articles li { margin-bottom: 0.8em; }
+.authors { list-style: none; padding: 0; }
+.flag { font-size: 0.8em; color: #a00; }
+.notice { padding: 0.5em 1em; background: #fdecea; border-left: 4px solid #a00; }
+dl { display: grid; grid-template-columns: max-content auto; gap: 0.2em 1em; }
+dt { font-weight: bold; }
+dd { margin: 0; }
+.references { font-size: 0.9em; }
+`
@@ -0,0 +1,194 @@
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Emails is the pseudo-field that scrubs email addresses from every string
+// in the record rather than clearing one field.
+const Emails = "emails"
+
+var emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+
+// Rule redacts one field, named by its JSON path (e.g. "fund_project" or
+// "authors.affiliation"). Hash replaces the value with a salted digest so
+// equal values stay linkable; otherwise the field is cleared.
+type Rule struct {
+	Field string
+	Hash  bool
+}
+
+// Redactor applies redaction rules to records before they are saved.
+type Redactor struct {
+	rules []Rule
+	salt  string
+}
+
+// Parse builds a Redactor from a comma-separated list such as
+// "emails,fund_project,authors.name:hash". Unknown fields are an error.
+func Parse(spec, salt string) (*Redactor, error) {
+	r := &Redactor{salt: salt}
+
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+
+		field, mode, _ := strings.Cut(item, ":")
+		rule := Rule{Field: field}
+		switch mode {
+		case "", "remove":
+		case "hash":
+			rule.Hash = true
+		default:
+			return nil, fmt.Errorf("unknown redaction mode %q for %s (use remove or hash)", mode, field)
+		}
+
+		// The ID names the record file, and records missing these fail validation
+		switch {
+		case field == "id":
+			return nil, fmt.Errorf("id can't be redacted")
+		case !rule.Hash && (field == "title_cn" || field == "authors" || field == "journal_cn"):
+			return nil, fmt.Errorf("%s is required in saved records; use %s:hash instead", field, field)
+		}
+
+		if field != Emails {
+			if _, ok := lookup(reflect.TypeOf(parser.PaperMetadata{}), strings.Split(field, ".")); !ok {
+				return nil, fmt.Errorf("unknown field %q", field)
+			}
+		}
+		r.rules = append(r.rules, rule)
+	}
+
+	return r, nil
+}
+
+// lookup reports whether path names a string, string slice or number field,
+// following slices of structs for nested paths.
+func lookup(t reflect.Type, path []string) (reflect.StructField, bool) {
+	for t.Kind() == reflect.Slice || t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return reflect.StructField{}, false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if jsonName(f) != path[0] {
+			continue
+		}
+		if len(path) == 1 {
+			return f, true
+		}
+		return lookup(f.Type, path[1:])
+	}
+	return reflect.StructField{}, false
+}
+
+func jsonName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+	if name == "" {
+		return f.Name
+	}
+	return name
+}
+
+// Apply redacts metadata in place.
+func (r *Redactor) Apply(metadata *parser.PaperMetadata) {
+	v := reflect.ValueOf(metadata).Elem()
+	for _, rule := range r.rules {
+		if rule.Field == Emails {
+			r.scrubEmails(v, rule.Hash)
+			continue
+		}
+		r.apply(v, strings.Split(rule.Field, "."), rule.Hash)
+	}
+}
+
+func (r *Redactor) apply(v reflect.Value, path []string, hash bool) {
+	switch v.Kind() {
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			r.apply(v.Index(i), path, hash)
+		}
+		return
+	case reflect.Struct:
+	default:
+		return
+	}
+
+	for i := 0; i < v.NumField(); i++ {
+		if jsonName(v.Type().Field(i)) != path[0] {
+			continue
+		}
+		field := v.Field(i)
+		if len(path) > 1 {
+			r.apply(field, path[1:], hash)
+		} else {
+			r.redactValue(field, hash)
+		}
+		return
+	}
+}
+
+func (r *Redactor) redactValue(v reflect.Value, hash bool) {
+	if !hash || v.IsZero() {
+		v.Set(reflect.Zero(v.Type()))
+		return
+	}
+
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(r.hash(v.String()))
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.String {
+			for i := 0; i < v.Len(); i++ {
+				v.Index(i).SetString(r.hash(v.Index(i).String()))
+			}
+			return
+		}
+		// Only strings can be hashed; clear anything else
+		v.Set(reflect.Zero(v.Type()))
+	default:
+		v.Set(reflect.Zero(v.Type()))
+	}
+}
+
+// scrubEmails walks every string in v, replacing email addresses.
+func (r *Redactor) scrubEmails(v reflect.Value, hash bool) {
+	switch v.Kind() {
+	case reflect.String:
+		s := v.String()
+		if !strings.Contains(s, "@") {
+			return
+		}
+		v.SetString(emailPattern.ReplaceAllStringFunc(s, func(email string) string {
+			if hash {
+				return r.hash(strings.ToLower(email))
+			}
+			return "[email redacted]"
+		}))
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			r.scrubEmails(v.Index(i), hash)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			r.scrubEmails(v.Field(i), hash)
+		}
+	}
+}
+
+func (r *Redactor) hash(value string) string {
+	sum := sha256.Sum256([]byte(r.salt + value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
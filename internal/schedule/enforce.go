@@ -0,0 +1,46 @@
+package schedule
+
+import (
+	"fmt"
+	"time"
+)
+
+// checkInterval is how often the crawl windows are checked.
+const checkInterval = 30 * time.Second
+
+// Pausable is a crawl that can stop starting new work for a while.
+type Pausable interface {
+	Pause()
+	Resume()
+}
+
+// Enforce pauses crawl whenever the windows are closed and resumes it when
+// they open, until stop is closed. It only resumes pauses it made itself, so
+// a crawl resumed by an operator outside the windows keeps running until
+// the next window ends.
+func Enforce(windows *Windows, crawl Pausable, stop <-chan struct{}) {
+	ticker := time.NewTicker(checkInterval)
+	defer ticker.Stop()
+
+	paused := false
+	for {
+		now := time.Now()
+		open := windows.Open(now)
+		switch {
+		case !open && !paused:
+			crawl.Pause()
+			paused = true
+			fmt.Printf("[Window] Outside crawl windows (%s); pausing until %s\n", windows, windows.NextOpen(now).Format("2006-01-02 15:04 MST"))
+		case open && paused:
+			crawl.Resume()
+			paused = false
+			fmt.Printf("[Window] Crawl window open; resuming\n")
+		}
+
+		select {
+		case <-ticker.C:
+		case <-stop:
+			return
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Package schedule restricts crawling to configured times of day.
+package schedule
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	// Site time zones must resolve on hosts without zoneinfo
+	_ "time/tzdata"
+)
+
+const minutesPerDay = 24 * 60
+
+// span is a daily window in minutes after midnight. end is exclusive and may
+// be less than start for a window that crosses midnight.
+type span struct {
+	start, end int
+}
+
+func (s span) contains(minute int) bool {
+	if s.start < s.end {
+		return minute >= s.start && minute < s.end
+	}
+	return minute >= s.start || minute < s.end
+}
+
+// Windows are the times of day crawling is allowed, in one time zone.
+type Windows struct {
+	spans []span
+	loc   *time.Location
+	spec  string
+}
+
+// ParseWindows parses comma-separated "HH:MM-HH:MM" windows, e.g.
+// "01:00-06:00,22:00-23:30", in loc. A window may cross midnight
+// ("22:00-02:00"), and 24:00 may end a window.
+func ParseWindows(spec string, loc *time.Location) (*Windows, error) {
+	w := &Windows{loc: loc, spec: spec}
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		from, to, ok := strings.Cut(item, "-")
+		if !ok {
+			return nil, fmt.Errorf("invalid crawl window %q: want HH:MM-HH:MM", item)
+		}
+		start, err := parseClock(from)
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl window %q: %w", item, err)
+		}
+		end, err := parseClock(to)
+		if err != nil {
+			return nil, fmt.Errorf("invalid crawl window %q: %w", item, err)
+		}
+		if start == minutesPerDay || start == end%minutesPerDay {
+			return nil, fmt.Errorf("invalid crawl window %q: empty or whole-day window", item)
+		}
+		w.spans = append(w.spans, span{start: start, end: end % minutesPerDay})
+	}
+	if len(w.spans) == 0 {
+		return nil, fmt.Errorf("no crawl windows in %q", spec)
+	}
+	return w, nil
+}
+
+// parseClock parses HH:MM into minutes after midnight.
+func parseClock(s string) (int, error) {
+	var hour, minute int
+	if _, err := fmt.Sscanf(strings.TrimSpace(s), "%d:%d", &hour, &minute); err != nil {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	if hour < 0 || minute < 0 || minute > 59 || hour > 24 || (hour == 24 && minute != 0) {
+		return 0, fmt.Errorf("invalid time %q", s)
+	}
+	return hour*60 + minute, nil
+}
+
+// Open reports whether crawling is allowed at t.
+func (w *Windows) Open(t time.Time) bool {
+	local := t.In(w.loc)
+	minute := local.Hour()*60 + local.Minute()
+	for _, s := range w.spans {
+		if s.contains(minute) {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen returns when the next window starts after t.
+func (w *Windows) NextOpen(t time.Time) time.Time {
+	local := t.In(w.loc)
+	var next time.Time
+	for day := 0; day <= 1; day++ {
+		for _, s := range w.spans {
+			start := time.Date(local.Year(), local.Month(), local.Day()+day, s.start/60, s.start%60, 0, 0, w.loc)
+			if start.After(t) && (next.IsZero() || start.Before(next)) {
+				next = start
+			}
+		}
+	}
+	return next
+}
+
+func (w *Windows) String() string {
+	return w.spec + " " + w.loc.String()
+}
@@ -0,0 +1,266 @@
+// Package server exposes health, readiness, control and metrics endpoints
+// so orchestration systems can manage a long-running crawl.
+package server
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"gtft-crawler/internal/metrics"
+)
+
+// Controller is the crawl being managed.
+type Controller interface {
+	Pause()
+	Resume()
+	Paused() bool
+	// Throttled reports whether the crawl is waiting out a pause the site
+	// asked for
+	Throttled() bool
+	SetRate(requestsPerSecond int) error
+	Rate() int
+	// StopIntake stops the crawl gracefully: in-flight URLs finish and
+	// the run's results are saved
+	StopIntake()
+}
+
+// Status is the crawl state reported by /control.
+type Status struct {
+	State string `json:"state"`
+	Rate  int    `json:"rate,omitempty"`
+}
+
+const (
+	stateIdle      = "idle"
+	stateRunning   = "running"
+	statePaused    = "paused"
+	stateThrottled = "throttled"
+	stateStopping  = "stopping"
+)
+
+// Server serves the endpoints for the process's lifetime; each crawl run is
+// attached while it is running.
+type Server struct {
+	http    *http.Server
+	token   string
+	verbose bool
+
+	mu        sync.Mutex
+	crawl     Controller
+	registry  *metrics.Registry
+	namespace string
+	stopped   bool
+	stop      chan struct{}
+}
+
+// New creates a server listening on addr. When token is set, /control
+// requires it as a bearer token.
+func New(addr, token, namespace string, verbose bool) *Server {
+	s := &Server{
+		token:     token,
+		namespace: namespace,
+		verbose:   verbose,
+		stop:      make(chan struct{}),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.handleHealth)
+	mux.HandleFunc("GET /readyz", s.handleReady)
+	mux.HandleFunc("GET /control", s.handleStatus)
+	mux.HandleFunc("POST /control", s.handleControl)
+	mux.HandleFunc("GET /metrics", s.handleMetrics)
+
+	s.http = &http.Server{
+		Addr:              addr,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+// Start listens and serves in the background.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.http.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.http.Addr, err)
+	}
+
+	go func() {
+		if err := s.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("[Server] %v\n", err)
+		}
+	}()
+	return nil
+}
+
+// Attach makes crawl the run being managed and registry the metrics
+// served, and marks the server ready.
+func (s *Server) Attach(crawl Controller, registry *metrics.Registry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.crawl = crawl
+	s.registry = registry
+	if s.stopped {
+		crawl.StopIntake()
+	}
+}
+
+// Detach ends management of the current run; the server is not ready
+// until the next Attach.
+func (s *Server) Detach() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.crawl = nil
+}
+
+// Stopped is closed when a stop is requested through /control.
+func (s *Server) Stopped() <-chan struct{} {
+	return s.stop
+}
+
+// Close shuts the server down.
+func (s *Server) Close() error {
+	return s.http.Close()
+}
+
+func (s *Server) status() Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.stopped:
+		return Status{State: stateStopping}
+	case s.crawl == nil:
+		return Status{State: stateIdle}
+	case s.crawl.Paused():
+		return Status{State: statePaused, Rate: s.crawl.Rate()}
+	case s.crawl.Throttled():
+		return Status{State: stateThrottled, Rate: s.crawl.Rate()}
+	default:
+		return Status{State: stateRunning, Rate: s.crawl.Rate()}
+	}
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	fmt.Fprintln(w, "ok")
+}
+
+// handleReady reports ready while a run is crawling or paused. Between
+// -watch runs and once a stop is requested it answers 503.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	status := s.status()
+	if status.State != stateRunning && status.State != statePaused && status.State != stateThrottled {
+		http.Error(w, status.State, http.StatusServiceUnavailable)
+		return
+	}
+	fmt.Fprintln(w, "ok")
+}
+
+func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+	writeStatus(w, s.status())
+}
+
+// handleControl applies the action form value: pause, resume, set-rate
+// (with rate) or stop.
+func (s *Server) handleControl(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(w, r) {
+		return
+	}
+
+	action := r.FormValue("action")
+	if action == "stop" {
+		s.requestStop()
+		writeStatus(w, s.status())
+		return
+	}
+
+	s.mu.Lock()
+	crawl, stopped := s.crawl, s.stopped
+	s.mu.Unlock()
+	if crawl == nil || stopped {
+		http.Error(w, "no crawl is running", http.StatusConflict)
+		return
+	}
+
+	switch action {
+	case "pause":
+		crawl.Pause()
+	case "resume":
+		crawl.Resume()
+	case "set-rate":
+		rate, err := strconv.Atoi(r.FormValue("rate"))
+		if err != nil {
+			http.Error(w, "rate must be an integer", http.StatusBadRequest)
+			return
+		}
+		if err := crawl.SetRate(rate); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	default:
+		http.Error(w, fmt.Sprintf("unknown action %q (want pause, resume, set-rate or stop)", action), http.StatusBadRequest)
+		return
+	}
+
+	if s.verbose {
+		fmt.Printf("[Server] Control: %s\n", action)
+	}
+	writeStatus(w, s.status())
+}
+
+func (s *Server) requestStop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.stopped {
+		return
+	}
+	s.stopped = true
+	close(s.stop)
+	if s.crawl != nil {
+		s.crawl.StopIntake()
+	}
+	fmt.Println("[Server] Stop requested: finishing in-flight URLs...")
+}
+
+func (s *Server) handleMetrics(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	registry := s.registry
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	if registry == nil {
+		return
+	}
+	if err := metrics.WritePrometheus(w, s.namespace, registry.Gather()); err != nil && s.verbose {
+		fmt.Printf("[Server] Failed to write metrics: %v\n", err)
+	}
+}
+
+func (s *Server) authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+s.token)) == 1 {
+		return true
+	}
+	http.Error(w, "unauthorized", http.StatusUnauthorized)
+	return false
+}
+
+func writeStatus(w http.ResponseWriter, status Status) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(status)
+}
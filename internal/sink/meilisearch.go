@@ -0,0 +1,90 @@
+package sink
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// meilisearchSettings makes titles rank above keywords and abstracts, lets
+// results be filtered by year, journal, keyword and author, and tags the
+// Chinese fields so they are segmented as Mandarin. Typo tolerance is on by
+// default.
+var meilisearchSettings = map[string]any{
+	"searchableAttributes": []string{
+		"title_cn", "title_en",
+		"keywords_cn", "keywords_en",
+		"authors",
+		"abstract_cn", "abstract_en",
+		"doi",
+	},
+	"filterableAttributes": []string{"year", "journal_cn", "keywords_cn", "authors"},
+	"sortableAttributes":   []string{"year", "citations", "views", "downloads"},
+	"localizedAttributes": []map[string]any{
+		{"attributePatterns": []string{"*_cn", "authors"}, "locales": []string{"cmn"}},
+		{"attributePatterns": []string{"*_en"}, "locales": []string{"eng"}},
+	},
+}
+
+// Meilisearch indexes each saved record into a Meilisearch index, creating
+// the index and its settings if needed.
+type Meilisearch struct {
+	*searchClient
+	index string
+}
+
+// NewMeilisearch connects to the Meilisearch server at baseURL (e.g.
+// http://localhost:7700) and prepares the index. apiKey may be empty for an
+// unsecured server.
+func NewMeilisearch(baseURL, index, apiKey string) (*Meilisearch, error) {
+	headers := map[string]string{}
+	if apiKey != "" {
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+
+	m := &Meilisearch{
+		searchClient: newSearchClient("meilisearch", baseURL, headers),
+		index:        index,
+	}
+	m.send = m.addDocuments
+
+	if _, err := m.do(http.MethodGet, "/health", "application/json", nil); err != nil {
+		return nil, err
+	}
+
+	// Creating an index that already exists fails asynchronously, so the
+	// request is harmless on later runs
+	create, err := json.Marshal(map[string]string{"uid": index, "primaryKey": "id"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode index: %w", err)
+	}
+	if _, err := m.do(http.MethodPost, "/indexes", "application/json", create); err != nil {
+		return nil, err
+	}
+
+	settings, err := json.Marshal(meilisearchSettings)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if _, err := m.do(http.MethodPatch, m.indexPath("/settings"), "application/json", settings); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+func (m *Meilisearch) indexPath(suffix string) string {
+	return "/indexes/" + url.PathEscape(m.index) + suffix
+}
+
+// addDocuments adds or replaces documents. Meilisearch applies them
+// asynchronously; failures show up in its task list.
+func (m *Meilisearch) addDocuments(docs []searchDocument) error {
+	body, err := json.Marshal(docs)
+	if err != nil {
+		return fmt.Errorf("failed to encode documents: %w", err)
+	}
+	_, err = m.do(http.MethodPost, m.indexPath("/documents?primaryKey=id"), "application/json", body)
+	return err
+}
@@ -0,0 +1,79 @@
+package sink
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Redis stores each saved record in a hash at {prefix}:article:{id} and
+// announces it on the {prefix}:records stream, so downstream services can
+// consume results while the crawl runs.
+type Redis struct {
+	client *redis.Client
+	prefix string
+}
+
+// NewRedis connects to a redis:// or rediss:// URL, e.g.
+// redis://:password@localhost:6379/0.
+func NewRedis(redisURL, prefix string) (*Redis, error) {
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid redis URL: %w", err)
+	}
+
+	client := redis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("redis connection failed: %w", err)
+	}
+
+	return &Redis{client: client, prefix: prefix}, nil
+}
+
+func (r *Redis) Write(metadata *parser.PaperMetadata) error {
+	record, err := json.Marshal(metadata)
+	if err != nil {
+		return fmt.Errorf("failed to encode record: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	// Hash and stream entry are written together so consumers reading the
+	// stream always find the hash populated
+	_, err = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.HSet(ctx, r.prefix+":article:"+metadata.ID,
+			"record", record,
+			"title", metadata.TitleCN,
+			"doi", metadata.DOI,
+			"url", metadata.URL,
+			"parsed_at", metadata.ParsedAt,
+		)
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: r.prefix + ":records",
+			Values: map[string]any{
+				"id":     metadata.ID,
+				"record": record,
+			},
+		})
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("redis write failed: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Redis) Close() error {
+	return r.client.Close()
+}
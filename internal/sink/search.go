@@ -0,0 +1,164 @@
+package sink
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"gtft-crawler/internal/parser"
+)
+
+// searchBatchSize is how many documents are buffered before they are sent
+// to a search engine
+const searchBatchSize = 100
+
+// searchDocument is the flattened form of a record indexed by the search
+// sinks. ID is restricted to characters both engines accept; ArticleID is
+// the record's own ID.
+type searchDocument struct {
+	ID         string   `json:"id"`
+	ArticleID  string   `json:"article_id"`
+	URL        string   `json:"url"`
+	TitleCN    string   `json:"title_cn"`
+	TitleEN    string   `json:"title_en,omitempty"`
+	AbstractCN string   `json:"abstract_cn,omitempty"`
+	AbstractEN string   `json:"abstract_en,omitempty"`
+	KeywordsCN []string `json:"keywords_cn,omitempty"`
+	KeywordsEN []string `json:"keywords_en,omitempty"`
+	Authors    []string `json:"authors"`
+	JournalCN  string   `json:"journal_cn"`
+	Year       int      `json:"year,omitempty"`
+	Volume     string   `json:"volume,omitempty"`
+	Issue      string   `json:"issue,omitempty"`
+	DOI        string   `json:"doi,omitempty"`
+	Views      int      `json:"views"`
+	Downloads  int      `json:"downloads"`
+	Citations  int      `json:"citations"`
+}
+
+var searchIDPattern = regexp.MustCompile(`[^A-Za-z0-9_-]`)
+
+func newSearchDocument(metadata *parser.PaperMetadata) searchDocument {
+	authors := make([]string, 0, len(metadata.Authors))
+	for _, author := range metadata.Authors {
+		authors = append(authors, author.Name)
+	}
+	year, _ := strconv.Atoi(strings.TrimSpace(metadata.Year))
+
+	return searchDocument{
+		ID:         searchIDPattern.ReplaceAllString(metadata.ID, "_"),
+		ArticleID:  metadata.ID,
+		URL:        metadata.URL,
+		TitleCN:    metadata.TitleCN,
+		TitleEN:    metadata.TitleEN,
+		AbstractCN: metadata.AbstractCN,
+		AbstractEN: metadata.AbstractEN,
+		KeywordsCN: metadata.KeywordsCN,
+		KeywordsEN: metadata.KeywordsEN,
+		Authors:    authors,
+		JournalCN:  metadata.JournalCN,
+		Year:       year,
+		Volume:     metadata.Volume,
+		Issue:      metadata.Issue,
+		DOI:        metadata.DOI,
+		Views:      metadata.Views,
+		Downloads:  metadata.Downloads,
+		Citations:  metadata.Citations,
+	}
+}
+
+// searchClient is the HTTP plumbing shared by the search sinks: it buffers
+// documents and hands full batches to send.
+type searchClient struct {
+	name    string
+	baseURL string
+	headers map[string]string
+	client  *http.Client
+	send    func(docs []searchDocument) error
+
+	mu   sync.Mutex
+	docs []searchDocument
+}
+
+func (c *searchClient) Write(metadata *parser.PaperMetadata) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.docs = append(c.docs, newSearchDocument(metadata))
+	if len(c.docs) < searchBatchSize {
+		return nil
+	}
+	return c.flush()
+}
+
+func (c *searchClient) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.flush()
+}
+
+// flush sends buffered documents. Callers hold mu.
+func (c *searchClient) flush() error {
+	if len(c.docs) == 0 {
+		return nil
+	}
+	if err := c.send(c.docs); err != nil {
+		return err
+	}
+	c.docs = c.docs[:0]
+	return nil
+}
+
+// do sends a request and returns the response body, treating any status of
+// 300 or more as an error unless it is listed in allowed.
+func (c *searchClient) do(method, path, contentType string, body []byte, allowed ...int) ([]byte, error) {
+	req, err := http.NewRequest(method, c.baseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range c.headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%s request failed: %w", c.name, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s response: %w", c.name, err)
+	}
+
+	if resp.StatusCode >= 300 {
+		for _, status := range allowed {
+			if resp.StatusCode == status {
+				return data, nil
+			}
+		}
+		if len(data) > 512 {
+			data = data[:512]
+		}
+		return nil, fmt.Errorf("%s rejected %s %s: HTTP %d: %s", c.name, method, path, resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+
+	return data, nil
+}
+
+func newSearchClient(name, baseURL string, headers map[string]string) *searchClient {
+	return &searchClient{
+		name:    name,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		headers: headers,
+		client:  &http.Client{Timeout: 30 * time.Second},
+	}
+}
@@ -0,0 +1,121 @@
+package sink
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/oauth2/google"
+
+	"gtft-crawler/internal/parser"
+)
+
+const sheetsScope = "https://www.googleapis.com/auth/spreadsheets"
+
+// sheetsBatchSize is how many rows are buffered before an append request
+const sheetsBatchSize = 50
+
+// Sheets appends a summary row (title, authors, year, DOI, URL) per saved
+// record to a Google Sheet using a service account.
+type Sheets struct {
+	client        *http.Client
+	spreadsheetID string
+	sheetRange    string
+
+	mu   sync.Mutex
+	rows [][]string
+}
+
+// NewSheets authenticates with the service-account key at credentialsFile
+// (default: $GOOGLE_APPLICATION_CREDENTIALS). The spreadsheet must be shared
+// with the service account's email address.
+func NewSheets(spreadsheetID, sheetRange, credentialsFile string) (*Sheets, error) {
+	if credentialsFile == "" {
+		credentialsFile = os.Getenv("GOOGLE_APPLICATION_CREDENTIALS")
+	}
+	if credentialsFile == "" {
+		return nil, fmt.Errorf("no service account credentials: set -sheets-credentials or GOOGLE_APPLICATION_CREDENTIALS")
+	}
+
+	key, err := os.ReadFile(credentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read credentials: %w", err)
+	}
+
+	jwtConfig, err := google.JWTConfigFromJSON(key, sheetsScope)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service account credentials: %w", err)
+	}
+
+	return &Sheets{
+		client:        jwtConfig.Client(context.Background()),
+		spreadsheetID: spreadsheetID,
+		sheetRange:    sheetRange,
+	}, nil
+}
+
+func (s *Sheets) Write(metadata *parser.PaperMetadata) error {
+	authors := make([]string, 0, len(metadata.Authors))
+	for _, author := range metadata.Authors {
+		authors = append(authors, author.Name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.rows = append(s.rows, []string{
+		metadata.TitleCN,
+		strings.Join(authors, ", "),
+		metadata.Year,
+		metadata.DOI,
+		metadata.URL,
+	})
+
+	if len(s.rows) < sheetsBatchSize {
+		return nil
+	}
+	return s.flush()
+}
+
+func (s *Sheets) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.flush()
+}
+
+// flush appends buffered rows to the sheet. Callers hold mu.
+func (s *Sheets) flush() error {
+	if len(s.rows) == 0 {
+		return nil
+	}
+
+	body, err := json.Marshal(map[string]any{"values": s.rows})
+	if err != nil {
+		return fmt.Errorf("failed to encode rows: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://sheets.googleapis.com/v4/spreadsheets/%s/values/%s:append?valueInputOption=RAW&insertDataOption=INSERT_ROWS",
+		url.PathEscape(s.spreadsheetID), url.PathEscape(s.sheetRange))
+
+	resp, err := s.client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("sheets append failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("sheets append rejected: HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+
+	s.rows = s.rows[:0]
+	return nil
+}
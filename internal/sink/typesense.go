@@ -0,0 +1,108 @@
+package sink
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// typesenseFields is the collection schema. Chinese fields use the zh locale
+// so they are segmented into words; keywords, authors, journal and year can
+// be faceted. Typo tolerance is on by default.
+var typesenseFields = []map[string]any{
+	{"name": "article_id", "type": "string", "index": false, "optional": true},
+	{"name": "url", "type": "string", "index": false, "optional": true},
+	{"name": "title_cn", "type": "string", "locale": "zh"},
+	{"name": "title_en", "type": "string", "optional": true},
+	{"name": "abstract_cn", "type": "string", "locale": "zh", "optional": true},
+	{"name": "abstract_en", "type": "string", "optional": true},
+	{"name": "keywords_cn", "type": "string[]", "locale": "zh", "facet": true, "optional": true},
+	{"name": "keywords_en", "type": "string[]", "facet": true, "optional": true},
+	{"name": "authors", "type": "string[]", "locale": "zh", "facet": true},
+	{"name": "journal_cn", "type": "string", "facet": true},
+	{"name": "year", "type": "int32", "facet": true, "optional": true},
+	{"name": "volume", "type": "string", "optional": true},
+	{"name": "issue", "type": "string", "optional": true},
+	{"name": "doi", "type": "string", "optional": true},
+	{"name": "views", "type": "int32"},
+	{"name": "downloads", "type": "int32"},
+	{"name": "citations", "type": "int32"},
+}
+
+// Typesense indexes each saved record into a Typesense collection, creating
+// the collection if needed.
+type Typesense struct {
+	*searchClient
+	collection string
+}
+
+// NewTypesense connects to the Typesense server at baseURL (e.g.
+// http://localhost:8108) and prepares the collection.
+func NewTypesense(baseURL, collection, apiKey string) (*Typesense, error) {
+	if apiKey == "" {
+		return nil, fmt.Errorf("typesense needs an API key")
+	}
+
+	t := &Typesense{
+		searchClient: newSearchClient("typesense", baseURL, map[string]string{"X-TYPESENSE-API-KEY": apiKey}),
+		collection:   collection,
+	}
+	t.send = t.importDocuments
+
+	schema, err := json.Marshal(map[string]any{
+		"name":   collection,
+		"fields": typesenseFields,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+
+	// 409 means the collection already exists
+	if _, err := t.do(http.MethodPost, "/collections", "application/json", schema, http.StatusConflict); err != nil {
+		return nil, err
+	}
+
+	return t, nil
+}
+
+// importDocuments upserts documents. Typesense answers with one JSON result
+// per document, which is checked for failures.
+func (t *Typesense) importDocuments(docs []searchDocument) error {
+	var body bytes.Buffer
+	encoder := json.NewEncoder(&body)
+	for _, doc := range docs {
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode document %s: %w", doc.ArticleID, err)
+		}
+	}
+
+	path := "/collections/" + url.PathEscape(t.collection) + "/documents/import?action=upsert"
+	data, err := t.do(http.MethodPost, path, "text/plain", body.Bytes())
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	var firstErr string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		var result struct {
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+		}
+		if json.Unmarshal(scanner.Bytes(), &result) == nil && !result.Success {
+			if failed == 0 {
+				firstErr = result.Error
+			}
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("typesense rejected %d of %d documents: %s", failed, len(docs), firstErr)
+	}
+
+	return nil
+}
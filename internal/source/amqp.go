@@ -0,0 +1,139 @@
+package source
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// AMQP consumes URLs from a queue, one URL per message body. Messages are
+// acked once their record is saved and nacked on failure (requeued the first
+// time), so an upstream discovery service can feed the crawler continuously.
+type AMQP struct {
+	conn    *amqp.Connection
+	channel *amqp.Channel
+	urls    chan string
+	stop    chan struct{}
+	once    sync.Once
+	verbose bool
+
+	mu      sync.Mutex
+	pending map[string][]amqp.Delivery
+}
+
+// NewAMQP connects to amqpURL (amqp:// or amqps://) and starts consuming
+// queue. At most prefetch messages are unacknowledged at a time.
+func NewAMQP(amqpURL, queue string, prefetch int, verbose bool) (*AMQP, error) {
+	conn, err := amqp.Dial(amqpURL)
+	if err != nil {
+		return nil, fmt.Errorf("AMQP connection failed: %w", err)
+	}
+
+	channel, err := conn.Channel()
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to open AMQP channel: %w", err)
+	}
+
+	if err := channel.Qos(prefetch, 0, false); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to set AMQP prefetch: %w", err)
+	}
+
+	deliveries, err := channel.Consume(queue, "gtft-crawler", false, false, false, false, nil)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to consume queue %s: %w", queue, err)
+	}
+
+	a := &AMQP{
+		conn:    conn,
+		channel: channel,
+		urls:    make(chan string),
+		stop:    make(chan struct{}),
+		verbose: verbose,
+		pending: make(map[string][]amqp.Delivery),
+	}
+	go a.receive(deliveries)
+
+	return a, nil
+}
+
+func (a *AMQP) receive(deliveries <-chan amqp.Delivery) {
+	defer close(a.urls)
+
+	for {
+		select {
+		case d, ok := <-deliveries:
+			if !ok {
+				if a.verbose {
+					fmt.Printf("[AMQP] Delivery channel closed\n")
+				}
+				return
+			}
+
+			url := strings.TrimSpace(string(d.Body))
+			if url == "" {
+				// Nothing to crawl; drop it rather than requeue forever
+				d.Reject(false)
+				continue
+			}
+
+			a.mu.Lock()
+			a.pending[url] = append(a.pending[url], d)
+			a.mu.Unlock()
+
+			select {
+			case a.urls <- url:
+			case <-a.stop:
+				// Unacked messages are requeued by the broker on Close
+				return
+			}
+
+		case <-a.stop:
+			return
+		}
+	}
+}
+
+func (a *AMQP) URLs() <-chan string {
+	return a.urls
+}
+
+func (a *AMQP) Done(url string, err error) error {
+	a.mu.Lock()
+	deliveries := a.pending[url]
+	if len(deliveries) == 0 {
+		a.mu.Unlock()
+		return fmt.Errorf("no pending AMQP message for %s", url)
+	}
+	d := deliveries[0]
+	if len(deliveries) == 1 {
+		delete(a.pending, url)
+	} else {
+		a.pending[url] = deliveries[1:]
+	}
+	a.mu.Unlock()
+
+	if err != nil {
+		// Requeue once; a second failure goes to the queue's dead-letter
+		// exchange (or is dropped) so a bad URL can't loop forever
+		requeue := !d.Redelivered
+		if a.verbose {
+			fmt.Printf("[AMQP] Nacking %s (requeue: %v): %v\n", url, requeue, err)
+		}
+		return d.Nack(false, requeue)
+	}
+	return d.Ack(false)
+}
+
+func (a *AMQP) Stop() {
+	a.once.Do(func() { close(a.stop) })
+}
+
+func (a *AMQP) Close() error {
+	a.Stop()
+	return a.conn.Close()
+}
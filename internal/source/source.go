@@ -0,0 +1,16 @@
+package source
+
+// Source supplies URLs to crawl from somewhere other than the input file,
+// and is told the outcome of each one so it can record or acknowledge it.
+type Source interface {
+	// URLs returns the worklist. The channel is closed when the source is
+	// exhausted or stopped.
+	URLs() <-chan string
+	// Done reports the outcome for url: err is nil when the record was
+	// saved (or already present). It is called once per URL received.
+	Done(url string, err error) error
+	// Stop ends delivery of new URLs; URLs already received may still be
+	// reported to Done.
+	Stop()
+	Close() error
+}
@@ -0,0 +1,167 @@
+package source
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// Spider crawls outward from seed pages, following links to other article
+// pages up to a maximum depth. Seeds are depth 0. Each URL is visited once,
+// with article pages deduplicated by ID so /cn/ and bare variants count once.
+type Spider struct {
+	maxDepth  int
+	allowed   func(url string) bool
+	isArticle func(url string) bool
+	idFor     func(url string) string
+	verbose   bool
+
+	mu      sync.Mutex
+	cond    *sync.Cond
+	queue   []string
+	depth   map[string]int
+	seen    map[string]bool
+	pending int
+	stopped bool
+
+	urls chan string
+}
+
+// NewSpider starts a spider from seeds. allowed filters discovered links
+// (e.g. an allowlist), isArticle tells article pages from listing pages and
+// idFor maps an article URL to its record ID.
+func NewSpider(seeds []string, maxDepth int, allowed, isArticle func(string) bool, idFor func(string) string, verbose bool) *Spider {
+	s := &Spider{
+		maxDepth:  maxDepth,
+		allowed:   allowed,
+		isArticle: isArticle,
+		idFor:     idFor,
+		verbose:   verbose,
+		depth:     make(map[string]int),
+		seen:      make(map[string]bool),
+		urls:      make(chan string),
+	}
+	s.cond = sync.NewCond(&s.mu)
+
+	for _, seed := range seeds {
+		s.enqueue(seed, 0)
+	}
+	go s.feed()
+
+	return s
+}
+
+// key identifies a page for deduplication. Callers hold mu.
+func (s *Spider) key(u string) string {
+	if s.isArticle(u) {
+		return "id:" + s.idFor(u)
+	}
+	return u
+}
+
+// enqueue adds u at depth unless it was already seen. Callers hold mu, or
+// are the constructor.
+func (s *Spider) enqueue(u string, depth int) {
+	key := s.key(u)
+	if s.seen[key] {
+		return
+	}
+	s.seen[key] = true
+	s.depth[u] = depth
+	s.queue = append(s.queue, u)
+	s.pending++
+}
+
+func (s *Spider) feed() {
+	defer close(s.urls)
+
+	for {
+		s.mu.Lock()
+		for len(s.queue) == 0 && s.pending > 0 && !s.stopped {
+			s.cond.Wait()
+		}
+		if s.stopped || len(s.queue) == 0 {
+			// Stopped, or nothing queued and nothing in flight
+			s.mu.Unlock()
+			return
+		}
+		next := s.queue[0]
+		s.queue = s.queue[1:]
+		s.mu.Unlock()
+
+		s.urls <- next
+	}
+}
+
+// Discover queues the in-domain article links found on page, if page is
+// shallower than the maximum depth. It must be called before Done(page).
+func (s *Spider) Discover(page string, links []string) {
+	base, err := url.Parse(page)
+	if err != nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	depth, ok := s.depth[page]
+	if !ok || depth >= s.maxDepth {
+		return
+	}
+
+	added := 0
+	for _, link := range links {
+		u, err := url.Parse(link)
+		if err != nil || u.Host != base.Host || u.RawQuery != "" || !strings.Contains(u.Path, "/article/") {
+			continue
+		}
+		u.Fragment = ""
+		link = u.String()
+		if !s.allowed(link) {
+			continue
+		}
+
+		before := s.pending
+		s.enqueue(link, depth+1)
+		if s.pending > before {
+			added++
+		}
+	}
+
+	if added > 0 {
+		if s.verbose {
+			fmt.Printf("[Spider] %s (depth %d): queued %d new links\n", page, depth, added)
+		}
+		s.cond.Broadcast()
+	}
+}
+
+func (s *Spider) URLs() <-chan string {
+	return s.urls
+}
+
+// Done marks page as finished; the spider ends once nothing is queued or in
+// flight.
+func (s *Spider) Done(page string, err error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending--
+	if s.pending <= 0 {
+		s.cond.Broadcast()
+	}
+	return nil
+}
+
+func (s *Spider) Stop() {
+	s.mu.Lock()
+	s.stopped = true
+	s.mu.Unlock()
+	s.cond.Broadcast()
+}
+
+func (s *Spider) Close() error {
+	s.Stop()
+	return nil
+}
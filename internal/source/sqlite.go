@@ -0,0 +1,164 @@
+package source
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// statusSchema is created in the source database if missing. Other tooling
+// can join it against its own tables, e.g. to exclude crawled URLs from the
+// pending query.
+const statusSchema = `
+CREATE TABLE IF NOT EXISTS %s (
+	url        TEXT PRIMARY KEY,
+	status     TEXT NOT NULL,
+	error      TEXT,
+	attempts   INTEGER NOT NULL DEFAULT 1,
+	updated_at TEXT NOT NULL
+);
+`
+
+// SQLite reads the worklist from a query against a SQLite database and
+// writes each URL's outcome ("done" or "failed") back to a status table.
+type SQLite struct {
+	db          *sql.DB
+	statusTable string
+	urls        chan string
+	stop        chan struct{}
+	once        sync.Once
+	count       int
+}
+
+// NewSQLite runs query against the database at path; the first column of
+// each row is taken as a URL. Outcomes are upserted into statusTable.
+func NewSQLite(path, query, statusTable string) (*SQLite, error) {
+	if !validIdentifier(statusTable) {
+		return nil, fmt.Errorf("invalid status table name %q", statusTable)
+	}
+
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database: %w", err)
+	}
+	// One connection serializes status writes, avoiding SQLITE_BUSY
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.Exec(fmt.Sprintf(statusSchema, statusTable)); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create status table: %w", err)
+	}
+
+	// Read the whole worklist before any status writes touch the database
+	urls, err := queryURLs(db, query)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	s := &SQLite{
+		db:          db,
+		statusTable: statusTable,
+		urls:        make(chan string),
+		stop:        make(chan struct{}),
+		count:       len(urls),
+	}
+	go s.send(urls)
+
+	return s, nil
+}
+
+func queryURLs(db *sql.DB, query string) ([]string, error) {
+	rows, err := db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("input query failed: %w", err)
+	}
+	defer rows.Close()
+
+	var urls []string
+	for rows.Next() {
+		var url sql.NullString
+		if err := rows.Scan(&url); err != nil {
+			return nil, fmt.Errorf("failed to read URL row: %w", err)
+		}
+		if u := strings.TrimSpace(url.String); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read URL rows: %w", err)
+	}
+
+	return urls, nil
+}
+
+func (s *SQLite) send(urls []string) {
+	defer close(s.urls)
+
+	for _, url := range urls {
+		select {
+		case s.urls <- url:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Len returns the number of URLs the query returned.
+func (s *SQLite) Len() int {
+	return s.count
+}
+
+func (s *SQLite) URLs() <-chan string {
+	return s.urls
+}
+
+func (s *SQLite) Done(url string, err error) error {
+	status, message := "done", sql.NullString{}
+	if err != nil {
+		status, message = "failed", sql.NullString{String: err.Error(), Valid: true}
+	}
+
+	_, dbErr := s.db.Exec(fmt.Sprintf(`
+		INSERT INTO %s (url, status, error, updated_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT (url) DO UPDATE SET
+			status = excluded.status,
+			error = excluded.error,
+			attempts = attempts + 1,
+			updated_at = excluded.updated_at`, s.statusTable),
+		url, status, message, time.Now().UTC().Format(time.RFC3339))
+	if dbErr != nil {
+		return fmt.Errorf("failed to write status: %w", dbErr)
+	}
+
+	return nil
+}
+
+func (s *SQLite) Stop() {
+	s.once.Do(func() { close(s.stop) })
+}
+
+func (s *SQLite) Close() error {
+	s.Stop()
+	return s.db.Close()
+}
+
+// validIdentifier reports whether name can be used unquoted as a table name.
+func validIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_', r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case r >= '0' && r <= '9' && i > 0:
+		default:
+			return false
+		}
+	}
+	return true
+}
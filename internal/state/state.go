@@ -0,0 +1,155 @@
+// Package state records the outcome of every URL a crawl has processed, so
+// later runs and the plan command know which URLs failed and which record
+// each URL produced.
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/worker"
+)
+
+// FileName is where the state is kept in the output directory.
+const FileName = "crawl_state.json"
+
+const (
+	StatusOK     = "ok"
+	StatusFailed = "failed"
+)
+
+// Entry is the latest outcome for one URL.
+type Entry struct {
+	// ID is the record the URL was saved as, when it produced one
+	ID     string `json:"id,omitempty"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// Attempts counts the runs that processed the URL
+	Attempts    int       `json:"attempts"`
+	LastAttempt time.Time `json:"last_attempt"`
+	LastSuccess time.Time `json:"last_success,omitzero"`
+	// StatusCode, FetchAttempts and AttemptErrors describe the requests of
+	// the last attempt when it failed fetching the page: the last response
+	// status (0 if none), how many requests were made and why each failed
+	StatusCode    int      `json:"status_code,omitempty"`
+	FetchAttempts int      `json:"fetch_attempts,omitempty"`
+	AttemptErrors []string `json:"attempt_errors,omitempty"`
+	// Missing lists the critical fields strict mode found empty when the
+	// last attempt failed as an incomplete record
+	Missing []string `json:"missing,omitempty"`
+}
+
+// Gone reports whether the URL's last attempt found its page no longer
+// exists (404 Not Found or 410 Gone), so retrying it is pointless.
+func (e Entry) Gone() bool {
+	return e.Status == StatusFailed && (e.StatusCode == http.StatusNotFound || e.StatusCode == http.StatusGone)
+}
+
+// Quarantined reports whether the URL's last attempt failed strict mode as
+// an incomplete record, so fetching it again with the same parser is
+// pointless.
+func (e Entry) Quarantined() bool {
+	return e.Status == StatusFailed && len(e.Missing) > 0
+}
+
+// State maps URLs to their latest outcome. It is safe for concurrent use.
+type State struct {
+	mu   sync.Mutex
+	urls map[string]*Entry
+}
+
+type stateFile struct {
+	URLs map[string]*Entry `json:"urls"`
+}
+
+func New() *State {
+	return &State{urls: make(map[string]*Entry)}
+}
+
+// Decode reads a state previously written by Encode.
+func Decode(data []byte) (*State, error) {
+	var file stateFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid crawl state: %w", err)
+	}
+
+	s := New()
+	for url, entry := range file.URLs {
+		if entry != nil {
+			s.urls[url] = entry
+		}
+	}
+	return s, nil
+}
+
+// Encode returns the state as JSON, with URLs in sorted order.
+func (s *State) Encode() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	data, err := json.MarshalIndent(stateFile{URLs: s.urls}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode crawl state: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// Record stores the outcome of processing url: the ID of the record it
+// produced, or the error that stopped it.
+func (s *State) Record(url, id string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.urls[url]
+	if !ok {
+		entry = &Entry{}
+		s.urls[url] = entry
+	}
+
+	now := time.Now().UTC()
+	entry.Attempts++
+	entry.LastAttempt = now
+	entry.FetchAttempts, entry.AttemptErrors, entry.StatusCode, _ = worker.Attempts(err)
+	entry.Missing = nil
+	var incomplete *parser.IncompleteError
+	if errors.As(err, &incomplete) {
+		entry.Missing = incomplete.Missing
+	}
+	if err != nil {
+		entry.Status = StatusFailed
+		entry.Error = err.Error()
+		return
+	}
+
+	entry.Status = StatusOK
+	entry.Error = ""
+	entry.LastSuccess = now
+	if id != "" {
+		entry.ID = id
+	}
+}
+
+// Get returns the latest outcome for url.
+func (s *State) Get(url string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.urls[url]
+	if !ok {
+		return Entry{}, false
+	}
+	return *entry, true
+}
+
+// Len returns the number of URLs recorded.
+func (s *State) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.urls)
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Canonicalize normalizes a record so that crawling an unchanged page
+// always encodes to the same bytes: every string is trimmed and has CRLF
+// line endings converted to LF, empty keywords are dropped, and keyword
+// lists are sorted. Field order is fixed by the struct.
+func Canonicalize(metadata *parser.PaperMetadata) {
+	canonicalizeStrings(reflect.ValueOf(metadata).Elem())
+
+	metadata.KeywordsCN = canonicalKeywords(metadata.KeywordsCN)
+	metadata.KeywordsEN = canonicalKeywords(metadata.KeywordsEN)
+}
+
+func canonicalizeStrings(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.String:
+		if v.CanSet() {
+			v.SetString(strings.TrimSpace(strings.ReplaceAll(v.String(), "\r\n", "\n")))
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			canonicalizeStrings(v.Field(i))
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			canonicalizeStrings(v.Index(i))
+		}
+	case reflect.Pointer:
+		if !v.IsNil() {
+			canonicalizeStrings(v.Elem())
+		}
+	}
+}
+
+func canonicalKeywords(keywords []string) []string {
+	if keywords == nil {
+		return nil
+	}
+	keywords = slices.DeleteFunc(keywords, func(k string) bool { return k == "" })
+	slices.Sort(keywords)
+	return keywords
+}
@@ -0,0 +1,142 @@
+package storage
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// EncryptedSuffix is appended to the name of every file written through an
+// EncryptedBackend.
+const EncryptedSuffix = ".enc"
+
+// encryptedMagic starts every encrypted file, followed by the GCM nonce
+var encryptedMagic = []byte("GTFTENC1")
+
+// EncryptedBackend wraps another backend, sealing each file with AES-256-GCM
+// before it is stored and opening it on read.
+type EncryptedBackend struct {
+	Backend
+	aead cipher.AEAD
+}
+
+// NewEncryptedBackend wraps b using a 32-byte key.
+func NewEncryptedBackend(b Backend, key []byte) (*EncryptedBackend, error) {
+	aead, err := NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return &EncryptedBackend{Backend: b, aead: aead}, nil
+}
+
+// NewCipher returns the AES-256-GCM AEAD for a 32-byte key.
+func NewCipher(key []byte) (cipher.AEAD, error) {
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes, got %d", len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// LoadKey reads a 32-byte key from keyFile, or from the GTFT_ENCRYPTION_KEY
+// environment variable when keyFile is empty. The key may be raw bytes (file
+// only), hex or base64.
+func LoadKey(keyFile string) ([]byte, error) {
+	var data []byte
+	if keyFile != "" {
+		var err error
+		data, err = os.ReadFile(keyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key file: %w", err)
+		}
+		if len(data) == 32 {
+			return data, nil
+		}
+	} else {
+		data = []byte(os.Getenv("GTFT_ENCRYPTION_KEY"))
+		if len(data) == 0 {
+			return nil, fmt.Errorf("no encryption key: set -encrypt-key-file or GTFT_ENCRYPTION_KEY")
+		}
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == 32 {
+		return key, nil
+	}
+
+	return nil, fmt.Errorf("encryption key must be 32 bytes, given raw, as 64 hex digits or as base64")
+}
+
+// Seal encrypts data for storage under name; the name is bound as
+// additional data so files can't be swapped undetected.
+func Seal(aead cipher.AEAD, name string, data []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	out := append(append([]byte{}, encryptedMagic...), nonce...)
+	return aead.Seal(out, nonce, data, []byte(name)), nil
+}
+
+// Open decrypts a file produced by Seal for name.
+func Open(aead cipher.AEAD, name string, sealed []byte) ([]byte, error) {
+	if !bytes.HasPrefix(sealed, encryptedMagic) {
+		return nil, fmt.Errorf("%s is not an encrypted file", name)
+	}
+	sealed = sealed[len(encryptedMagic):]
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("%s is truncated", name)
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+
+	data, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s (wrong key or corrupted file): %w", name, err)
+	}
+	return data, nil
+}
+
+func (b *EncryptedBackend) WriteFile(name string, data []byte) error {
+	sealed, err := Seal(b.aead, name, data)
+	if err != nil {
+		return err
+	}
+	return b.Backend.WriteFile(name+EncryptedSuffix, sealed)
+}
+
+func (b *EncryptedBackend) ReadFile(name string) ([]byte, error) {
+	sealed, err := b.Backend.ReadFile(name + EncryptedSuffix)
+	if err != nil {
+		return nil, err
+	}
+	return Open(b.aead, name, sealed)
+}
+
+func (b *EncryptedBackend) Exists(name string) (bool, error) {
+	return b.Backend.Exists(name + EncryptedSuffix)
+}
+
+// AppendFile decrypts, extends and re-seals the whole file, since a GCM
+// ciphertext can't be extended in place.
+func (b *EncryptedBackend) AppendFile(name string, data []byte) error {
+	existing, err := b.ReadFile(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return b.WriteFile(name, append(existing, data...))
+}
@@ -0,0 +1,33 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"fmt"
+)
+
+// SaveHTML keeps the page a record was parsed from as html/{id}.html.gz, or
+// html/{id}.html when compress is false, so the corpus can be parsed again
+// after parser changes without crawling it again. It returns the file's
+// path.
+func (s *Storage) SaveHTML(url, id string, html []byte, compress bool) (string, error) {
+	name := s.AssetPath(url, "html", id+".html")
+	data := html
+	if compress {
+		name += ".gz"
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(html); err != nil {
+			return "", fmt.Errorf("failed to compress HTML: %w", err)
+		}
+		if err := zw.Close(); err != nil {
+			return "", fmt.Errorf("failed to compress HTML: %w", err)
+		}
+		data = buf.Bytes()
+	}
+
+	if err := s.backend.WriteFile(name, data); err != nil {
+		return "", fmt.Errorf("failed to save HTML: %w", err)
+	}
+	return name, nil
+}
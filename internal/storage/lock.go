@@ -0,0 +1,124 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const lockFileName = ".gtft-crawler.lock"
+
+// ErrLocked is returned when another run holds the output directory lock.
+var ErrLocked = errors.New("output directory is locked by another run")
+
+// Lock is an exclusive lock on an output directory, held for the duration
+// of a run so overlapping invocations can't corrupt stats or records. It is
+// an OS lock on the lock file (flock, or LockFileEx on Windows), which the
+// kernel drops if the process dies.
+type Lock struct {
+	path string
+	file *os.File
+}
+
+// AcquireLock takes the run lock in dir. If the lock is held by a live
+// process, it waits up to wait for it to be released (0 fails immediately).
+// Lock files left behind by processes that no longer exist hold no lock and
+// are simply taken over.
+func AcquireLock(dir string, wait time.Duration) (*Lock, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	path := filepath.Join(dir, lockFileName)
+	deadline := time.Now().Add(wait)
+
+	for {
+		lock, err := tryLock(path)
+		if err != nil {
+			return nil, err
+		}
+		if lock != nil {
+			return lock, nil
+		}
+
+		if time.Now().After(deadline) {
+			pid, started := readLock(path)
+			return nil, fmt.Errorf("%w: %s (pid %d, started %s)", ErrLocked, dir, pid, started)
+		}
+
+		time.Sleep(time.Second)
+	}
+}
+
+// tryLock makes one attempt at the lock, returning nil without an error if
+// another process holds it.
+func tryLock(path string) (*Lock, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	locked, err := lockFile(file)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to lock %s: %w", path, err)
+	}
+	if !locked {
+		file.Close()
+		return nil, nil
+	}
+
+	// The holder we waited on may have removed the file on release, leaving
+	// us a lock on an unlinked file while another run creates a new one
+	if !sameFile(file, path) {
+		file.Close()
+		return nil, nil
+	}
+
+	if err := writeLock(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write lock file: %w", err)
+	}
+	return &Lock{path: path, file: file}, nil
+}
+
+// sameFile reports whether file is still the one at path.
+func sameFile(file *os.File, path string) bool {
+	opened, err := file.Stat()
+	if err != nil {
+		return false
+	}
+	current, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	return os.SameFile(opened, current)
+}
+
+// writeLock records the holder's PID and start time, for the error other
+// runs report.
+func writeLock(file *os.File) error {
+	if err := file.Truncate(0); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(file, "%d\n%s\n", os.Getpid(), time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+func readLock(path string) (int, string) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, "unknown"
+	}
+
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	pid, _ := strconv.Atoi(strings.TrimSpace(lines[0]))
+	started := "unknown"
+	if len(lines) > 1 {
+		started = strings.TrimSpace(lines[1])
+	}
+	return pid, started
+}
@@ -0,0 +1,36 @@
+//go:build windows
+
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// lockFile takes an exclusive LockFileEx lock on the first byte of file
+// without blocking, reporting false if another process holds it.
+func lockFile(file *os.File) (bool, error) {
+	var overlapped windows.Overlapped
+	err := windows.LockFileEx(windows.Handle(file.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0, &overlapped)
+	if errors.Is(err, windows.ERROR_LOCK_VIOLATION) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Release drops the lock and removes the lock file. Windows can't remove a
+// file that is open, so the lock is dropped first; if another run has opened
+// the file by then, removing it fails and it is left for that run.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+	if err := l.file.Close(); err != nil {
+		return fmt.Errorf("failed to release lock: %w", err)
+	}
+	os.Remove(l.path)
+	return nil
+}
@@ -0,0 +1,75 @@
+package storage
+
+import "path"
+
+// JournalStats counts the outcomes of one journal's URLs.
+type JournalStats struct {
+	Saved   int `json:"saved"`
+	Failed  int `json:"failed"`
+	Skipped int `json:"skipped"`
+}
+
+type outcome int
+
+const (
+	outcomeSaved outcome = iota
+	outcomeFailed
+	outcomeSkipped
+)
+
+// SetNamespaces keeps each journal's records apart, for runs crawling
+// several journals whose article IDs may collide: namespace names the
+// journal a URL belongs to, and its records are stored in a subdirectory of
+// that name and counted separately in the stats.
+func (s *Storage) SetNamespaces(namespace func(url string) string) {
+	s.namespace = namespace
+	s.stats.Journals = make(map[string]*JournalStats)
+}
+
+// namespaceOf returns the namespace of url, or "" without namespaces.
+func (s *Storage) namespaceOf(url string) string {
+	if s.namespace == nil {
+		return ""
+	}
+	return s.namespace(url)
+}
+
+// recordPath returns where a record is kept in the given layout, inside the
+// namespace of the URL it was crawled from.
+func (s *Storage) recordPath(url, id string, sharded bool) string {
+	return path.Join(s.namespaceOf(url), RecordPath(id, sharded))
+}
+
+// AssetPath joins elem into the path of a file that belongs with the record
+// crawled from url, such as its images or PDF, inside that URL's namespace
+// so journals sharing an article ID don't overwrite each other's files.
+func (s *Storage) AssetPath(url string, elem ...string) string {
+	return path.Join(append([]string{s.namespaceOf(url)}, elem...)...)
+}
+
+// tally counts the outcome of url in the run totals and in those of its
+// journal. Callers hold fileLock.
+func (s *Storage) tally(url string, o outcome) {
+	counters := []*int{&s.stats.Saved, &s.stats.Failed, &s.stats.Skipped}
+	*counters[o]++
+
+	if s.namespace == nil {
+		return
+	}
+	name := s.namespace(url)
+	journal := s.stats.Journals[name]
+	if journal == nil {
+		journal = &JournalStats{}
+		s.stats.Journals[name] = journal
+	}
+	counters = []*int{&journal.Saved, &journal.Failed, &journal.Skipped}
+	*counters[o]++
+}
+
+// count is tally for callers outside save.
+func (s *Storage) count(url string, o outcome) {
+	s.fileLock.Lock()
+	defer s.fileLock.Unlock()
+
+	s.tally(url, o)
+}
@@ -0,0 +1,100 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"gtft-crawler/internal/index"
+	"gtft-crawler/internal/version"
+)
+
+// RunsDir holds a copy of every run's stats, named by run ID, since
+// stats.json only describes the latest run.
+const RunsDir = "runs"
+
+// runIDFormat names runs by their UTC start time, which sorts in run order.
+const runIDFormat = "20060102T150405Z"
+
+// RunStats is the summary of one run, written to stats.json and to
+// runs/{run_id}.json.
+type RunStats struct {
+	RunID   string `json:"run_id"`
+	Total   int    `json:"total"`
+	Saved   int    `json:"saved"`
+	Failed  int    `json:"failed"`
+	Skipped int    `json:"skipped"`
+	// Removed counts records newly marked as removed from the site
+	Removed     int          `json:"removed,omitempty"`
+	SuccessRate float64      `json:"success_rate"`
+	StartTime   time.Time    `json:"start_time"`
+	EndTime     time.Time    `json:"end_time"`
+	Duration    string       `json:"duration"`
+	Crawler     version.Info `json:"crawler"`
+	// Journals breaks the counts down by journal in multi-journal runs
+	Journals map[string]*JournalStats `json:"journals,omitempty"`
+
+	Completeness *CompletenessStats `json:"completeness,omitempty"`
+	Coverage     *index.Coverage    `json:"coverage,omitempty"`
+}
+
+// RunID identifies this run in the run history.
+func (s *Storage) RunID() string {
+	return s.stats.StartTime.UTC().Format(runIDFormat)
+}
+
+// RunStatsPath returns where a run's stats are kept, relative to the output
+// directory.
+func RunStatsPath(runID string) string {
+	return path.Join(RunsDir, runID+".json")
+}
+
+// ReadRuns loads the run history of dir in run order. stats.json is
+// included when its run isn't in the history, as for runs made before the
+// history was kept.
+func ReadRuns(dir string) ([]*RunStats, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, RunsDir, "*.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list runs: %w", err)
+	}
+	paths = append(paths, filepath.Join(dir, "stats.json"))
+
+	var runs []*RunStats
+	seen := make(map[time.Time]bool)
+	for _, file := range paths {
+		data, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read run stats: %w", err)
+		}
+
+		var run RunStats
+		if err := json.Unmarshal(data, &run); err != nil {
+			return nil, fmt.Errorf("failed to decode %s: %w", file, err)
+		}
+		if seen[run.StartTime] {
+			continue
+		}
+		seen[run.StartTime] = true
+		runs = append(runs, &run)
+	}
+
+	if len(runs) == 0 {
+		encrypted, _ := filepath.Glob(filepath.Join(dir, RunsDir, "*.json"+EncryptedSuffix))
+		if len(encrypted) > 0 {
+			return nil, fmt.Errorf("run history in %s is encrypted; decrypt the directory first", dir)
+		}
+	}
+
+	sort.SliceStable(runs, func(i, j int) bool {
+		return runs[i].StartTime.Before(runs[j].StartTime)
+	})
+	return runs, nil
+}
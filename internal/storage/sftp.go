@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// SFTPBackend writes output files to a directory on an SSH server.
+type SFTPBackend struct {
+	root   string
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+// NewSFTPBackend connects to sftp://user@host[:port]/path. It authenticates
+// with the key in opts.SSHKeyFile and/or a password, and checks the host key
+// against opts.KnownHostsFile (default ~/.ssh/known_hosts).
+func NewSFTPBackend(u *url.URL, opts BackendOptions) (*SFTPBackend, error) {
+	if u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("sftp URL must include a user, e.g. sftp://user@host/path")
+	}
+
+	var auth []ssh.AuthMethod
+	if opts.SSHKeyFile != "" {
+		key, err := os.ReadFile(opts.SSHKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read SSH key: %w", err)
+		}
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH key: %w", err)
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	password := opts.Password
+	if p, ok := u.User.Password(); ok {
+		password = p
+	}
+	if password != "" {
+		auth = append(auth, ssh.Password(password))
+	}
+	if len(auth) == 0 {
+		return nil, fmt.Errorf("sftp backend needs an SSH key or password")
+	}
+
+	knownHostsFile := opts.KnownHostsFile
+	if knownHostsFile == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("failed to locate known_hosts: %w", err)
+		}
+		knownHostsFile = filepath.Join(home, ".ssh", "known_hosts")
+	}
+	hostKeyCallback, err := knownhosts.New(knownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load known hosts: %w", err)
+	}
+
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "22")
+	}
+
+	conn, err := ssh.Dial("tcp", host, &ssh.ClientConfig{
+		User:            u.User.Username(),
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         30 * time.Second,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("SSH connection to %s failed: %w", host, err)
+	}
+
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to start SFTP session: %w", err)
+	}
+
+	root := u.Path
+	if root == "" {
+		root = "."
+	}
+
+	return &SFTPBackend{root: root, conn: conn, client: client}, nil
+}
+
+func (b *SFTPBackend) path(name string) string {
+	return path.Join(b.root, name)
+}
+
+func (b *SFTPBackend) WriteFile(name string, data []byte) error {
+	filename := b.path(name)
+
+	if err := b.client.MkdirAll(path.Dir(filename)); err != nil {
+		return fmt.Errorf("failed to create remote directory: %w", err)
+	}
+
+	// Write to a temporary file and rename it into place atomically
+	tempFile := filename + ".tmp"
+	if err := b.put(tempFile, data, os.O_WRONLY|os.O_CREATE|os.O_TRUNC); err != nil {
+		b.client.Remove(tempFile)
+		return err
+	}
+
+	if err := b.client.PosixRename(tempFile, filename); err != nil {
+		b.client.Remove(tempFile)
+		return fmt.Errorf("failed to rename remote temp file: %w", err)
+	}
+
+	return nil
+}
+
+func (b *SFTPBackend) put(filename string, data []byte, flags int) error {
+	file, err := b.client.OpenFile(filename, flags)
+	if err != nil {
+		return fmt.Errorf("failed to open remote file: %w", err)
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write remote file: %w", err)
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to close remote file: %w", err)
+	}
+
+	return nil
+}
+
+func (b *SFTPBackend) ReadFile(name string) ([]byte, error) {
+	file, err := b.client.Open(b.path(name))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return io.ReadAll(file)
+}
+
+func (b *SFTPBackend) Exists(name string) (bool, error) {
+	_, err := b.client.Stat(b.path(name))
+	if err == nil {
+		return true, nil
+	}
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	return false, err
+}
+
+func (b *SFTPBackend) AppendFile(name string, data []byte) error {
+	filename := b.path(name)
+
+	if err := b.client.MkdirAll(path.Dir(filename)); err != nil {
+		return fmt.Errorf("failed to create remote directory: %w", err)
+	}
+
+	return b.put(filename, data, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+}
+
+func (b *SFTPBackend) Close() error {
+	b.client.Close()
+	return b.conn.Close()
+}
@@ -0,0 +1,27 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"gtft-crawler/internal/state"
+)
+
+// ReadState loads the crawl state from the output directory dir; a missing
+// state is empty. An encrypted state is an error, as it can't be read
+// without the key.
+func ReadState(dir string) (*state.State, error) {
+	data, err := os.ReadFile(filepath.Join(dir, state.FileName))
+	if errors.Is(err, os.ErrNotExist) {
+		if _, encErr := os.Stat(filepath.Join(dir, state.FileName+EncryptedSuffix)); encErr == nil {
+			return nil, fmt.Errorf("crawl state in %s is encrypted; decrypt the directory first", dir)
+		}
+		return state.New(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crawl state: %w", err)
+	}
+	return state.Decode(data)
+}
@@ -1,22 +1,59 @@
 package storage
 
 import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"os"
-	"path/filepath"
+	"io"
+	"maps"
+	"math"
+	"path"
+	"slices"
 	"sync"
 	"time"
 
+	"gtft-crawler/internal/index"
 	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/version"
 	"gtft-crawler/internal/worker"
 )
 
 type Storage struct {
-	outputDir string
-	fileLock  sync.RWMutex
-	stats     *Stats
-	verbose   bool
+	backend  Backend
+	fileLock sync.RWMutex
+	stats    *Stats
+	verbose  bool
+
+	// refresh overwrites existing records instead of skipping them
+	refresh bool
+	// metricsHistory appends a usage sample per record to metrics/{id}.jsonl
+	metricsHistory bool
+	// shard places new records in hash-prefixed subdirectories
+	shard bool
+
+	// added holds records first written during this run, for alerting
+	added []*parser.PaperMetadata
+
+	sinks []Sink
+
+	// stream, when set, receives each record as a JSON line instead of the backend
+	stream *json.Encoder
+
+	// onResult, when set, is told the final outcome of every task in SaveBatch
+	onResult func(url, id string, err error)
+
+	// namespace, when set, names the journal each URL's record belongs to
+	namespace func(url string) string
+}
+
+// Sink receives every record after it has been saved, e.g. to mirror
+// results into another system while the crawl runs. Write may be called
+// from several goroutines at once.
+type Sink interface {
+	Write(metadata *parser.PaperMetadata) error
+	Close() error
 }
 
 type Stats struct {
@@ -26,95 +63,358 @@ type Stats struct {
 	Skipped    int
 	StartTime  time.Time
 	LastUpdate time.Time
+	// Removed counts saved records newly marked as removed from the site
+	Removed int
+
+	// Completeness aggregates the scores of every valid record handled
+	Completeness CompletenessStats
+	// Coverage counts the fields missing from those records, by year
+	Coverage *index.Coverage
+	// Journals breaks the counts down by journal when records are
+	// namespaced
+	Journals map[string]*JournalStats
+}
+
+// CompletenessStats summarises record completeness scores.
+type CompletenessStats struct {
+	Records int     `json:"records"`
+	Mean    float64 `json:"mean"`
+	Min     int     `json:"min"`
+	// Buckets counts records by score range: 0-49, 50-79, 80-99 and 100
+	Buckets map[string]int `json:"buckets"`
+
+	sum int
+}
+
+func (c *CompletenessStats) add(score int) {
+	if c.Buckets == nil {
+		c.Buckets = map[string]int{"0-49": 0, "50-79": 0, "80-99": 0, "100": 0}
+	}
+	if c.Records == 0 || score < c.Min {
+		c.Min = score
+	}
+	c.Records++
+	c.sum += score
+	c.Mean = float64(c.sum) / float64(c.Records)
+
+	switch {
+	case score < 50:
+		c.Buckets["0-49"]++
+	case score < 80:
+		c.Buckets["50-79"]++
+	case score < 100:
+		c.Buckets["80-99"]++
+	default:
+		c.Buckets["100"]++
+	}
 }
 
 func NewStorage(outputDir string, verbose bool) *Storage {
 	return &Storage{
-		outputDir: outputDir,
+		backend: NewLocalBackend(outputDir),
 		stats: &Stats{
 			StartTime:  time.Now(),
+			Coverage:   index.NewCoverage(),
 			LastUpdate: time.Now(),
 		},
 		verbose: verbose,
 	}
 }
 
+// SetBackend replaces the local output directory with b, e.g. an SFTP or
+// WebDAV server. Storage takes ownership and closes b in Close.
+func (s *Storage) SetBackend(b Backend) {
+	s.backend = b
+}
+
+// SetStream writes each record to w as one line of JSON (NDJSON) instead of
+// saving it to the backend. Every record is written; nothing is skipped as
+// already present.
+func (s *Storage) SetStream(w io.Writer) {
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	s.stream = encoder
+}
+
+// AddSink registers a sink to receive saved records.
+func (s *Storage) AddSink(sink Sink) {
+	s.sinks = append(s.sinks, sink)
+}
+
+// Close flushes and closes all sinks, then the backend.
+func (s *Storage) Close() error {
+	var firstErr error
+	for _, sink := range s.sinks {
+		if err := sink.Close(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to close sink: %w", err)
+		}
+	}
+	if err := s.backend.Close(); err != nil && firstErr == nil {
+		firstErr = fmt.Errorf("failed to close backend: %w", err)
+	}
+	return firstErr
+}
+
 func (s *Storage) Save(metadata *parser.PaperMetadata) error {
+	written, err := s.save(metadata)
+	if err != nil || !written {
+		return err
+	}
+
+	// Sink failures are reported but don't fail the save
+	for _, sink := range s.sinks {
+		if err := sink.Write(metadata); err != nil {
+			fmt.Printf("[Sink] Failed to write %s: %v\n", metadata.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// save writes the record file and reports whether it was written.
+func (s *Storage) save(metadata *parser.PaperMetadata) (bool, error) {
 	if metadata == nil {
-		return fmt.Errorf("metadata is nil")
+		return false, fmt.Errorf("metadata is nil")
 	}
 
 	// Validate required fields
 	if !metadata.Validate() {
-		s.stats.Skipped++
+		s.count(metadata.URL, outcomeSkipped)
 		if s.verbose {
 			fmt.Printf("Skipping invalid metadata for URL: %s\n", metadata.URL)
 		}
-		return fmt.Errorf("metadata validation failed")
-	}
-
-	// Ensure output directory exists
-	if err := os.MkdirAll(s.outputDir, 0o755); err != nil {
-		return fmt.Errorf("failed to create output directory: %w", err)
+		return false, fmt.Errorf("metadata validation failed")
 	}
 
-	// Generate filename from article ID
-	filename := filepath.Join(s.outputDir, metadata.ID+".json")
+	Canonicalize(metadata)
 
 	// Acquire lock for this specific file
 	s.fileLock.Lock()
 	defer s.fileLock.Unlock()
 
+	s.stats.Completeness.add(metadata.Completeness)
+	s.stats.Coverage.Add(metadata)
+
+	if s.stream != nil {
+		if err := s.stream.Encode(metadata); err != nil {
+			s.tally(metadata.URL, outcomeFailed)
+			return false, fmt.Errorf("failed to write record to stream: %w", err)
+		}
+		s.tally(metadata.URL, outcomeSaved)
+		s.stats.LastUpdate = time.Now()
+		s.added = append(s.added, metadata)
+		return true, nil
+	}
+
+	// Usage samples are kept even when the record itself is unchanged
+	if s.metricsHistory {
+		if err := s.appendMetrics(metadata); err != nil {
+			return false, err
+		}
+	}
+
 	// Check if file already exists
-	if _, err := os.Stat(filename); err == nil {
+	filename, exists, err := s.locateRecord(metadata.URL, metadata.ID)
+	if err != nil {
+		s.tally(metadata.URL, outcomeFailed)
+		return false, fmt.Errorf("failed to check for existing record: %w", err)
+	}
+	if exists && !s.refresh {
 		if s.verbose {
 			fmt.Printf("File already exists, skipping: %s\n", filename)
 		}
-		s.stats.Skipped++
-		return nil
+		s.tally(metadata.URL, outcomeSkipped)
+		return false, nil
 	}
 
-	// Create temporary file for atomic write
-	tempFile := filename + ".tmp"
+	var buf bytes.Buffer
+	if err := EncodeJSON(&buf, metadata); err != nil {
+		s.tally(metadata.URL, outcomeFailed)
+		return false, err
+	}
 
-	// Write JSON to temporary file
-	if err := s.writeJSON(tempFile, metadata); err != nil {
-		// Clean up temp file on error
-		os.Remove(tempFile)
-		s.stats.Failed++
-		return fmt.Errorf("failed to write JSON: %w", err)
+	// A refreshed record whose content hasn't changed keeps its file, and
+	// with it the original parsed_at, so unchanged pages produce no diff
+	if exists {
+		unchanged, err := s.unchanged(filename, metadata)
+		if err != nil && s.verbose {
+			fmt.Printf("Can't compare with existing %s: %v\n", filename, err)
+		}
+		if unchanged {
+			if s.verbose {
+				fmt.Printf("Unchanged, keeping: %s\n", filename)
+			}
+			s.tally(metadata.URL, outcomeSkipped)
+			return false, nil
+		}
 	}
 
-	// Atomically rename temp file to final filename
-	if err := os.Rename(tempFile, filename); err != nil {
-		// Clean up temp file on error
-		os.Remove(tempFile)
-		s.stats.Failed++
-		return fmt.Errorf("failed to rename temp file: %w", err)
+	// The backend writes atomically, so readers never see a partial record
+	if err := s.backend.WriteFile(filename, buf.Bytes()); err != nil {
+		s.tally(metadata.URL, outcomeFailed)
+		return false, fmt.Errorf("failed to write JSON: %w", err)
 	}
 
-	s.stats.Saved++
+	s.tally(metadata.URL, outcomeSaved)
 	s.stats.LastUpdate = time.Now()
+	if !exists {
+		s.added = append(s.added, metadata)
+	}
 
 	if s.verbose {
 		fmt.Printf("Saved metadata to: %s\n", filename)
 	}
 
+	return true, nil
+}
+
+// SetResultHook registers fn to be called with the outcome of each task
+// handled by SaveBatch: err is nil once the record is saved or already
+// present, and id is the record's ID when the URL produced one. Sources use
+// it to acknowledge or record processed URLs.
+func (s *Storage) SetResultHook(fn func(url, id string, err error)) {
+	s.onResult = fn
+}
+
+func (s *Storage) reportResult(url, id string, err error) {
+	if s.onResult != nil {
+		s.onResult(url, id, err)
+	}
+}
+
+// SetRefresh makes Save overwrite existing records, for refresh runs that
+// re-crawl articles already in the output directory.
+func (s *Storage) SetRefresh(refresh bool) {
+	s.refresh = refresh
+}
+
+// SetSharding places new records at {xx}/{id}.json, where xx is the first
+// two hex digits of the ID's SHA-256, to keep directories small for large
+// corpora.
+func (s *Storage) SetSharding(enabled bool) {
+	s.shard = enabled
+}
+
+// RecordPath returns the path of a record relative to the output directory,
+// in the flat or sharded layout.
+func RecordPath(id string, sharded bool) string {
+	if !sharded {
+		return id + ".json"
+	}
+	sum := sha256.Sum256([]byte(id))
+	return path.Join(hex.EncodeToString(sum[:1]), id+".json")
+}
+
+// locateRecord finds an existing record in either layout, so switching
+// sharding on or off doesn't duplicate records. A record that doesn't exist
+// yet gets the path for the configured layout.
+func (s *Storage) locateRecord(url, id string) (string, bool, error) {
+	preferred := s.recordPath(url, id, s.shard)
+	for _, name := range []string{preferred, s.recordPath(url, id, !s.shard)} {
+		exists, err := s.backend.Exists(name)
+		if err != nil {
+			return "", false, err
+		}
+		if exists {
+			return name, true, nil
+		}
+	}
+	return preferred, false, nil
+}
+
+// SetMetricsHistory enables appending a timestamped views/downloads/citations
+// sample to metrics/{id}.jsonl for every saved or re-crawled record.
+func (s *Storage) SetMetricsHistory(enabled bool) {
+	s.metricsHistory = enabled
+}
+
+type metricsSample struct {
+	SampledAt string `json:"sampled_at"`
+	Views     int    `json:"views"`
+	Downloads int    `json:"downloads"`
+	Citations int    `json:"citations"`
+}
+
+// appendMetrics adds one line to the record's metrics history. Callers hold fileLock.
+func (s *Storage) appendMetrics(metadata *parser.PaperMetadata) error {
+	line, err := json.Marshal(metricsSample{
+		SampledAt: metadata.ParsedAt,
+		Views:     metadata.Views,
+		Downloads: metadata.Downloads,
+		Citations: metadata.Citations,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode metrics sample: %w", err)
+	}
+
+	if err := s.backend.AppendFile(s.AssetPath(metadata.URL, "metrics", metadata.ID+".jsonl"), append(line, '\n')); err != nil {
+		return fmt.Errorf("failed to append metrics sample: %w", err)
+	}
+
 	return nil
 }
 
-func (s *Storage) writeJSON(filename string, metadata *parser.PaperMetadata) error {
-	file, err := os.Create(filename)
+// WriteFile atomically writes data to name, a path relative to the output
+// directory. Parent directories are created as needed.
+func (s *Storage) WriteFile(name string, data []byte) error {
+	return s.backend.WriteFile(name, data)
+}
+
+// ReadFile reads name, a path relative to the output directory.
+func (s *Storage) ReadFile(name string) ([]byte, error) {
+	return s.backend.ReadFile(name)
+}
+
+// Exists reports whether name, a path relative to the output directory,
+// exists.
+func (s *Storage) Exists(name string) (bool, error) {
+	return s.backend.Exists(name)
+}
+
+// HasRecord reports whether the record with this ID crawled from url is
+// stored, in either layout.
+func (s *Storage) HasRecord(url, id string) (bool, error) {
+	_, exists, err := s.locateRecord(url, id)
+	return exists, err
+}
+
+// Unchanged is the result of a page the server reported unchanged since it
+// was saved as the record ID. Its record is kept as it is and counted as
+// skipped, like a refreshed record whose content didn't change.
+type Unchanged struct {
+	ID string
+}
+
+// unchanged reports whether the record stored at filename matches metadata
+// in everything but parsed_at.
+func (s *Storage) unchanged(filename string, metadata *parser.PaperMetadata) (bool, error) {
+	data, err := s.backend.ReadFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to create file: %w", err)
+		return false, err
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
+	var existing parser.PaperMetadata
+	if err := json.Unmarshal(data, &existing); err != nil {
+		return false, fmt.Errorf("invalid record: %w", err)
+	}
+
+	candidate := *metadata
+	candidate.ParsedAt = existing.ParsedAt
+
+	var buf bytes.Buffer
+	if err := EncodeJSON(&buf, &candidate); err != nil {
+		return false, err
+	}
+	return bytes.Equal(buf.Bytes(), data), nil
+}
+
+// EncodeJSON writes v in the indented, unescaped layout used for record files.
+func EncodeJSON(w io.Writer, v any) error {
+	encoder := json.NewEncoder(w)
 	encoder.SetIndent("", "  ")
 	encoder.SetEscapeHTML(false)
 
-	if err := encoder.Encode(metadata); err != nil {
+	if err := encoder.Encode(v); err != nil {
 		return fmt.Errorf("failed to encode JSON: %w", err)
 	}
 
@@ -125,17 +425,63 @@ func (s *Storage) SaveBatch(results <-chan worker.Result) error {
 	var wg sync.WaitGroup
 	errors := make(chan error, 100)
 
+	// Collect errors as they arrive so a long-running stream can't fill the
+	// buffer and block the savers
+	var errorList []error
+	collected := make(chan struct{})
+	go func() {
+		defer close(collected)
+		for err := range errors {
+			errorList = append(errorList, err)
+		}
+	}()
+
 	// Process results concurrently
 	for result := range results {
 		wg.Add(1)
 		go func(r worker.Result) {
 			defer wg.Done()
 
+			// Skipped before fetching; there is nothing new to record
+			if r.Skipped != "" {
+				s.count(r.Task.URL, outcomeSkipped)
+				s.reportResult(r.Task.URL, "", nil)
+				return
+			}
+
 			if r.Error != nil {
 				if s.verbose {
-					fmt.Printf("Task failed: %s, error: %v\n", r.Task.URL, r.Error)
+					if r.Attempts > 0 {
+						fmt.Printf("Task failed: %s (%d attempts, last status %d), error: %v\n", r.Task.URL, r.Attempts, r.StatusCode, r.Error)
+					} else {
+						fmt.Printf("Task failed: %s, error: %v\n", r.Task.URL, r.Error)
+					}
+				}
+				s.count(r.Task.URL, outcomeFailed)
+				s.reportResult(r.Task.URL, "", r.Error)
+				return
+			}
+
+			if removed, ok := r.Data.(Removed); ok {
+				if err := s.saveRemoved(r.Task.URL, removed); err != nil {
+					errors <- fmt.Errorf("failed to mark %s removed: %w", r.Task.URL, err)
+				}
+				s.reportResult(r.Task.URL, removed.ID, removed.Err)
+				return
+			}
+
+			if unchanged, ok := r.Data.(Unchanged); ok {
+				if s.verbose {
+					fmt.Printf("Not modified, keeping: %s\n", unchanged.ID)
 				}
-				s.stats.Failed++
+				s.count(r.Task.URL, outcomeSkipped)
+				s.reportResult(r.Task.URL, unchanged.ID, nil)
+				return
+			}
+
+			// Pages visited only for their links produce no record
+			if r.Data == nil {
+				s.reportResult(r.Task.URL, "", nil)
 				return
 			}
 
@@ -143,25 +489,23 @@ func (s *Storage) SaveBatch(results <-chan worker.Result) error {
 			if !ok {
 				err := fmt.Errorf("invalid data type for URL: %s", r.Task.URL)
 				errors <- err
-				s.stats.Failed++
+				s.count(r.Task.URL, outcomeFailed)
+				s.reportResult(r.Task.URL, "", err)
 				return
 			}
 
-			if err := s.Save(metadata); err != nil {
+			err := s.Save(metadata)
+			if err != nil {
 				errors <- fmt.Errorf("failed to save metadata for URL %s: %w", r.Task.URL, err)
 			}
+			s.reportResult(r.Task.URL, metadata.ID, err)
 		}(result)
 	}
 
 	// Wait for all goroutines to complete
 	wg.Wait()
 	close(errors)
-
-	// Collect errors
-	var errorList []error
-	for err := range errors {
-		errorList = append(errorList, err)
-	}
+	<-collected
 
 	if len(errorList) > 0 {
 		return fmt.Errorf("batch save completed with %d errors", len(errorList))
@@ -171,39 +515,49 @@ func (s *Storage) SaveBatch(results <-chan worker.Result) error {
 }
 
 func (s *Storage) SaveStats() error {
-	statsFile := filepath.Join(s.outputDir, "stats.json")
-
-	stats := struct {
-		Total       int       `json:"total"`
-		Saved       int       `json:"saved"`
-		Failed      int       `json:"failed"`
-		Skipped     int       `json:"skipped"`
-		SuccessRate float64   `json:"success_rate"`
-		StartTime   time.Time `json:"start_time"`
-		EndTime     time.Time `json:"end_time"`
-		Duration    string    `json:"duration"`
-	}{
+	// Streaming sources have no total up front; rate against what was processed
+	total := s.stats.Total
+	if total == 0 {
+		total = s.stats.Saved + s.stats.Failed + s.stats.Skipped
+	}
+	successRate := 0.0
+	if total > 0 {
+		successRate = float64(s.stats.Saved) / float64(total) * 100
+	}
+
+	stats := RunStats{
+		RunID:       s.RunID(),
 		Total:       s.stats.Total,
 		Saved:       s.stats.Saved,
 		Failed:      s.stats.Failed,
 		Skipped:     s.stats.Skipped,
-		SuccessRate: float64(s.stats.Saved) / float64(s.stats.Total) * 100,
+		Removed:     s.stats.Removed,
+		SuccessRate: successRate,
 		StartTime:   s.stats.StartTime,
 		EndTime:     time.Now(),
 		Duration:    time.Since(s.stats.StartTime).String(),
+		Crawler:     version.Get(),
+		Journals:    s.stats.Journals,
+	}
+	if s.stats.Completeness.Records > 0 {
+		completeness := s.stats.Completeness
+		completeness.Mean = math.Round(completeness.Mean*10) / 10
+		stats.Completeness = &completeness
+		stats.Coverage = s.stats.Coverage
 	}
 
-	file, err := os.Create(statsFile)
+	data, err := json.MarshalIndent(stats, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to create stats file: %w", err)
+		return fmt.Errorf("failed to encode stats JSON: %w", err)
 	}
-	defer file.Close()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
+	data = append(data, '\n')
 
-	if err := encoder.Encode(stats); err != nil {
-		return fmt.Errorf("failed to encode stats JSON: %w", err)
+	if err := s.backend.WriteFile("stats.json", data); err != nil {
+		return fmt.Errorf("failed to write stats file: %w", err)
+	}
+	if err := s.backend.WriteFile(RunStatsPath(stats.RunID), data); err != nil {
+		return fmt.Errorf("failed to write run history: %w", err)
 	}
 
 	return nil
@@ -213,10 +567,26 @@ func (s *Storage) SetTotal(total int) {
 	s.stats.Total = total
 }
 
+// Added returns the records written for the first time by this Storage.
+func (s *Storage) Added() []*parser.PaperMetadata {
+	s.fileLock.RLock()
+	defer s.fileLock.RUnlock()
+
+	return append([]*parser.PaperMetadata(nil), s.added...)
+}
+
 func (s *Storage) GetStats() *Stats {
 	return s.stats
 }
 
+// Counts returns the running totals, safely while records are being saved.
+func (s *Storage) Counts() (total, saved, failed, skipped int) {
+	s.fileLock.RLock()
+	defer s.fileLock.RUnlock()
+
+	return s.stats.Total, s.stats.Saved, s.stats.Failed, s.stats.Skipped
+}
+
 func (s *Storage) PrintStats() {
 	total := s.stats.Saved + s.stats.Failed + s.stats.Skipped
 	elapsed := time.Since(s.stats.StartTime)
@@ -226,6 +596,9 @@ func (s *Storage) PrintStats() {
 	fmt.Printf("Successfully saved: %d\n", s.stats.Saved)
 	fmt.Printf("Failed: %d\n", s.stats.Failed)
 	fmt.Printf("Skipped: %d\n", s.stats.Skipped)
+	if s.stats.Removed > 0 {
+		fmt.Printf("Marked removed: %d\n", s.stats.Removed)
+	}
 
 	if total > 0 {
 		successRate := float64(s.stats.Saved) / float64(total) * 100
@@ -238,4 +611,13 @@ func (s *Storage) PrintStats() {
 		avgTime := elapsed / time.Duration(s.stats.Saved)
 		fmt.Printf("Average time per save: %v\n", avgTime.Round(time.Millisecond))
 	}
+
+	if c := s.stats.Completeness; c.Records > 0 {
+		fmt.Printf("Completeness: mean %.1f, min %d (%d records below 50)\n", c.Mean, c.Min, c.Buckets["0-49"])
+	}
+
+	for _, name := range slices.Sorted(maps.Keys(s.stats.Journals)) {
+		j := s.stats.Journals[name]
+		fmt.Printf("Journal %s: %d saved, %d failed, %d skipped\n", name, j.Saved, j.Failed, j.Skipped)
+	}
 }
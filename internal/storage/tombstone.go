@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Removed is the result of a page that answered 404 Not Found or 410 Gone
+// although its record ID is saved. The record is kept but marked with a
+// tombstone, so it can be told apart from live articles, and the URL still
+// fails with Err.
+type Removed struct {
+	ID         string
+	StatusCode int
+	Err        error
+}
+
+// tombstone marks the saved record of a removed page, keeping the time an
+// earlier run first marked it. It reports whether the record was written.
+// Callers hold fileLock.
+func (s *Storage) tombstone(url string, removed Removed) (*parser.PaperMetadata, bool, error) {
+	filename, exists, err := s.locateRecord(url, removed.ID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to find record %s: %w", removed.ID, err)
+	}
+	if !exists {
+		return nil, false, nil
+	}
+
+	data, err := s.backend.ReadFile(filename)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to read record %s: %w", removed.ID, err)
+	}
+	var metadata parser.PaperMetadata
+	if err := json.Unmarshal(data, &metadata); err != nil {
+		return nil, false, fmt.Errorf("invalid record %s: %w", removed.ID, err)
+	}
+	if metadata.Removed != nil {
+		return &metadata, false, nil
+	}
+
+	metadata.Removed = &parser.Tombstone{
+		StatusCode: removed.StatusCode,
+		RemovedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+	var buf bytes.Buffer
+	if err := EncodeJSON(&buf, &metadata); err != nil {
+		return nil, false, err
+	}
+	if err := s.backend.WriteFile(filename, buf.Bytes()); err != nil {
+		return nil, false, fmt.Errorf("failed to write JSON: %w", err)
+	}
+	s.stats.Removed++
+	if s.verbose {
+		fmt.Printf("Marked removed (HTTP %d): %s\n", removed.StatusCode, filename)
+	}
+	return &metadata, true, nil
+}
+
+// saveRemoved tombstones the record of a removed page and passes the marked
+// record to the sinks. The URL counts as failed either way.
+func (s *Storage) saveRemoved(url string, removed Removed) error {
+	s.fileLock.Lock()
+	metadata, written, err := s.tombstone(url, removed)
+	s.tally(url, outcomeFailed)
+	s.fileLock.Unlock()
+	if err != nil || !written {
+		return err
+	}
+
+	for _, sink := range s.sinks {
+		if err := sink.Write(metadata); err != nil {
+			fmt.Printf("[Sink] Failed to write %s: %v\n", metadata.ID, err)
+		}
+	}
+	return nil
+}
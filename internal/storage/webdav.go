@@ -0,0 +1,197 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebDAVBackend writes output files to a WebDAV share.
+type WebDAVBackend struct {
+	base     *url.URL
+	username string
+	password string
+	client   *http.Client
+
+	// Collections already created, so MKCOL is issued once per directory
+	mu          sync.Mutex
+	collections map[string]bool
+}
+
+// NewWebDAVBackend connects to webdav://host/path (plain HTTP) or
+// webdavs://host/path (HTTPS).
+func NewWebDAVBackend(u *url.URL, opts BackendOptions) (*WebDAVBackend, error) {
+	base := *u
+	base.Scheme = "http"
+	if u.Scheme == "webdavs" {
+		base.Scheme = "https"
+	}
+	base.User = nil
+	base.Path = strings.TrimSuffix(base.Path, "/")
+
+	b := &WebDAVBackend{
+		base:        &base,
+		password:    opts.Password,
+		client:      &http.Client{Timeout: 60 * time.Second},
+		collections: make(map[string]bool),
+	}
+	if u.User != nil {
+		b.username = u.User.Username()
+		if password, ok := u.User.Password(); ok {
+			b.password = password
+		}
+	}
+
+	return b, nil
+}
+
+func (b *WebDAVBackend) url(name string) string {
+	return b.urlForPath(path.Join(b.base.Path, name))
+}
+
+func (b *WebDAVBackend) urlForPath(p string) string {
+	u := *b.base
+	u.Path = p
+	return u.String()
+}
+
+func (b *WebDAVBackend) do(method, name string, body []byte, header http.Header) (*http.Response, error) {
+	return b.doURL(method, b.url(name), body, header)
+}
+
+func (b *WebDAVBackend) doURL(method, rawURL string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := http.NewRequest(method, rawURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	if b.username != "" {
+		req.SetBasicAuth(b.username, b.password)
+	}
+	return b.client.Do(req)
+}
+
+func (b *WebDAVBackend) WriteFile(name string, data []byte) error {
+	if err := b.mkcolAll(path.Dir(path.Join("/", b.base.Path, name))); err != nil {
+		return err
+	}
+
+	// Upload under a temporary name and MOVE it into place so readers
+	// never see a partial file
+	tempName := name + ".tmp"
+	resp, err := b.do("PUT", tempName, data, nil)
+	if err != nil {
+		return fmt.Errorf("WebDAV PUT failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("WebDAV PUT %s: HTTP %d", tempName, resp.StatusCode)
+	}
+
+	resp, err = b.do("MOVE", tempName, nil, http.Header{
+		"Destination": {b.url(name)},
+		"Overwrite":   {"T"},
+	})
+	if err != nil {
+		return fmt.Errorf("WebDAV MOVE failed: %w", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("WebDAV MOVE %s: HTTP %d", name, resp.StatusCode)
+	}
+
+	return nil
+}
+
+func (b *WebDAVBackend) ReadFile(name string) ([]byte, error) {
+	resp, err := b.do("GET", name, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("WebDAV GET failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%s: %w", name, os.ErrNotExist)
+	}
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("WebDAV GET %s: HTTP %d", name, resp.StatusCode)
+	}
+
+	return io.ReadAll(resp.Body)
+}
+
+func (b *WebDAVBackend) Exists(name string) (bool, error) {
+	resp, err := b.do("HEAD", name, nil, nil)
+	if err != nil {
+		return false, fmt.Errorf("WebDAV HEAD failed: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("WebDAV HEAD %s: HTTP %d", name, resp.StatusCode)
+	default:
+		return true, nil
+	}
+}
+
+// AppendFile rewrites the whole file, as WebDAV has no append operation.
+func (b *WebDAVBackend) AppendFile(name string, data []byte) error {
+	existing, err := b.ReadFile(name)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return b.WriteFile(name, append(existing, data...))
+}
+
+// mkcolAll creates the collection at dir, an absolute server path, and any
+// missing parents.
+func (b *WebDAVBackend) mkcolAll(dir string) error {
+	if dir == "/" || dir == "." {
+		return nil
+	}
+
+	b.mu.Lock()
+	done := b.collections[dir]
+	b.mu.Unlock()
+	if done {
+		return nil
+	}
+
+	if err := b.mkcolAll(path.Dir(dir)); err != nil {
+		return err
+	}
+
+	resp, err := b.doURL("MKCOL", b.urlForPath(dir+"/"), nil, nil)
+	if err != nil {
+		return fmt.Errorf("WebDAV MKCOL failed: %w", err)
+	}
+	resp.Body.Close()
+
+	// 405 means the collection already exists
+	if resp.StatusCode >= 300 && resp.StatusCode != http.StatusMethodNotAllowed {
+		return fmt.Errorf("WebDAV MKCOL %s: HTTP %d", dir, resp.StatusCode)
+	}
+
+	b.mu.Lock()
+	b.collections[dir] = true
+	b.mu.Unlock()
+
+	return nil
+}
+
+func (b *WebDAVBackend) Close() error {
+	return nil
+}
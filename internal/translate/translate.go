@@ -0,0 +1,130 @@
+// Package translate fills the English fields a record lacks with machine
+// translations of its Chinese ones, through a translation endpoint the user
+// runs or subscribes to.
+package translate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Translator sends texts to an HTTP endpoint that takes
+//
+//	{"source": "zh", "target": "en", "texts": ["...", ...]}
+//
+// and answers {"translations": ["...", ...]}, one translation per text in
+// the same order. A small adapter can put any translation API behind it.
+type Translator struct {
+	url    string
+	apiKey string
+	client *http.Client
+}
+
+// New returns a Translator posting to url, sending apiKey as a bearer token
+// when it isn't empty.
+func New(url, apiKey string) *Translator {
+	return &Translator{url: url, apiKey: apiKey, client: &http.Client{Timeout: 60 * time.Second}}
+}
+
+type request struct {
+	Source string   `json:"source"`
+	Target string   `json:"target"`
+	Texts  []string `json:"texts"`
+}
+
+type response struct {
+	Translations []string `json:"translations"`
+}
+
+// Apply translates the Chinese title, abstract and keywords of a record
+// whose English ones are missing, in one request, and lists the fields it
+// filled in MachineTranslated. Records with nothing to translate don't
+// send a request.
+func (t *Translator) Apply(metadata *parser.PaperMetadata) error {
+	var texts, fields []string
+	if metadata.TitleEN == "" && metadata.TitleCN != "" {
+		texts = append(texts, metadata.TitleCN)
+		fields = append(fields, "title_en")
+	}
+	if metadata.AbstractEN == "" && metadata.AbstractCN != "" {
+		texts = append(texts, metadata.AbstractCN)
+		fields = append(fields, "abstract_en")
+	}
+	keywords := 0
+	if len(metadata.KeywordsEN) == 0 && len(metadata.KeywordsCN) > 0 {
+		keywords = len(metadata.KeywordsCN)
+		texts = append(texts, metadata.KeywordsCN...)
+		fields = append(fields, "keywords_en")
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	translations, err := t.translate(texts)
+	if err != nil {
+		return err
+	}
+
+	next := 0
+	for _, field := range fields {
+		switch field {
+		case "title_en":
+			metadata.TitleEN = translations[next]
+			next++
+		case "abstract_en":
+			metadata.AbstractEN = translations[next]
+			next++
+		case "keywords_en":
+			metadata.KeywordsEN = translations[next : next+keywords]
+			next += keywords
+		}
+		if !slices.Contains(metadata.MachineTranslated, field) {
+			metadata.MachineTranslated = append(metadata.MachineTranslated, field)
+		}
+	}
+
+	return nil
+}
+
+// translate returns the English translations of texts.
+func (t *Translator) translate(texts []string) ([]string, error) {
+	body, err := json.Marshal(request{Source: "zh", Target: "en", Texts: texts})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode translation request: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", t.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create translation request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if t.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+t.apiKey)
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("translation request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("translation rejected: HTTP %d", resp.StatusCode)
+	}
+
+	var result response
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("invalid translation response: %w", err)
+	}
+	if len(result.Translations) != len(texts) {
+		return nil, fmt.Errorf("translation response has %d texts, want %d", len(result.Translations), len(texts))
+	}
+
+	return result.Translations, nil
+}
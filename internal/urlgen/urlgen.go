@@ -0,0 +1,141 @@
+package urlgen
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Var is a template placeholder and the values it ranges over.
+type Var struct {
+	Name   string
+	Values []string
+}
+
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// ParseVar parses a -var flag value of the form
+//
+//	name=1-45      integer range (a zero-padded start, e.g. 01-12, pads all values)
+//	name=a,b,c     explicit list
+//	name=@ids.txt  one value per line of a file (blank lines and # comments skipped)
+func ParseVar(spec string) (Var, error) {
+	name, value, ok := strings.Cut(spec, "=")
+	if !ok || name == "" || value == "" {
+		return Var{}, fmt.Errorf("invalid variable %q: want name=values", spec)
+	}
+
+	var values []string
+	var err error
+	switch {
+	case strings.HasPrefix(value, "@"):
+		values, err = readValues(value[1:])
+	case isRange(value):
+		values, err = expandRange(value)
+	default:
+		for _, v := range strings.Split(value, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	}
+	if err != nil {
+		return Var{}, fmt.Errorf("variable %s: %w", name, err)
+	}
+	if len(values) == 0 {
+		return Var{}, fmt.Errorf("variable %s has no values", name)
+	}
+
+	return Var{Name: name, Values: values}, nil
+}
+
+var rangePattern = regexp.MustCompile(`^(\d+)-(\d+)$`)
+
+func isRange(value string) bool {
+	return rangePattern.MatchString(value)
+}
+
+func expandRange(value string) ([]string, error) {
+	m := rangePattern.FindStringSubmatch(value)
+	start, _ := strconv.Atoi(m[1])
+	end, _ := strconv.Atoi(m[2])
+	if end < start {
+		return nil, fmt.Errorf("range %s ends before it starts", value)
+	}
+
+	width := 0
+	if len(m[1]) > 1 && m[1][0] == '0' {
+		width = len(m[1])
+	}
+
+	values := make([]string, 0, end-start+1)
+	for i := start; i <= end; i++ {
+		values = append(values, fmt.Sprintf("%0*d", width, i))
+	}
+	return values, nil
+}
+
+func readValues(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open value file: %w", err)
+	}
+	defer file.Close()
+
+	var values []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			values = append(values, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read value file: %w", err)
+	}
+
+	return values, nil
+}
+
+// Expand returns every URL produced by substituting vars into template,
+// iterating the first variable slowest. Each placeholder in the template
+// must have exactly one variable and vice versa.
+func Expand(template string, vars []Var) ([]string, error) {
+	used := make(map[string]bool)
+	for _, m := range placeholderPattern.FindAllStringSubmatch(template, -1) {
+		used[m[1]] = true
+	}
+
+	defined := make(map[string]bool)
+	for _, v := range vars {
+		if defined[v.Name] {
+			return nil, fmt.Errorf("variable %s defined twice", v.Name)
+		}
+		defined[v.Name] = true
+		if !used[v.Name] {
+			return nil, fmt.Errorf("variable %s does not appear in the template", v.Name)
+		}
+	}
+	for name := range used {
+		if !defined[name] {
+			return nil, fmt.Errorf("template placeholder {%s} has no variable", name)
+		}
+	}
+
+	urls := []string{template}
+	for _, v := range vars {
+		placeholder := "{" + v.Name + "}"
+		next := make([]string, 0, len(urls)*len(v.Values))
+		for _, url := range urls {
+			for _, value := range v.Values {
+				next = append(next, strings.ReplaceAll(url, placeholder, value))
+			}
+		}
+		urls = next
+	}
+
+	return urls, nil
+}
@@ -0,0 +1,88 @@
+package version
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"gtft-crawler/internal/parser"
+)
+
+// Build metadata, set at link time:
+//
+//	go build -ldflags "-X gtft-crawler/internal/version.Version=v1.2.0 \
+//	  -X gtft-crawler/internal/version.Commit=$(git rev-parse --short HEAD) \
+//	  -X gtft-crawler/internal/version.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left empty are filled from the embedded Go build info when available.
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// Info describes the crawler build that produced a dataset.
+type Info struct {
+	Version     string `json:"version"`
+	Commit      string `json:"commit"`
+	BuildDate   string `json:"build_date"`
+	ParserRules string `json:"parser_rules"`
+	GoVersion   string `json:"go_version"`
+}
+
+// Get returns the build information for the running binary.
+func Get() Info {
+	info := Info{
+		Version:     Version,
+		Commit:      Commit,
+		BuildDate:   BuildDate,
+		ParserRules: parser.RulesVersion,
+		GoVersion:   runtime.Version(),
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "" {
+			info.Version = bi.Main.Version
+		}
+
+		var revision, revisionTime string
+		modified := false
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				revision = setting.Value
+			case "vcs.time":
+				revisionTime = setting.Value
+			case "vcs.modified":
+				modified = setting.Value == "true"
+			}
+		}
+
+		if info.Commit == "" && revision != "" {
+			info.Commit = revision
+			if modified {
+				info.Commit += "-dirty"
+			}
+		}
+		if info.BuildDate == "" {
+			info.BuildDate = revisionTime
+		}
+	}
+
+	if info.Version == "" {
+		info.Version = "(devel)"
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.BuildDate == "" {
+		info.BuildDate = "unknown"
+	}
+
+	return info
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("gtft-crawler %s (commit %s, built %s, parser rules %s, %s)",
+		i.Version, i.Commit, i.BuildDate, i.ParserRules, i.GoVersion)
+}
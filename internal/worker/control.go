@@ -0,0 +1,169 @@
+package worker
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/time/rate"
+)
+
+// Pause stops workers from starting new tasks; tasks already running finish.
+func (wp *WorkerPool) Pause() {
+	wp.control.Lock()
+	defer wp.control.Unlock()
+
+	if wp.gate == nil && !wp.stopping {
+		wp.gate = make(chan struct{})
+		if wp.verbose {
+			fmt.Println("Worker pool: paused")
+		}
+	}
+}
+
+// Resume lets paused workers continue.
+func (wp *WorkerPool) Resume() {
+	wp.control.Lock()
+	defer wp.control.Unlock()
+
+	wp.resumeLocked()
+}
+
+func (wp *WorkerPool) resumeLocked() {
+	if wp.gate != nil {
+		close(wp.gate)
+		wp.gate = nil
+		if wp.verbose {
+			fmt.Println("Worker pool: resumed")
+		}
+	}
+}
+
+// Paused reports whether the pool is paused.
+func (wp *WorkerPool) Paused() bool {
+	wp.control.Lock()
+	defer wp.control.Unlock()
+
+	return wp.gate != nil
+}
+
+// StopIntake ends processing gracefully: no more tasks are started, tasks
+// already running finish and report their results, and the result channel
+// is closed as when the worklist is exhausted. A paused pool is resumed so
+// it can wind down.
+func (wp *WorkerPool) StopIntake() {
+	wp.control.Lock()
+	defer wp.control.Unlock()
+
+	if wp.stopping {
+		return
+	}
+	wp.stopping = true
+	close(wp.stopIntake)
+	wp.resumeLocked()
+}
+
+// SetStopWhen makes the pool stop intake, as with StopIntake, once fn
+// returns true. fn is checked before each task starts, so it must be cheap.
+func (wp *WorkerPool) SetStopWhen(fn func() bool) {
+	wp.stopWhen = fn
+}
+
+// Stopping reports whether StopIntake was called.
+func (wp *WorkerPool) Stopping() bool {
+	wp.control.Lock()
+	defer wp.control.Unlock()
+
+	return wp.stopping
+}
+
+// SetRate changes the shared rate limit, in requests per second.
+func (wp *WorkerPool) SetRate(requestsPerSecond int) error {
+	if requestsPerSecond <= 0 {
+		return fmt.Errorf("rate must be greater than 0")
+	}
+
+	wp.control.Lock()
+	defer wp.control.Unlock()
+
+	wp.rateLimit = requestsPerSecond
+	wp.rateLimiter.SetLimit(rate.Limit(requestsPerSecond))
+	wp.rateLimiter.SetBurst(requestsPerSecond)
+	return nil
+}
+
+// Rate returns the shared rate limit, in requests per second.
+func (wp *WorkerPool) Rate() int {
+	wp.control.Lock()
+	defer wp.control.Unlock()
+
+	return wp.rateLimit
+}
+
+// WaitRate waits for a token from the shared rate limiter, for requests
+// made by a task beyond its page, such as its images. It fails once the
+// pool's context is cancelled.
+func (wp *WorkerPool) WaitRate() error {
+	return wp.rateLimiter.Wait(wp.ctx)
+}
+
+// TryRate takes a token from the shared rate limiter if one is available
+// now, for optional requests that are better dropped than delayed, such as
+// hedges.
+func (wp *WorkerPool) TryRate() bool {
+	return wp.rateLimiter.Allow()
+}
+
+// SetThrottle makes workers wait until the time fn returns before starting
+// a task, such as the end of a pause the site asked for with Retry-After. The
+// zero time means no wait. fn is checked before each task starts, so it must
+// be cheap.
+func (wp *WorkerPool) SetThrottle(fn func() time.Time) {
+	wp.throttle = fn
+}
+
+// Throttled reports whether workers are waiting out a throttle.
+func (wp *WorkerPool) Throttled() bool {
+	return wp.throttle != nil && !wp.throttle().IsZero()
+}
+
+// waitThrottle waits out the throttle, if any, or until intake is stopped.
+// It returns false if the pool's context is cancelled first.
+func (wp *WorkerPool) waitThrottle() bool {
+	if wp.throttle == nil {
+		return true
+	}
+	until := wp.throttle()
+	if until.IsZero() {
+		return true
+	}
+
+	wp.statsMu.Lock()
+	if until.After(wp.stats.ThrottledUntil) {
+		wp.stats.ThrottledUntil = until
+		wp.stats.Throttles++
+	}
+	wp.statsMu.Unlock()
+
+	select {
+	case <-time.After(time.Until(until)):
+		return true
+	case <-wp.stopIntake:
+		return true
+	case <-wp.ctx.Done():
+		return false
+	}
+}
+
+func (wp *WorkerPool) waitWhilePaused() {
+	wp.control.Lock()
+	gate := wp.gate
+	wp.control.Unlock()
+
+	if gate == nil {
+		return
+	}
+	select {
+	case <-gate:
+	case <-wp.ctx.Done():
+	}
+}
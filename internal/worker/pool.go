@@ -13,17 +13,43 @@ import (
 type ProcessFunc func(url string) (any, error)
 
 type WorkerPool struct {
-	workers     int
-	rateLimit   int
-	taskQueue   chan Task
-	resultChan  chan Result
-	wg          sync.WaitGroup
-	taskGenWg   sync.WaitGroup
+	workers    int
+	rateLimit  int
+	taskQueue  chan Task
+	resultChan chan Result
+	wg         sync.WaitGroup
+	taskGenWg  sync.WaitGroup
+	// statsMu guards stats, which the generator, workers and stats monitor
+	// all touch
+	statsMu     sync.Mutex
 	stats       *Stats
 	ctx         context.Context
 	cancel      context.CancelFunc
 	verbose     bool
 	rateLimiter *rate.Limiter
+	// rateExempt reports URLs that don't reach the network and so skip
+	// rate limiting
+	rateExempt func(url string) bool
+
+	// control guards pausing and stopping: while paused, gate is open
+	// until Resume closes it; stopIntake is closed by StopIntake
+	control    sync.Mutex
+	gate       chan struct{}
+	stopIntake chan struct{}
+	stopping   bool
+	// stopWhen, when set, is checked before each task starts
+	stopWhen func() bool
+	// skip, when set, is asked about each URL before it is queued
+	skip func(url string) (reason string, skip bool)
+	// throttle, when set, returns when workers may start tasks again
+	throttle func() time.Time
+
+	// onResult, when set, is called with every result; discardResults
+	// keeps results off the result channel
+	onResult       func(Result)
+	discardResults bool
+	// streaming is set by ProcessStream, whose total grows as URLs arrive
+	streaming bool
 }
 
 func NewPool(workers, rateLimit int, verbose bool) *WorkerPool {
@@ -39,12 +65,52 @@ func NewPool(workers, rateLimit int, verbose bool) *WorkerPool {
 		cancel:      cancel,
 		verbose:     verbose,
 		rateLimiter: rate.NewLimiter(rate.Limit(rateLimit), rateLimit),
+		stopIntake:  make(chan struct{}),
 	}
 }
 
+// SetRateExempt skips rate limiting for URLs fn returns true for, such as
+// those answered from a cache.
+func (wp *WorkerPool) SetRateExempt(fn func(url string) bool) {
+	wp.rateExempt = fn
+}
+
+// SetSkip makes the task generator ask fn about each URL before queueing
+// it. URLs fn skips never reach a worker or the rate limiter: their results,
+// with Skipped set to fn's reason, are delivered by the generator itself.
+func (wp *WorkerPool) SetSkip(fn func(url string) (reason string, skip bool)) {
+	wp.skip = fn
+}
+
+// SetOnResult makes the pool call fn with each result as its task
+// completes, from the worker that ran it and before the result is sent on
+// the result channel. fn is called from several workers at once, and a slow
+// fn holds up its worker. Set it before Process.
+func (wp *WorkerPool) SetOnResult(fn func(Result)) {
+	wp.onResult = fn
+}
+
+// DiscardResults stops results being sent on the channel Process returns,
+// for callers that consume them through SetOnResult alone and so needn't
+// drain it. The channel is still closed by Stop.
+func (wp *WorkerPool) DiscardResults() {
+	wp.discardResults = true
+}
+
 func (wp *WorkerPool) Process(urls []string, processFunc ProcessFunc) <-chan Result {
 	wp.stats.Total = len(urls)
 
+	return wp.start(processFunc, func() { wp.generateTasks(urls) })
+}
+
+// ProcessStream is like Process but takes URLs from a channel, for sources
+// with no fixed worklist. Processing ends when urls is closed.
+func (wp *WorkerPool) ProcessStream(urls <-chan string, processFunc ProcessFunc) <-chan Result {
+	wp.streaming = true
+	return wp.start(processFunc, func() { wp.streamTasks(urls) })
+}
+
+func (wp *WorkerPool) start(processFunc ProcessFunc, generate func()) <-chan Result {
 	// Start workers
 	for i := 0; i < wp.workers; i++ {
 		wp.wg.Add(1)
@@ -55,7 +121,7 @@ func (wp *WorkerPool) Process(urls []string, processFunc ProcessFunc) <-chan Res
 	wp.taskGenWg.Add(1)
 	go func() {
 		defer wp.taskGenWg.Done()
-		wp.generateTasks(urls)
+		generate()
 	}()
 
 	// Start stats monitor
@@ -74,6 +140,11 @@ func (wp *WorkerPool) generateTasks(urls []string) {
 	sent := 0
 	for _, url := range urls {
 		task := NewTask(extractIDFromURL(url), url)
+		if skipped, ok := wp.skipTask(task); !ok {
+			return
+		} else if skipped {
+			continue
+		}
 		select {
 		case wp.taskQueue <- task:
 			sent++
@@ -85,6 +156,12 @@ func (wp *WorkerPool) generateTasks(urls []string) {
 				fmt.Printf("Task generator: context cancelled, sent %d/%d tasks\n", sent, len(urls))
 			}
 			return
+		case <-wp.stopIntake:
+			if wp.verbose {
+				fmt.Printf("Task generator: stopped, sent %d/%d tasks\n", sent, len(urls))
+			}
+			close(wp.taskQueue)
+			return
 		}
 	}
 	close(wp.taskQueue)
@@ -94,67 +171,84 @@ func (wp *WorkerPool) generateTasks(urls []string) {
 	}
 }
 
-func (wp *WorkerPool) worker(processFunc ProcessFunc) {
-	defer wp.wg.Done()
-
+func (wp *WorkerPool) streamTasks(urls <-chan string) {
 	if wp.verbose {
-		fmt.Printf("Worker started\n")
+		fmt.Printf("Task generator started, streaming URLs\n")
 	}
 
+	defer close(wp.taskQueue)
+
 	for {
 		select {
-		case task, ok := <-wp.taskQueue:
+		case url, ok := <-urls:
 			if !ok {
 				if wp.verbose {
-					fmt.Printf("Worker: task queue closed, exiting\n")
+					fmt.Printf("Task generator: stream closed after %d tasks\n", wp.snapshot().Total)
 				}
 				return
 			}
 
+			task := NewTask(extractIDFromURL(url), url)
+			if skipped, ok := wp.skipTask(task); !ok {
+				return
+			} else if skipped {
+				continue
+			}
+			select {
+			case wp.taskQueue <- task:
+				wp.addTotal()
+			case <-wp.ctx.Done():
+				return
+			case <-wp.stopIntake:
+				return
+			}
+		case <-wp.ctx.Done():
 			if wp.verbose {
-				fmt.Printf("Worker: processing task %s\n", task.ID)
+				fmt.Printf("Task generator: context cancelled, sent %d tasks\n", wp.snapshot().Total)
 			}
+			return
+		case <-wp.stopIntake:
+			if wp.verbose {
+				fmt.Printf("Task generator: stopped, sent %d tasks\n", wp.snapshot().Total)
+			}
+			return
+		}
+	}
+}
 
-			// Apply shared rate limiting
-			if err := wp.rateLimiter.Wait(wp.ctx); err != nil {
+func (wp *WorkerPool) worker(processFunc ProcessFunc) {
+	defer wp.wg.Done()
+
+	if wp.verbose {
+		fmt.Printf("Worker started\n")
+	}
+
+	for {
+		wp.waitWhilePaused()
+
+		select {
+		case task, ok := <-wp.taskQueue:
+			if !ok {
 				if wp.verbose {
-					fmt.Printf("Worker: context cancelled, exiting\n")
+					fmt.Printf("Worker: task queue closed, exiting\n")
 				}
 				return
 			}
 
-			start := time.Now()
-			task.Status = TaskProcessing
-
-			// Handle panics in processFunc
-			data, err := func() (data any, err error) {
-				defer func() {
-					if r := recover(); r != nil {
-						err = fmt.Errorf("panic in processFunc: %v", r)
-					}
-				}()
-				return processFunc(task.URL)
-			}()
-
-			duration := time.Since(start)
-
-			result := Result{
-				Task:  task,
-				Data:  data,
-				Error: err,
-				Time:  duration,
+			// Tasks already queued when intake stopped are dropped unprocessed
+			if wp.stopWhen != nil && wp.stopWhen() {
+				wp.StopIntake()
+			}
+			if wp.Stopping() {
+				continue
 			}
 
-			wp.updateStats(result)
-
-			select {
-			case wp.resultChan <- result:
-				if wp.verbose && result.Error != nil {
-					fmt.Printf("Worker: task %s failed: %v\n", task.ID, result.Error)
-				}
-			case <-wp.ctx.Done():
+			if wp.verbose {
+				fmt.Printf("Worker: processing task %s\n", task.ID)
+			}
+			if !wp.runTask(task, processFunc) {
 				if wp.verbose {
-					fmt.Printf("Worker: context cancelled while sending result, exiting\n")
+					fmt.Printf("Worker: context cancelled, exiting\n")
 				}
 				return
 			}
@@ -168,16 +262,82 @@ func (wp *WorkerPool) worker(processFunc ProcessFunc) {
 	}
 }
 
-func (wp *WorkerPool) processTask(task Task, processFunc ProcessFunc) {
-	// Apply shared rate limiting (non-blocking)
-	ctx, cancel := context.WithTimeout(wp.ctx, 100*time.Millisecond)
-	defer cancel()
-	wp.rateLimiter.Wait(ctx)
+// runTask waits for the rate limiter, runs processFunc on task and delivers
+// the result. It returns false if the pool's context is cancelled first; the
+// task then may not have run, or its result may not have been delivered.
+func (wp *WorkerPool) runTask(task Task, processFunc ProcessFunc) bool {
+	if !wp.waitThrottle() {
+		return false
+	}
+	if wp.Stopping() {
+		// Intake stopped during the throttle: drop the task like those
+		// still queued
+		return true
+	}
+	if wp.rateExempt == nil || !wp.rateExempt(task.URL) {
+		if err := wp.rateLimiter.Wait(wp.ctx); err != nil {
+			return false
+		}
+	}
+
+	result := execute(task, processFunc)
+	wp.updateStats(result)
+	if wp.verbose && result.Error != nil {
+		fmt.Printf("Worker: task %s failed: %v\n", task.ID, result.Error)
+	}
+	return wp.deliver(result)
+}
+
+// skipTask delivers a skipped result for task if the skip function skips
+// it, counting it in the total for streamed tasks. ok is false if the
+// pool's context was cancelled before the result was received.
+func (wp *WorkerPool) skipTask(task Task) (skipped, ok bool) {
+	if wp.skip == nil {
+		return false, true
+	}
+	reason, skip := wp.skip(task.URL)
+	if !skip {
+		return false, true
+	}
+
+	if wp.verbose {
+		fmt.Printf("Task generator: skipping %s: %s\n", task.URL, reason)
+	}
+	task.Status = TaskSkipped
+	wp.statsMu.Lock()
+	wp.stats.Skipped++
+	if wp.streaming {
+		wp.stats.Total++
+	}
+	wp.statsMu.Unlock()
+	return true, wp.deliver(Result{Task: task, Skipped: reason})
+}
+
+// deliver hands result to the OnResult callback, then sends it on the result
+// channel unless results are discarded. It blocks until the result is
+// received, so none is ever dropped, and returns false if the pool's context
+// is cancelled first.
+func (wp *WorkerPool) deliver(result Result) bool {
+	if wp.onResult != nil {
+		wp.callOnResult(result)
+	}
+	if wp.discardResults {
+		return true
+	}
 
+	select {
+	case wp.resultChan <- result:
+		return true
+	case <-wp.ctx.Done():
+		return false
+	}
+}
+
+// execute runs processFunc on task, turning a panic into the result's error.
+func execute(task Task, processFunc ProcessFunc) Result {
 	start := time.Now()
 	task.Status = TaskProcessing
 
-	// Handle panics in processFunc
 	data, err := func() (data any, err error) {
 		defer func() {
 			if r := recover(); r != nil {
@@ -187,26 +347,52 @@ func (wp *WorkerPool) processTask(task Task, processFunc ProcessFunc) {
 		return processFunc(task.URL)
 	}()
 
-	duration := time.Since(start)
-
 	result := Result{
 		Task:  task,
 		Data:  data,
 		Error: err,
-		Time:  duration,
+		Time:  time.Since(start),
+	}
+	if attempts, errs, status, ok := Attempts(err); ok {
+		result.Task.Attempts = attempts
+		result.Attempts = attempts
+		result.AttemptErrors = errs
+		result.StatusCode = status
 	}
+	return result
+}
 
-	wp.updateStats(result)
+// callOnResult calls the OnResult callback, turning a panic into a logged
+// error so it can't take the worker down.
+func (wp *WorkerPool) callOnResult(result Result) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf("Worker: panic in OnResult for task %s: %v\n", result.Task.ID, r)
+		}
+	}()
+	wp.onResult(result)
+}
 
-	// Try to send result non-blockingly
-	select {
-	case wp.resultChan <- result:
-	case <-time.After(100 * time.Millisecond):
-		// Drop result if channel is full
-	}
+// addTotal counts a streamed task in the total.
+func (wp *WorkerPool) addTotal() {
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+
+	wp.stats.Total++
+}
+
+// snapshot returns a copy of the stats.
+func (wp *WorkerPool) snapshot() Stats {
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+
+	return *wp.stats
 }
 
 func (wp *WorkerPool) updateStats(result Result) {
+	wp.statsMu.Lock()
+	defer wp.statsMu.Unlock()
+
 	wp.stats.AvgTime = (wp.stats.AvgTime*time.Duration(wp.stats.Completed+wp.stats.Failed) + result.Time) / time.Duration(wp.stats.Completed+wp.stats.Failed+1)
 
 	if result.Error != nil {
@@ -223,7 +409,7 @@ func (wp *WorkerPool) updateStats(result Result) {
 
 		elapsed := time.Since(wp.stats.StartTime)
 		avgTimePerTask := elapsed / time.Duration(completed)
-		remainingTasks := wp.stats.Total - completed
+		remainingTasks := wp.stats.Total - completed - wp.stats.Skipped
 		eta := time.Now().Add(avgTimePerTask * time.Duration(remainingTasks))
 		wp.stats.ETA = eta
 	}
@@ -244,13 +430,18 @@ func (wp *WorkerPool) monitorStats() {
 }
 
 func (wp *WorkerPool) printStats() {
-	completed := wp.stats.Completed + wp.stats.Failed
-	progress := float64(completed) / float64(wp.stats.Total) * 100
+	stats := wp.snapshot()
+	completed := stats.Completed + stats.Failed + stats.Skipped
+	progress := float64(completed) / float64(stats.Total) * 100
 
 	fmt.Println("\n====================================================================")
-	fmt.Printf("\rProgress: %d/%d (%.1f%%) | Success: %.1f%% | Avg: %v | ETA: %v\n",
-		completed, wp.stats.Total, progress, wp.stats.SuccessRate,
-		wp.stats.AvgTime.Round(time.Millisecond), wp.stats.ETA.Format("15:04:05"))
+	fmt.Printf("\rProgress: %d/%d (%.1f%%) | Success: %.1f%% | Avg: %v | ETA: %v",
+		completed, stats.Total, progress, stats.SuccessRate,
+		stats.AvgTime.Round(time.Millisecond), stats.ETA.Format("15:04:05"))
+	if wp.Throttled() {
+		fmt.Printf(" | Throttled until %s", wp.throttle().Format("15:04:05"))
+	}
+	fmt.Println()
 	fmt.Println("====================================================================")
 }
 
@@ -272,16 +463,21 @@ func (wp *WorkerPool) Stop() {
 }
 
 func (wp *WorkerPool) printFinalStats() {
-	totalTime := time.Since(wp.stats.StartTime)
+	stats := wp.snapshot()
+	totalTime := time.Since(stats.StartTime)
 
 	fmt.Println("\n=== Processing Complete ===")
-	fmt.Printf("Total URLs:      %d\n", wp.stats.Total)
-	fmt.Printf("Completed:       %d (%.1f%%)\n", wp.stats.Completed, float64(wp.stats.Completed)/float64(wp.stats.Total)*100)
-	fmt.Printf("Failed:          %d (%.1f%%)\n", wp.stats.Failed, float64(wp.stats.Failed)/float64(wp.stats.Total)*100)
-	fmt.Printf("Success Rate:    %.1f%%\n", wp.stats.SuccessRate)
-	fmt.Printf("Average Time:    %v\n", wp.stats.AvgTime.Round(time.Millisecond))
+	fmt.Printf("Total URLs:      %d\n", stats.Total)
+	fmt.Printf("Completed:       %d (%.1f%%)\n", stats.Completed, float64(stats.Completed)/float64(stats.Total)*100)
+	fmt.Printf("Failed:          %d (%.1f%%)\n", stats.Failed, float64(stats.Failed)/float64(stats.Total)*100)
+	fmt.Printf("Skipped:         %d (%.1f%%)\n", stats.Skipped, float64(stats.Skipped)/float64(stats.Total)*100)
+	if stats.Throttles > 0 {
+		fmt.Printf("Throttled:       %d pauses\n", stats.Throttles)
+	}
+	fmt.Printf("Success Rate:    %.1f%%\n", stats.SuccessRate)
+	fmt.Printf("Average Time:    %v\n", stats.AvgTime.Round(time.Millisecond))
 	fmt.Printf("Total Time:      %v\n", totalTime.Round(time.Second))
-	fmt.Printf("Requests/sec:    %.1f\n", float64(wp.stats.Total)/totalTime.Seconds())
+	fmt.Printf("Requests/sec:    %.1f\n", float64(stats.Total)/totalTime.Seconds())
 }
 
 func extractIDFromURL(url string) string {
@@ -0,0 +1,145 @@
+package worker
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestRunTaskProcessError(t *testing.T) {
+	wp := NewPool(1, 100, false)
+	defer wp.cancel()
+
+	failure := errors.New("parse failed")
+	task := NewTask("a", "https://example.com/article/id/a")
+	if !wp.runTask(task, func(url string) (any, error) { return nil, failure }) {
+		t.Fatal("runTask returned false with a live context")
+	}
+
+	result := <-wp.resultChan
+	if !errors.Is(result.Error, failure) {
+		t.Errorf("result error = %v, want %v", result.Error, failure)
+	}
+	if result.Data != nil {
+		t.Errorf("result data = %v, want nil", result.Data)
+	}
+	if wp.stats.Failed != 1 || wp.stats.Completed != 0 {
+		t.Errorf("stats failed/completed = %d/%d, want 1/0", wp.stats.Failed, wp.stats.Completed)
+	}
+}
+
+func TestExecuteRecoversPanic(t *testing.T) {
+	task := NewTask("a", "https://example.com/article/id/a")
+	result := execute(task, func(url string) (any, error) { panic("boom") })
+
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "boom") {
+		t.Fatalf("result error = %v, want the panic value", result.Error)
+	}
+	if result.Task.ID != task.ID {
+		t.Errorf("result task = %q, want %q", result.Task.ID, task.ID)
+	}
+}
+
+func TestRunTaskCancelledWaitingOnLimiter(t *testing.T) {
+	wp := NewPool(1, 1, false)
+	// Spend the only token so the next task waits about a second
+	wp.rateLimiter.Allow()
+
+	var ran bool
+	done := make(chan bool)
+	go func() {
+		done <- wp.runTask(NewTask("a", "u"), func(url string) (any, error) {
+			ran = true
+			return nil, nil
+		})
+	}()
+
+	wp.cancel()
+	select {
+	case ok := <-done:
+		if ok {
+			t.Error("runTask returned true after the context was cancelled")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("runTask still waiting on the limiter after cancel")
+	}
+	if ran {
+		t.Error("processFunc ran after the context was cancelled")
+	}
+}
+
+func TestDeliverBlocksOnFullResultChannel(t *testing.T) {
+	wp := NewPool(1, 100, false)
+	defer wp.cancel()
+	wp.resultChan = make(chan Result, 1)
+	wp.resultChan <- Result{}
+
+	done := make(chan bool)
+	go func() { done <- wp.deliver(Result{Task: NewTask("b", "u")}) }()
+
+	select {
+	case <-done:
+		t.Fatal("deliver returned while the result channel was full")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	<-wp.resultChan
+	if ok := <-done; !ok {
+		t.Fatal("deliver returned false once there was room")
+	}
+	if result := <-wp.resultChan; result.Task.ID != "b" {
+		t.Errorf("delivered task = %q, want b", result.Task.ID)
+	}
+
+	// A cancelled context releases a blocked deliver
+	wp.resultChan <- Result{}
+	go func() { done <- wp.deliver(Result{}) }()
+	wp.cancel()
+	if ok := <-done; ok {
+		t.Error("deliver returned true after the context was cancelled")
+	}
+}
+
+func TestDiscardResults(t *testing.T) {
+	wp := NewPool(2, 100, false)
+	defer wp.cancel()
+
+	var mu sync.Mutex
+	var got []string
+	wp.SetOnResult(func(result Result) {
+		mu.Lock()
+		defer mu.Unlock()
+		got = append(got, result.Task.ID)
+	})
+	wp.DiscardResults()
+	// Nothing drains the channel: with results discarded, workers must not
+	// block on it
+	wp.resultChan = make(chan Result)
+
+	urls := []string{
+		"https://example.com/article/id/a",
+		"https://example.com/article/id/b",
+		"https://example.com/article/id/c",
+	}
+	results := wp.Process(urls, func(url string) (any, error) { return url, nil })
+
+	stopped := make(chan struct{})
+	go func() {
+		wp.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(2 * time.Second):
+		t.Fatal("Stop blocked with results discarded")
+	}
+
+	if _, ok := <-results; ok {
+		t.Error("a result was sent on the channel despite DiscardResults")
+	}
+	if len(got) != len(urls) {
+		t.Errorf("OnResult saw %d results, want %d", len(got), len(urls))
+	}
+}
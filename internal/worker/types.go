@@ -1,6 +1,7 @@
 package worker
 
 import (
+	"errors"
 	"time"
 )
 
@@ -27,6 +28,25 @@ type Result struct {
 	Data  interface{}
 	Error error
 	Time  time.Duration
+	// Skipped is why the task was skipped without running (see SetSkip),
+	// empty for tasks that ran
+	Skipped string
+
+	// Attempts, AttemptErrors and StatusCode describe the requests behind a
+	// failed task whose error reports them (see AttemptReporter): how many
+	// were made, why each one failed, and the last response status (0 when
+	// there was none)
+	Attempts      int
+	AttemptErrors []string
+	StatusCode    int
+}
+
+// AttemptReporter is implemented by errors that know the requests made
+// before giving up, such as *fetcher.FetchError. It is found anywhere in a
+// task's error chain.
+type AttemptReporter interface {
+	error
+	AttemptHistory() (attempts int, errs []string, status int)
 }
 
 type Stats struct {
@@ -38,6 +58,20 @@ type Stats struct {
 	AvgTime     time.Duration
 	StartTime   time.Time
 	ETA         time.Time
+	// Throttles counts the pauses the site asked for that held workers
+	// back, the latest ending at ThrottledUntil
+	Throttles      int
+	ThrottledUntil time.Time
+}
+
+// Attempts extracts the request history reported by err, if any.
+func Attempts(err error) (attempts int, errs []string, status int, ok bool) {
+	var reporter AttemptReporter
+	if !errors.As(err, &reporter) {
+		return 0, nil, 0, false
+	}
+	attempts, errs, status = reporter.AttemptHistory()
+	return attempts, errs, status, true
 }
 
 func NewTask(id, url string) Task {
@@ -2,50 +2,499 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
 	"os"
+	"os/signal"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"gtft-crawler/internal/assets"
+	"gtft-crawler/internal/command"
 	"gtft-crawler/internal/config"
+	"gtft-crawler/internal/crossref"
+	"gtft-crawler/internal/estimate"
 	"gtft-crawler/internal/fetcher"
+	"gtft-crawler/internal/manifest"
+	"gtft-crawler/internal/metrics"
+	"gtft-crawler/internal/notify"
 	"gtft-crawler/internal/parser"
+	"gtft-crawler/internal/plugin"
+	"gtft-crawler/internal/profile"
+	"gtft-crawler/internal/redact"
+	"gtft-crawler/internal/schedule"
+	"gtft-crawler/internal/server"
+	"gtft-crawler/internal/sink"
+	"gtft-crawler/internal/source"
+	"gtft-crawler/internal/state"
 	"gtft-crawler/internal/storage"
+	"gtft-crawler/internal/translate"
 	"gtft-crawler/internal/worker"
 )
 
 func main() {
+	// Dispatch subcommands before parsing crawl flags
+	if len(os.Args) > 1 {
+		if cmd, ok := command.Lookup(os.Args[1]); ok {
+			if err := cmd.Run(os.Args[2:]); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	// Parse command line flags
 	cfg := config.New()
+	cfg.CommandUsage = command.PrintUsage
 	cfg.ParseFlags()
 
+	// With -output -, stdout carries records only and all logging goes to stderr
+	var stream io.Writer
+	if storage.IsStream(cfg.OutputDir) {
+		stream = os.Stdout
+		os.Stdout = os.Stderr
+	}
+
 	fmt.Println("=== GTFT Academic Paper Crawler ===")
-	fmt.Printf("Input file: %s\n", cfg.InputFile)
-	fmt.Printf("Output directory: %s\n", cfg.OutputDir)
+	if len(cfg.Profiles) == 1 {
+		fmt.Printf("Site profile: %s (%s)\n", cfg.Profile.Name, cfg.Profile.BaseURL)
+	} else {
+		var sites []string
+		for _, site := range cfg.Profiles {
+			sites = append(sites, fmt.Sprintf("%s (%s)", site.Name, site.BaseURL))
+		}
+		fmt.Printf("Site profiles: %s; records are kept per journal\n", strings.Join(sites, ", "))
+	}
+	if len(cfg.Plugins) > 0 {
+		fmt.Printf("Plugins: %s\n", strings.Join(cfg.Plugins, ", "))
+	}
+	if len(cfg.SkipExtractors) > 0 {
+		fmt.Printf("Skipped extractors: %s\n", strings.Join(cfg.SkipExtractors, ", "))
+	}
+	if cfg.Strict {
+		fmt.Printf("Strict mode: pages missing %s fail\n", strings.Join(cfg.StrictFields, ", "))
+	}
+	switch {
+	case cfg.InputAMQP != "":
+		fmt.Printf("Input queue: %s\n", cfg.AMQPQueue)
+	case cfg.InputSQLite != "":
+		fmt.Printf("Input database: %s\n", cfg.InputSQLite)
+	default:
+		fmt.Printf("Input file: %s\n", cfg.InputFile)
+	}
+	if stream != nil {
+		fmt.Println("Output: stdout (NDJSON)")
+	} else {
+		fmt.Printf("Output directory: %s\n", storage.RedactLocation(cfg.OutputDir))
+	}
 	fmt.Printf("Workers: %d\n", cfg.Workers)
 	fmt.Printf("Rate limit: %d requests/second\n", cfg.RateLimit)
 	fmt.Printf("Timeout: %v\n", cfg.Timeout)
 	fmt.Printf("Max retries: %d\n", cfg.MaxRetries)
+	if cfg.Cache != "" || cfg.CacheDir != "" {
+		fmt.Printf("Response cache: %s (TTL %v)\n", cfg.Cache+cfg.CacheDir, cfg.CacheTTL)
+	}
+	if cfg.Conditional != "" {
+		fmt.Printf("Conditional requests: validators in %s\n", cfg.Conditional)
+	}
+	if cfg.Render {
+		fmt.Printf("Rendering: pages without text in %s are loaded in a headless browser\n", strings.Join(cfg.RenderSelectors, " or "))
+	}
+	if cfg.Windows != nil {
+		fmt.Printf("Crawl windows: %s\n", cfg.Windows)
+	}
+	if cfg.RetryBudget > 0 {
+		fmt.Printf("Retry budget: %g%% of fetches\n", cfg.RetryBudget)
+	}
+	if cfg.MaxRequests > 0 || cfg.MaxBytes > 0 {
+		fmt.Printf("Quota: %s\n", describeQuota(cfg))
+	}
+	if cfg.Hedge > 0 {
+		fmt.Printf("Hedging: second request after p%g of recent response times\n", cfg.Hedge)
+	}
 	fmt.Println()
 
-	// Read URLs from file
-	urls, err := readURLs(cfg.InputFile)
+	// Take the output directory lock so overlapping runs can't corrupt stats.
+	// Remote outputs can't be locked; coordinate those runs externally.
+	var lock *storage.Lock
+	if !storage.IsRemote(cfg.OutputDir) && stream == nil {
+		var err error
+		lock, err = storage.AcquireLock(cfg.OutputDir, cfg.LockWait)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			fmt.Println("Another crawl is writing to this output directory; wait for it to finish or use -lock-wait")
+			os.Exit(1)
+		}
+		defer lock.Release()
+	}
+
+	var srv *server.Server
+	if cfg.Listen != "" {
+		srv = server.New(cfg.Listen, cfg.ControlToken, metricsNamespace, cfg.Verbose)
+		if err := srv.Start(); err != nil {
+			fmt.Printf("Error: %v\n", err)
+			lock.Release()
+			os.Exit(1)
+		}
+		defer srv.Close()
+		fmt.Printf("Serving health, control and metrics endpoints on %s\n", cfg.Listen)
+	}
+
+	notifiers := notify.FromConfig(cfg.AlertWebhook, cfg.AlertSlack)
+
+	for {
+		added, err := runCrawl(cfg, stream, srv)
+		if err != nil {
+			fmt.Printf("Error: %v\n", err)
+			if cfg.Watch <= 0 || errors.Is(err, errCrawlCancelled) {
+				lock.Release()
+				os.Exit(1)
+			}
+		}
+
+		if len(added) > 0 {
+			for _, notifier := range notifiers {
+				if err := notifier.Notify(added); err != nil {
+					fmt.Printf("[Alert] Failed to send alert: %v\n", err)
+				}
+			}
+		}
+
+		if cfg.Watch <= 0 || stopRequested(srv) {
+			break
+		}
+
+		fmt.Println()
+		fmt.Printf("[Watch] %d new articles this run; next run at %s\n", len(added), time.Now().Add(cfg.Watch).Format("15:04:05"))
+		if !sleepUnlessStopped(srv, cfg.Watch) {
+			break
+		}
+	}
+}
+
+// runCrawl performs one pass over the input and returns the records that
+// were newly added to the output directory. When stream is set, records are
+// written to it as NDJSON instead.
+func runCrawl(cfg *config.Config, stream io.Writer, srv *server.Server) ([]*parser.PaperMetadata, error) {
+	allowlist := fetcher.NewAllowlist(cfg.AllowHosts)
+
+	redactor, err := redact.Parse(cfg.Redact, cfg.RedactSalt)
 	if err != nil {
-		fmt.Printf("Error reading URLs: %v\n", err)
-		os.Exit(1)
+		return nil, fmt.Errorf("invalid -redact: %w", err)
 	}
 
-	fmt.Printf("Loaded %d URLs from %s\n", len(urls), cfg.InputFile)
+	var urls []string
+	src, err := openSource(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if src != nil {
+		defer src.Close()
+	} else {
+		// Read URLs from file
+		urls, err = readURLs(cfg.InputFile)
+		if err != nil {
+			return nil, fmt.Errorf("reading URLs: %w", err)
+		}
+
+		fmt.Printf("Loaded %d URLs from %s\n", len(urls), cfg.InputFile)
+		urls = filterAllowed(urls, allowlist)
+
+		if cfg.SpiderDepth == 0 {
+			if err := confirmCrawl(cfg, urls, stream); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Spider mode treats the input as seed pages and discovers the rest
+	var spider *source.Spider
+	if cfg.SpiderDepth > 0 {
+		isArticleURL := func(u string) bool { return profile.For(cfg.Profiles, u).IsArticleURL(u) }
+		spider = source.NewSpider(urls, cfg.SpiderDepth, allowlist.Allows, isArticleURL, parser.IDFromURL, cfg.Verbose)
+		src = spider
+		defer src.Close()
+		fmt.Printf("Spidering from %d seed pages, up to %d links deep\n", len(urls), cfg.SpiderDepth)
+		urls = nil
+	}
 	fmt.Println()
 
+	var cache fetcher.ResponseCache
+	if cfg.Cache != "" || cfg.CacheDir != "" {
+		cache, err = openCache(cfg)
+		if err != nil {
+			return nil, err
+		}
+		defer cache.Close()
+	}
+	var validators *fetcher.Validators
+	if cfg.Conditional != "" {
+		validators, err = fetcher.OpenValidators(cfg.Conditional)
+		if err != nil {
+			return nil, err
+		}
+		defer validators.Close()
+	}
+	var renderer *fetcher.Renderer
+	if cfg.Render {
+		renderer, err = fetcher.NewRenderer(cfg.Browser, cfg.Timeout)
+		if err != nil {
+			return nil, err
+		}
+		defer renderer.Close()
+	}
+
 	// Initialize components
-	fetcher := fetcher.NewFetcher(cfg.Timeout, cfg.MaxRetries, cfg.RateLimit, cfg.Verbose)
-	parser := parser.NewParser(cfg.Verbose)
-	storage := storage.NewStorage(cfg.OutputDir, cfg.Verbose)
+	transport := cfg.Transport
+	if transport.MaxIdleConnsPerHost == 0 {
+		transport.MaxIdleConnsPerHost = cfg.Workers
+	}
+	fetcher := fetcher.NewFetcher(cfg.Timeout, cfg.MaxRetries, cfg.RateLimit, cfg.Verbose, transport)
+	if err := fetcher.SetBackoff(cfg.BackoffBase, cfg.BackoffCap, cfg.BackoffJitter); err != nil {
+		return nil, err
+	}
+	if err := fetcher.SetRedirectPolicy(cfg.MaxRedirects, cfg.SameHostRedirects); err != nil {
+		return nil, err
+	}
+	fetcher.SetHedge(cfg.Hedge)
+	fetcher.SetRetryBudget(cfg.RetryBudget)
+	if cfg.AuthFile != "" || cfg.Auth != "" {
+		creds, err := loadCredentials(cfg)
+		if err != nil {
+			return nil, err
+		}
+		fetcher.SetCredentials(creds)
+	}
+	if cfg.Cookies != "" {
+		n, err := fetcher.LoadCookies(cfg.Cookies)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Loaded %d cookies from %s\n", n, cfg.Cookies)
+	}
+	if len(cfg.UserAgents) > 0 || cfg.UserAgentFile != "" {
+		agents, err := loadUserAgents(cfg)
+		if err != nil {
+			return nil, err
+		}
+		if err := fetcher.SetUserAgents(agents, cfg.UserAgentRotation); err != nil {
+			return nil, err
+		}
+		if len(agents) > 1 {
+			fmt.Printf("Rotating %d User-Agents per %s\n", len(agents), cfg.UserAgentRotation)
+		}
+	}
+	if len(cfg.Headers) > 0 || cfg.HeaderFile != "" {
+		headers, err := loadHeaders(cfg)
+		if err != nil {
+			return nil, err
+		}
+		fetcher.SetHeaders(headers)
+	}
+	if cfg.Proxies != "" || cfg.ProxyFile != "" {
+		proxies, err := loadProxies(cfg)
+		if err != nil {
+			return nil, err
+		}
+		fetcher.SetProxies(proxies, cfg.ProxyFailures)
+		fmt.Printf("Rotating requests through %d proxies\n", len(proxies))
+	}
+	if cache != nil {
+		fetcher.SetCache(cache)
+	}
+	if validators != nil {
+		fetcher.SetValidators(validators)
+	}
+	if renderer != nil {
+		fetcher.SetRenderer(renderer, cfg.RenderSelectors)
+	}
+	if cfg.Session {
+		if err := fetcher.EnableCookies(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.WarmUp {
+		// A failed warm-up only costs the session layout, not the run
+		for _, site := range cfg.Profiles {
+			n, err := fetcher.WarmUp(site.BaseURL)
+			if err != nil {
+				fmt.Printf("[Session] Warm-up with %s failed: %v\n", site.BaseURL, err)
+				continue
+			}
+			fmt.Printf("Session: %d cookies from %s\n", n, site.BaseURL)
+		}
+	}
+	var plugins []*plugin.Plugin
+	for _, path := range cfg.Plugins {
+		p, err := plugin.Load(path, cfg.Verbose)
+		if err != nil {
+			return nil, err
+		}
+		defer p.Close()
+		plugins = append(plugins, p)
+	}
+	var rules parser.Rules
+	if cfg.Rules != "" {
+		rules, err = parser.LoadRules(cfg.Rules)
+		if err != nil {
+			return nil, err
+		}
+		fmt.Printf("Loaded selector rules for %d fields from %s\n", len(rules), cfg.Rules)
+	}
 	workerPool := worker.NewPool(cfg.Workers, cfg.RateLimit, cfg.Verbose)
+	if cache != nil {
+		workerPool.SetRateExempt(cache.Fresh)
+	}
+	fetcher.SetHedgeRateLimit(workerPool.TryRate)
+	// Each site profile has its own parser, for its journal names and
+	// selector overrides
+	parsers := make(map[string]*parser.Parser, len(cfg.Profiles))
+	for _, site := range cfg.Profiles {
+		p := parser.NewParser(cfg.Verbose)
+		if err := p.SetSite(site.ParserSite()); err != nil {
+			return nil, fmt.Errorf("profile %s: %w", site.Name, err)
+		}
+		if err := p.SetRules(rules); err != nil {
+			return nil, err
+		}
+		if cfg.English {
+			p.SetEnglishPages(englishPage(fetcher, workerPool.WaitRate))
+		}
+		if err := p.SkipExtractors(cfg.SkipExtractors); err != nil {
+			return nil, fmt.Errorf("invalid -skip-extractors: %w", err)
+		}
+		if err := p.SetStrict(cfg.StrictFields); err != nil {
+			return nil, fmt.Errorf("invalid -strict-fields: %w", err)
+		}
+		for _, plugin := range plugins {
+			p.AddPlugin(plugin)
+		}
+		parsers[site.Name] = p
+	}
+	output := storage.RedactLocation(cfg.OutputDir)
+	backend, err := openBackend(cfg, stream)
+	if err != nil {
+		return nil, err
+	}
+	storage := storage.NewStorage(cfg.OutputDir, cfg.Verbose)
+	storage.SetBackend(backend)
+	if stream != nil {
+		storage.SetStream(stream)
+	}
+	downloader := assets.NewDownloader(fetcher, storage, cfg.Verbose)
+	downloader.SetFigureLimits(cfg.FigureWorkers, cfg.MaxFigureSize)
+	downloader.SetRateLimit(workerPool.WaitRate)
+
+	var translator *translate.Translator
+	if cfg.TranslateURL != "" {
+		translator = translate.New(cfg.TranslateURL, cfg.TranslateAPIKey)
+	}
+	var references *crossref.Client
+	if cfg.CrossrefReferences {
+		references = crossref.New(crossref.DefaultAPI, cfg.CrossrefMailto)
+	}
 
 	// Set total for statistics
-	storage.SetTotal(len(urls))
+	total := len(urls)
+	if sized, ok := src.(interface{ Len() int }); ok {
+		total = sized.Len()
+	}
+	storage.SetTotal(total)
+	storage.SetRefresh(cfg.Refresh)
+	storage.SetMetricsHistory(cfg.MetricsHistory)
+	storage.SetSharding(cfg.Shard)
+	if len(cfg.Profiles) > 1 {
+		storage.SetNamespaces(func(url string) string { return profile.For(cfg.Profiles, url).Name })
+	}
+	defer func() {
+		if err := storage.Close(); err != nil {
+			fmt.Printf("Error closing output: %v\n", err)
+		}
+	}()
+	if err := addSinks(cfg, storage); err != nil {
+		return nil, err
+	}
+
+	// Streams have no output directory to keep state in
+	var crawlState *state.State
+	if stream == nil {
+		crawlState, err = loadState(storage)
+		if err != nil {
+			return nil, err
+		}
+		workerPool.SetSkip(skipKnown(cfg, storage, crawlState))
+	}
+	// processed tracks file input so a run cut short by a quota can leave
+	// the rest for the next one
+	var processed sync.Map
+	storage.SetResultHook(func(url, id string, err error) {
+		if src == nil {
+			processed.Store(url, true)
+		}
+		// Pages visited only for their links have nothing to record
+		if crawlState != nil && (id != "" || err != nil) {
+			crawlState.Record(url, id, err)
+		}
+		if src != nil {
+			if err := src.Done(url, err); err != nil {
+				fmt.Printf("[Source] Failed to report %s: %v\n", url, err)
+			}
+		}
+	})
+
+	registry := crawlMetrics(fetcher, storage)
+	var statsd *metrics.StatsD
+	if cfg.StatsD != "" {
+		statsd, err = metrics.NewStatsD(registry, cfg.StatsD, cfg.StatsDPrefix, splitList(cfg.StatsDTags), cfg.Verbose)
+		if err != nil {
+			return nil, err
+		}
+		statsd.Start(cfg.StatsDInterval)
+	}
+	var pushgateway *metrics.Pushgateway
+	if cfg.Pushgateway != "" {
+		labels, err := parseLabels(cfg.PushgatewayLabels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid -pushgateway-labels: %w", err)
+		}
+		pushgateway, err = metrics.NewPushgateway(registry, cfg.Pushgateway, metricsNamespace, cfg.PushgatewayJob, labels)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	crawl := &crawlControl{WorkerPool: workerPool, src: src}
+	workerPool.SetThrottle(fetcher.ThrottledUntil)
+	var quotaReached atomic.Bool
+	if cfg.MaxRequests > 0 || cfg.MaxBytes > 0 {
+		workerPool.SetStopWhen(func() bool {
+			requests, bytes := fetcher.Requests()
+			if (cfg.MaxRequests > 0 && requests >= cfg.MaxRequests) || (cfg.MaxBytes > 0 && bytes >= cfg.MaxBytes) {
+				if !quotaReached.Swap(true) {
+					fmt.Printf("\nQuota reached (%d requests, %d bytes): finishing in-flight URLs...\n", requests, bytes)
+					// Streamed input keeps the rest in its queue or database
+					if src != nil {
+						src.Stop()
+					}
+				}
+				return true
+			}
+			return false
+		})
+	}
+	if srv != nil {
+		srv.Attach(crawl, registry)
+		defer srv.Detach()
+	}
 
 	// Start processing
 	fmt.Println("Starting concurrent processing...")
@@ -54,26 +503,150 @@ func main() {
 
 	startTime := time.Now()
 
-	// Process URLs through worker pool
-	results := workerPool.Process(urls, func(url string) (any, error) {
-		// Fetch HTML
-		fetchResult, err := fetcher.Fetch(url)
+	process := func(url string) (any, error) {
+		site := profile.For(cfg.Profiles, url)
+		parser := parsers[site.Name]
+
+		// File input is filtered up front; streamed URLs are checked here
+		if !allowlist.Allows(url) {
+			return nil, fmt.Errorf("host not in allowlist (%s)", allowlist)
+		}
+
+		// Fetch HTML; with -stream it's parsed as it downloads
+		fetchResult, metadata, err := fetchPage(fetcher, parser, url, cfg.Stream)
+		if err == nil && fetchResult.NotModified {
+			if spider == nil || site.IsArticleURL(url) {
+				if unchanged, ok := unchangedRecord(storage, crawlState, url); ok {
+					return unchanged, nil
+				}
+			}
+			// No record to keep, or spider mode needs the page's links
+			if err := fetcher.ForgetValidators(url); err != nil {
+				return nil, err
+			}
+			fetchResult, metadata, err = fetchPage(fetcher, parser, url, cfg.Stream)
+		}
 		if err != nil {
-			return nil, fmt.Errorf("fetch failed: %w", err)
+			return nil, err
 		}
 
 		if fetchResult.Error != nil {
-			return nil, fmt.Errorf("HTTP error: %w", fetchResult.Error)
+			err := fmt.Errorf("HTTP error: %w", fetchResult.Error)
+			if removed, ok := removedRecord(storage, crawlState, url, fetchResult.StatusCode, err); ok {
+				return removed, nil
+			}
+			return nil, err
 		}
 
-		// Parse HTML
-		metadata, err := parser.Parse(fetchResult.Body, url)
-		if err != nil {
-			return nil, fmt.Errorf("parse failed: %w", err)
+		if spider != nil {
+			links, err := parser.ExtractLinks(fetchResult.Body, fetchResult.FinalURL)
+			if err != nil {
+				return nil, fmt.Errorf("link extraction failed: %w", err)
+			}
+			spider.Discover(url, links)
+
+			// Issue and listing pages are only visited for their links
+			if !site.IsArticleURL(url) {
+				return nil, nil
+			}
+		}
+
+		if fetchResult.RedirectedAway() {
+			return nil, fmt.Errorf("redirected to non-article page %s", fetchResult.FinalURL)
+		}
+
+		// Parse HTML, as found at the address it was redirected to, so the
+		// record's URL and ID are the canonical ones
+		if metadata == nil {
+			metadata, err = parser.Parse(fetchResult.Body, fetchResult.FinalURL)
+			if err != nil {
+				return nil, fmt.Errorf("parse failed: %w", err)
+			}
+		}
+
+		if len(cfg.Profiles) > 1 {
+			metadata.Site = site.Name
+		}
+
+		if len(fetchResult.Redirects) > 0 {
+			metadata.FinalURL = fetchResult.FinalURL
+			for _, hop := range fetchResult.Redirects {
+				metadata.RedirectChain = append(metadata.RedirectChain, hop.URL)
+			}
+			metadata.RedirectChain = append(metadata.RedirectChain, fetchResult.FinalURL)
 		}
 
+		// Translation, reference and asset failures are logged but never
+		// fail the record
+		if translator != nil {
+			if err := translator.Apply(metadata); err != nil {
+				fmt.Printf("[Translate] %s: %v\n", url, err)
+			}
+		}
+		if references != nil {
+			if err := references.Apply(metadata); err != nil {
+				fmt.Printf("[Crossref] %s: %v\n", url, err)
+			}
+		}
+		if cfg.SaveHTML {
+			if _, err := storage.SaveHTML(url, metadata.ID, fetchResult.Body, cfg.SaveHTMLGzip); err != nil {
+				fmt.Printf("[HTML] %s: %v\n", url, err)
+			}
+		}
+		if cfg.DownloadImages {
+			if err := downloader.GraphicalAbstract(metadata); err != nil && cfg.Verbose {
+				fmt.Printf("[Assets] Graphical abstract for %s: %v\n", url, err)
+			}
+		}
+		if cfg.DownloadPDF {
+			if err := downloader.PDF(metadata); err != nil && cfg.Verbose {
+				fmt.Printf("[Assets] PDF for %s: %v\n", url, err)
+			}
+		}
+		if cfg.PDFSize {
+			if err := downloader.PDFSize(metadata); err != nil && cfg.Verbose {
+				fmt.Printf("[Assets] PDF size for %s: %v\n", url, err)
+			}
+		}
+		if cfg.DownloadFigures {
+			if err := downloader.Figures(metadata); err != nil && cfg.Verbose {
+				fmt.Printf("[Assets] Figures for %s: %v\n", url, err)
+			}
+		}
+
+		// Redact last so sinks and the saved file only see scrubbed values
+		redactor.Apply(metadata)
+
 		return metadata, nil
-	})
+	}
+
+	// Stop taking new URLs on Ctrl+C and let in-flight ones finish
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	defer func() {
+		signal.Stop(interrupt)
+		close(interrupt)
+	}()
+	go func() {
+		if _, ok := <-interrupt; ok {
+			fmt.Println("\nStopping: finishing in-flight URLs...")
+			crawl.StopIntake()
+		}
+	}()
+
+	if cfg.Windows != nil {
+		windowsDone := make(chan struct{})
+		defer close(windowsDone)
+		go schedule.Enforce(cfg.Windows, workerPool, windowsDone)
+	}
+
+	// Process URLs through worker pool
+	var results <-chan worker.Result
+	if src != nil {
+		results = workerPool.ProcessStream(src.URLs(), process)
+	} else {
+		results = workerPool.Process(urls, process)
+	}
 
 	// Process results and save them
 	saveErr := make(chan error, 1)
@@ -97,8 +670,44 @@ func main() {
 	}
 
 	// Save final statistics
-	if err := storage.SaveStats(); err != nil {
-		fmt.Printf("Error saving stats: %v\n", err)
+	var remaining []string
+	if quotaReached.Load() && src == nil {
+		for _, url := range urls {
+			if _, ok := processed.Load(url); !ok {
+				remaining = append(remaining, url)
+			}
+		}
+	}
+	if stream == nil {
+		if err := storage.SaveStats(); err != nil {
+			fmt.Printf("Error saving stats: %v\n", err)
+		}
+		if err := saveState(storage, crawlState); err != nil {
+			fmt.Printf("Error saving crawl state: %v\n", err)
+		}
+		if len(remaining) > 0 {
+			data := []byte(strings.Join(remaining, "\n") + "\n")
+			if err := storage.WriteFile(remainingURLsFile, data); err != nil {
+				fmt.Printf("Error saving remaining URLs: %v\n", err)
+			}
+		}
+	}
+
+	if statsd != nil {
+		if err := statsd.Close(); err != nil {
+			fmt.Printf("[StatsD] %v\n", err)
+		}
+	}
+	if pushgateway != nil {
+		if err := pushgateway.Push(); err != nil {
+			fmt.Printf("[Pushgateway] %v\n", err)
+		}
+	}
+
+	if cfg.Manifest {
+		if err := writeManifest(cfg); err != nil {
+			fmt.Printf("Error writing manifest: %v\n", err)
+		}
 	}
 
 	// Print final statistics
@@ -109,8 +718,636 @@ func main() {
 
 	storage.PrintStats()
 
+	if cache != nil {
+		fmt.Printf("Cache hits: %d\n", fetcher.CacheHits())
+	}
+	if validators != nil {
+		fmt.Printf("Not modified: %d\n", fetcher.NotModified())
+	}
+	if renderer != nil {
+		fmt.Printf("Rendered: %d\n", fetcher.Rendered())
+	}
+	if n := fetcher.Transcoded(); n > 0 {
+		fmt.Printf("Transcoded to UTF-8: %d\n", n)
+	}
+	if n := fetcher.Deduplicated(); n > 0 {
+		fmt.Printf("Duplicate fetches shared: %d\n", n)
+	}
+	if retries, denied := fetcher.Retries(); retries > 0 || denied > 0 {
+		fmt.Printf("Retries: %d", retries)
+		if denied > 0 {
+			fmt.Printf(" (retry budget exhausted; %d failures not retried)", denied)
+		}
+		fmt.Println()
+	}
+	if quotaReached.Load() {
+		requests, bytes := fetcher.Requests()
+		fmt.Printf("Quota reached: %d requests, %d bytes (%s)\n", requests, bytes, describeQuota(cfg))
+		if len(remaining) > 0 && stream == nil {
+			fmt.Printf("%d URLs left for the next run in %s\n", len(remaining), remainingURLsFile)
+		}
+	}
+	if proxies := fetcher.ProxyStatus(); proxies != nil {
+		var dead []string
+		for _, p := range proxies {
+			if p.Dead {
+				dead = append(dead, p.URL)
+			}
+		}
+		fmt.Printf("Proxies: %d of %d live", len(proxies)-len(dead), len(proxies))
+		if len(dead) > 0 {
+			fmt.Printf(" (removed %s)", strings.Join(dead, ", "))
+		}
+		fmt.Println()
+	}
+	if cfg.Hedge > 0 {
+		sent, won := fetcher.HedgeStats()
+		fmt.Printf("Hedged requests: %d sent, %d answered first\n", sent, won)
+	}
+
+	if stream == nil {
+		fmt.Println()
+		fmt.Println("JSON files saved to:", output)
+	}
+
+	return storage.Added(), nil
+}
+
+// openBackend opens the configured output, wrapped for encryption if
+// enabled. Streaming runs write no files, so they get a discarding backend.
+func openBackend(cfg *config.Config, stream io.Writer) (storage.Backend, error) {
+	if stream != nil {
+		return storage.DiscardBackend{}, nil
+	}
+
+	backend, err := storage.OpenBackend(cfg.OutputDir, storage.BackendOptions{
+		Password:       cfg.OutputPassword,
+		SSHKeyFile:     cfg.SSHKey,
+		KnownHostsFile: cfg.SSHKnownHosts,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening output: %w", err)
+	}
+
+	if cfg.Encrypt {
+		key, err := storage.LoadKey(cfg.EncryptKeyFile)
+		if err != nil {
+			backend.Close()
+			return nil, err
+		}
+		encrypted, err := storage.NewEncryptedBackend(backend, key)
+		if err != nil {
+			backend.Close()
+			return nil, err
+		}
+		return encrypted, nil
+	}
+
+	return backend, nil
+}
+
+// writeManifest checksums the output directory and signs the manifest if
+// configured.
+func writeManifest(cfg *config.Config) error {
+	path, count, err := manifest.Write(cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("[Manifest] Wrote %s (%d files)\n", path, count)
+
+	if cfg.Sign != "" {
+		sigPath, err := manifest.Sign(path, cfg.Sign, cfg.SignKey)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("[Manifest] Signed with %s: %s\n", cfg.Sign, sigPath)
+	}
+
+	return nil
+}
+
+// filterAllowed drops URLs whose host isn't on the allowlist, reporting each
+// one so a polluted link file is noticed rather than silently crawled.
+func filterAllowed(urls []string, allowlist *fetcher.Allowlist) []string {
+	allowed := urls[:0]
+	skipped := 0
+	for _, url := range urls {
+		if allowlist.Allows(url) {
+			allowed = append(allowed, url)
+			continue
+		}
+		fmt.Printf("[Allowlist] Skipping off-list URL: %s\n", url)
+		skipped++
+	}
+
+	if skipped > 0 {
+		fmt.Printf("[Allowlist] Skipped %d URLs not on the allowlist (%s); use -allow-hosts to permit more hosts\n", skipped, allowlist)
+	}
+
+	return allowed
+}
+
+// openSource returns the configured streaming URL source, or nil when URLs
+// come from the input file.
+func openSource(cfg *config.Config) (source.Source, error) {
+	if cfg.InputAMQP != "" {
+		amqp, err := source.NewAMQP(cfg.InputAMQP, cfg.AMQPQueue, cfg.AMQPPrefetch, cfg.Verbose)
+		if err != nil {
+			return nil, fmt.Errorf("amqp source: %w", err)
+		}
+		fmt.Printf("Consuming URLs from queue %s\n", cfg.AMQPQueue)
+		return amqp, nil
+	}
+
+	if cfg.InputSQLite != "" {
+		db, err := source.NewSQLite(cfg.InputSQLite, cfg.InputQuery, cfg.SQLiteStatusTable)
+		if err != nil {
+			return nil, fmt.Errorf("sqlite source: %w", err)
+		}
+		fmt.Printf("Loaded %d URLs from %s\n", db.Len(), cfg.InputSQLite)
+		return db, nil
+	}
+
+	return nil, nil
+}
+
+// loadState reads the crawl state from the output directory, starting a new
+// one if there is none yet.
+func loadState(s *storage.Storage) (*state.State, error) {
+	exists, err := s.Exists(state.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check for crawl state: %w", err)
+	}
+	if !exists {
+		return state.New(), nil
+	}
+
+	data, err := s.ReadFile(state.FileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read crawl state: %w", err)
+	}
+	return state.Decode(data)
+}
+
+// saveState writes the crawl state to the output directory, replacing the
+// previous one.
+func saveState(s *storage.Storage, crawlState *state.State) error {
+	data, err := crawlState.Encode()
+	if err != nil {
+		return err
+	}
+	return s.WriteFile(state.FileName, data)
+}
+
+// errCrawlCancelled is returned when the user declines to start a large
+// crawl; watch mode stops rather than asking again next run.
+var errCrawlCancelled = errors.New("crawl cancelled; pass -yes to start large crawls without asking")
+
+// confirmCrawl estimates the crawl of urls and, when it is expected to send
+// more than -confirm-above requests, shows the estimate and asks whether to
+// go ahead. Without a terminal to ask on, the crawl starts anyway.
+func confirmCrawl(cfg *config.Config, urls []string, stream io.Writer) error {
+	// Remote, encrypted and streamed outputs have no local records to
+	// estimate from
+	if cfg.Yes || cfg.ConfirmAbove == 0 || stream != nil || cfg.Encrypt || storage.IsRemote(cfg.OutputDir) {
+		return nil
+	}
+
+	e, err := estimate.Compute(urls, cfg.OutputDir, crawlSettings(cfg))
+	if err != nil {
+		fmt.Printf("[Estimate] %v\n", err)
+		return nil
+	}
+	if e.Requests <= cfg.ConfirmAbove {
+		return nil
+	}
+
 	fmt.Println()
-	fmt.Println("JSON files saved to:", cfg.OutputDir)
+	fmt.Printf("This crawl is expected to send more than %d requests:\n", cfg.ConfirmAbove)
+	e.Write(os.Stdout)
+
+	if info, err := os.Stdin.Stat(); err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		fmt.Println("Not running in a terminal; starting without confirmation")
+		cfg.Yes = true
+		return nil
+	}
+	fmt.Print("Start the crawl? [y/N] ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		// Later watch runs don't ask again
+		cfg.Yes = true
+		return nil
+	default:
+		return errCrawlCancelled
+	}
+}
+
+// crawlSettings are the options of cfg an estimate depends on.
+func crawlSettings(cfg *config.Config) estimate.Settings {
+	return estimate.Settings{
+		RateLimit:  cfg.RateLimit,
+		MaxRetries: cfg.MaxRetries,
+		Refresh:    cfg.Refresh,
+		PDF:        cfg.DownloadPDF,
+		PDFSize:    cfg.PDFSize,
+		Images:     cfg.DownloadImages,
+		Figures:    cfg.DownloadFigures,
+	}
+}
+
+// remainingURLsFile lists, in the output directory, the input URLs a run
+// stopped by its quota didn't get to.
+const remainingURLsFile = "remaining_urls.txt"
+
+// describeQuota formats the configured request and byte quotas.
+func describeQuota(cfg *config.Config) string {
+	var limits []string
+	if cfg.MaxRequests > 0 {
+		limits = append(limits, fmt.Sprintf("%d requests", cfg.MaxRequests))
+	}
+	if cfg.MaxBytes > 0 {
+		limits = append(limits, fmt.Sprintf("%d bytes", cfg.MaxBytes))
+	}
+	return "at most " + strings.Join(limits, ", ")
+}
+
+// crawlControl is the crawl managed through the server's /control endpoint.
+type crawlControl struct {
+	*worker.WorkerPool
+	src source.Source
+}
+
+// StopIntake stops the pool and, for streamed input, the source.
+func (c *crawlControl) StopIntake() {
+	c.WorkerPool.StopIntake()
+	if c.src != nil {
+		c.src.Stop()
+	}
+}
+
+// stopRequested reports whether a stop was requested through /control.
+func stopRequested(srv *server.Server) bool {
+	if srv == nil {
+		return false
+	}
+	select {
+	case <-srv.Stopped():
+		return true
+	default:
+		return false
+	}
+}
+
+// sleepUnlessStopped waits d between -watch runs and reports false if a stop
+// was requested meanwhile.
+func sleepUnlessStopped(srv *server.Server, d time.Duration) bool {
+	if srv == nil {
+		time.Sleep(d)
+		return true
+	}
+	select {
+	case <-time.After(d):
+		return true
+	case <-srv.Stopped():
+		return false
+	}
+}
+
+// metricsNamespace prefixes metric names in the Prometheus format.
+const metricsNamespace = "gtft_crawler"
+
+// crawlMetrics registers the counters monitoring systems are sent.
+func crawlMetrics(f *fetcher.Fetcher, s *storage.Storage) *metrics.Registry {
+	registry := metrics.NewRegistry()
+
+	stat := func(pick func(total, saved, failed, skipped int) int) func() float64 {
+		return func() float64 {
+			return float64(pick(s.Counts()))
+		}
+	}
+	registry.Gauge("urls", "URLs to process in this run (0 when streaming)",
+		stat(func(total, _, _, _ int) int { return total }))
+	registry.Counter("records_saved", "Records saved",
+		stat(func(_, saved, _, _ int) int { return saved }))
+	registry.Counter("records_failed", "URLs that failed to fetch, parse or save",
+		stat(func(_, _, failed, _ int) int { return failed }))
+	registry.Counter("records_skipped", "Records skipped as invalid or unchanged",
+		stat(func(_, _, _, skipped int) int { return skipped }))
+
+	registry.Counter("http_requests", "HTTP requests sent, including retries and hedges", func() float64 {
+		requests, _ := f.Requests()
+		return float64(requests)
+	})
+	registry.Counter("http_response_bytes", "Response body bytes received", func() float64 {
+		_, bytes := f.Requests()
+		return float64(bytes)
+	})
+	registry.Counter("fetch_retries", "Fetch attempts that were retries", func() float64 {
+		retries, _ := f.Retries()
+		return float64(retries)
+	})
+	registry.Counter("fetch_retries_denied", "Retries refused by the retry budget", func() float64 {
+		_, denied := f.Retries()
+		return float64(denied)
+	})
+	registry.Counter("cache_hits", "Fetches answered from the response cache", func() float64 {
+		return float64(f.CacheHits())
+	})
+	registry.Counter("fetches_not_modified", "Conditional fetches answered 304 Not Modified", func() float64 {
+		return float64(f.NotModified())
+	})
+	registry.Counter("pages_rendered", "Pages loaded in the headless browser because their static HTML lacked key content", func() float64 {
+		return float64(f.Rendered())
+	})
+	registry.Counter("pages_transcoded", "HTML pages converted to UTF-8 from another charset, such as GBK", func() float64 {
+		return float64(f.Transcoded())
+	})
+	registry.Counter("fetches_throttled", "Responses asking the crawler to slow down (429, or 503 with Retry-After)", func() float64 {
+		return float64(f.Throttled())
+	})
+	registry.Counter("fetches_deduplicated", "Fetches that shared a concurrent fetch of the same URL", func() float64 {
+		return float64(f.Deduplicated())
+	})
+	registry.Counter("hedged_requests", "Hedged second requests sent", func() float64 {
+		sent, _ := f.HedgeStats()
+		return float64(sent)
+	})
+	registry.Counter("hedged_requests_won", "Hedged requests that answered first", func() float64 {
+		_, won := f.HedgeStats()
+		return float64(won)
+	})
+
+	return registry
+}
+
+// splitList splits a comma-separated flag value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// loadCredentials merges the -auth-file credentials with those in
+// $GTFT_HTTP_AUTH, which take precedence.
+func loadCredentials(cfg *config.Config) (map[string]fetcher.Credential, error) {
+	creds := make(map[string]fetcher.Credential)
+	if cfg.AuthFile != "" {
+		fromFile, err := fetcher.LoadCredentials(cfg.AuthFile)
+		if err != nil {
+			return nil, err
+		}
+		maps.Copy(creds, fromFile)
+	}
+
+	fromEnv, err := fetcher.ParseCredentials(cfg.Auth)
+	if err != nil {
+		return nil, fmt.Errorf("GTFT_HTTP_AUTH: %w", err)
+	}
+	maps.Copy(creds, fromEnv)
+
+	hosts := slices.Sorted(maps.Keys(creds))
+	fmt.Printf("Authenticating requests to %s\n", strings.Join(hosts, ", "))
+	return creds, nil
+}
+
+// openCache opens the -cache database or the -cache-dir directory.
+func openCache(cfg *config.Config) (fetcher.ResponseCache, error) {
+	if cfg.CacheDir != "" {
+		return fetcher.OpenDirCache(cfg.CacheDir, cfg.CacheTTL)
+	}
+	return fetcher.OpenCache(cfg.Cache, cfg.CacheTTL)
+}
+
+// unchangedRecord returns the result for a page the server reported not
+// modified: the record it was last saved as, kept as it is. ok is false when
+// there is no such record to keep.
+func unchangedRecord(s *storage.Storage, crawlState *state.State, url string) (unchanged any, ok bool) {
+	if crawlState == nil {
+		return nil, false
+	}
+	entry, known := crawlState.Get(url)
+	if !known || entry.ID == "" {
+		return nil, false
+	}
+	if exists, err := s.HasRecord(url, entry.ID); err != nil || !exists {
+		return nil, false
+	}
+	return storage.Unchanged{ID: entry.ID}, true
+}
+
+// removedRecord returns the result of a page answering 404 or 410 whose
+// record is saved, which marks the record removed rather than leaving it
+// looking live.
+func removedRecord(s *storage.Storage, crawlState *state.State, url string, statusCode int, err error) (removed any, ok bool) {
+	if statusCode != http.StatusNotFound && statusCode != http.StatusGone {
+		return nil, false
+	}
+	// NDJSON streams keep no state and no records to mark
+	if crawlState == nil {
+		return nil, false
+	}
+	id := parser.IDFromURL(url)
+	if entry, known := crawlState.Get(url); known && entry.ID != "" {
+		id = entry.ID
+	}
+	if exists, err := s.HasRecord(url, id); err != nil || !exists {
+		return nil, false
+	}
+	return storage.Removed{ID: id, StatusCode: statusCode, Err: err}, true
+}
+
+// skipKnown returns the task generator's skip function, which keeps URLs
+// the output directory already accounts for from being fetched: those whose
+// record is saved, unless -refresh or -metrics-history needs the page again,
+// those an earlier run found gone, unless -retry-gone, and, in strict runs,
+// those an earlier strict run quarantined as incomplete, unless
+// -retry-incomplete.
+func skipKnown(cfg *config.Config, s *storage.Storage, crawlState *state.State) func(url string) (string, bool) {
+	return func(url string) (string, bool) {
+		entry, known := crawlState.Get(url)
+		if known && entry.Gone() && !cfg.RetryGone {
+			return fmt.Sprintf("gone (HTTP %d)", entry.StatusCode), true
+		}
+		if known && entry.Quarantined() && cfg.Strict && !cfg.RetryIncomplete {
+			return fmt.Sprintf("quarantined (missing %s)", strings.Join(entry.Missing, ", ")), true
+		}
+		if cfg.Refresh || cfg.MetricsHistory {
+			return "", false
+		}
+
+		id := entry.ID
+		if id == "" {
+			id = parser.IDFromURL(url)
+		}
+		if exists, err := s.HasRecord(url, id); err == nil && exists {
+			return "already saved", true
+		}
+		return "", false
+	}
+}
+
+// loadProxies merges the -proxy-file proxies with those given to -proxies.
+func loadProxies(cfg *config.Config) ([]*url.URL, error) {
+	var proxies []*url.URL
+	if cfg.ProxyFile != "" {
+		fromFile, err := fetcher.LoadProxies(cfg.ProxyFile)
+		if err != nil {
+			return nil, err
+		}
+		proxies = append(proxies, fromFile...)
+	}
+
+	fromFlag, err := fetcher.ParseProxies(cfg.Proxies)
+	if err != nil {
+		return nil, fmt.Errorf("invalid -proxies: %w", err)
+	}
+	proxies = append(proxies, fromFlag...)
+
+	if len(proxies) == 0 {
+		return nil, fmt.Errorf("no proxies listed in %s", cfg.ProxyFile)
+	}
+	return proxies, nil
+}
+
+// loadUserAgents collects the -user-agent-file and -user-agent User-Agents,
+// expanding built-in list names.
+func loadUserAgents(cfg *config.Config) ([]string, error) {
+	var agents []string
+	if cfg.UserAgentFile != "" {
+		fromFile, err := fetcher.LoadUserAgents(cfg.UserAgentFile)
+		if err != nil {
+			return nil, err
+		}
+		agents = append(agents, fromFile...)
+	}
+	agents = append(agents, fetcher.ExpandUserAgents(cfg.UserAgents)...)
+
+	if len(agents) == 0 {
+		return nil, fmt.Errorf("no User-Agents listed in %s", cfg.UserAgentFile)
+	}
+	return agents, nil
+}
+
+// fetchPage fetches url, conditionally when the fetcher keeps validators,
+// leaving the parsing to the caller. With stream, the page is instead parsed
+// with p as it downloads, under its final URL, and the record returned
+// along with the result.
+func fetchPage(f *fetcher.Fetcher, p *parser.Parser, url string, stream bool) (*fetcher.FetchResult, *parser.PaperMetadata, error) {
+	if !stream {
+		result, err := f.FetchConditional(url)
+		if err != nil {
+			return nil, nil, fmt.Errorf("fetch failed: %w", err)
+		}
+		return result, nil, nil
+	}
+
+	var metadata *parser.PaperMetadata
+	var parseErr error
+	result, err := f.FetchStream(url, func(body io.Reader, finalURL string) error {
+		metadata, parseErr = p.ParseReader(body, finalURL)
+		return parseErr
+	})
+	if parseErr != nil {
+		return nil, nil, fmt.Errorf("parse failed: %w", parseErr)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("fetch failed: %w", err)
+	}
+	return result, metadata, nil
+}
+
+// englishPage returns the parser's fetch function for English pages, which
+// waits on wait, the crawl's rate limiter, like any other request.
+func englishPage(f *fetcher.Fetcher, wait func() error) parser.EnglishPageFunc {
+	return func(url string) ([]byte, string, error) {
+		if err := wait(); err != nil {
+			return nil, "", err
+		}
+		result, err := f.Fetch(url)
+		if err != nil {
+			return nil, "", err
+		}
+		if result.Error != nil {
+			return nil, "", result.Error
+		}
+		return result.Body, result.FinalURL, nil
+	}
+}
+
+// loadHeaders collects the -header-file and -header headers, the flags
+// coming last so they win over the file.
+func loadHeaders(cfg *config.Config) (http.Header, error) {
+	headers := make(http.Header)
+	if cfg.HeaderFile != "" {
+		fromFile, err := fetcher.LoadHeaders(cfg.HeaderFile)
+		if err != nil {
+			return nil, err
+		}
+		headers = fromFile
+	}
+	fromFlags, err := fetcher.ParseHeaders(cfg.Headers)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range fromFlags {
+		headers[name] = values
+	}
+	return headers, nil
+}
+
+// parseLabels parses comma-separated name=value pairs.
+func parseLabels(value string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for _, pair := range splitList(value) {
+		name, labelValue, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(name) == "" {
+			return nil, fmt.Errorf("%q is not name=value", pair)
+		}
+		labels[strings.TrimSpace(name)] = strings.TrimSpace(labelValue)
+	}
+	return labels, nil
+}
+
+// addSinks registers the configured output sinks on s.
+func addSinks(cfg *config.Config, s *storage.Storage) error {
+	if cfg.SheetsID != "" {
+		sheets, err := sink.NewSheets(cfg.SheetsID, cfg.SheetsRange, cfg.SheetsCredentials)
+		if err != nil {
+			return fmt.Errorf("google sheets sink: %w", err)
+		}
+		s.AddSink(sheets)
+	}
+
+	if cfg.RedisURL != "" {
+		redis, err := sink.NewRedis(cfg.RedisURL, cfg.RedisPrefix)
+		if err != nil {
+			return fmt.Errorf("redis sink: %w", err)
+		}
+		s.AddSink(redis)
+	}
+
+	if cfg.Meilisearch != "" {
+		meili, err := sink.NewMeilisearch(cfg.Meilisearch, cfg.SearchIndex, cfg.SearchAPIKey)
+		if err != nil {
+			return fmt.Errorf("meilisearch sink: %w", err)
+		}
+		s.AddSink(meili)
+	}
+
+	if cfg.Typesense != "" {
+		typesense, err := sink.NewTypesense(cfg.Typesense, cfg.SearchIndex, cfg.SearchAPIKey)
+		if err != nil {
+			return fmt.Errorf("typesense sink: %w", err)
+		}
+		s.AddSink(typesense)
+	}
+
+	return nil
 }
 
 func readURLs(filename string) ([]string, error) {